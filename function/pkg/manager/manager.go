@@ -77,6 +77,7 @@ func (m *manager) useOperator(ctx context.Context, opr operator.Operator, option
 			DryRun:       m.getDryRunFlag(options.DryRun),
 			Callbacks:    callbacks,
 			WaitForApply: options.WaitForApply,
+			WaitForReady: options.WaitForReady,
 		},
 	}
 	return newRefs, opr.Apply(ctx, applyOpts)
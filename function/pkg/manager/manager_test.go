@@ -742,6 +742,27 @@ func Test_manager_useOperator(t *testing.T) {
 			want:    []metav1.OwnerReference{},
 			wantErr: false,
 		},
+		{
+			name: "should pass wait for ready to operator",
+			args: args{
+				opr: func() operator.Operator {
+					opr := fixMockOperatorWithoutActions(ctrl)
+					opr.EXPECT().Apply(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, opts operator.ApplyOptions) error {
+						if !opts.WaitForReady {
+							return errors.New("wait for ready not set")
+						}
+						return nil
+					}).Times(1)
+					return opr
+				}(),
+				options: Options{
+					WaitForReady: true,
+				},
+				references: nil,
+			},
+			want:    []metav1.OwnerReference{},
+			wantErr: false,
+		},
 		{
 			name: "should be error with operator",
 			args: args{
@@ -15,4 +15,6 @@ type Options struct {
 	DryRun             bool
 	SetOwnerReferences bool
 	WaitForApply       bool
+	// WaitForReady makes Do wait until each Function is built and running, see operator.Options
+	WaitForReady bool
 }
@@ -0,0 +1,222 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/function/pkg/client"
+	"github.com/kyma-incubator/hydroform/function/pkg/resources/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// readinessConditions are the conditions a Function goes through until it is ready, in order
+var readinessConditions = []types.ConditionType{
+	types.ConditionConfigurationReady,
+	types.ConditionBuildReady,
+	types.ConditionRunning,
+}
+
+// terminalReasons are the reasons of false conditions the controller does not recover from without a change of the Function.
+// The controller retries on the other reasons, such as a deployment waiting for its replicas.
+var terminalReasons = map[types.ConditionReason]bool{
+	types.ConditionReasonFunctionSpec:       true,
+	types.ConditionReasonSourceUpdateFailed: true,
+	types.ConditionReasonConfigMapError:     true,
+	types.ConditionReasonJobFailed:          true,
+	types.ConditionReasonDeploymentFailed:   true,
+	types.ConditionReasonServiceFailed:      true,
+	types.ConditionReasonHPAFailed:          true,
+}
+
+// FunctionNotReadyError is returned if a Function failed or did not become ready in time
+type FunctionNotReadyError struct {
+	Name      string
+	Namespace string
+	// Condition is the first condition of the Function that is not true, with its reason and message
+	Condition types.ConditionType
+	Reason    types.ConditionReason
+	Message   string
+	// JobLogs is a command printing the logs of the build job if the build failed
+	JobLogs string
+	// Err is the error of the context if the Function did not become ready in time
+	Err error
+}
+
+func (e *FunctionNotReadyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "function %s/%s is not ready", e.Namespace, e.Name)
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %s", e.Err)
+	}
+	if e.Condition != "" {
+		fmt.Fprintf(&b, ", %s is not true", e.Condition)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, " (%s)", e.Reason)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	if e.JobLogs != "" {
+		fmt.Fprintf(&b, ", see the logs of the build job: %s", e.JobLogs)
+	}
+	return b.String()
+}
+
+func (e *FunctionNotReadyError) Unwrap() error {
+	return e.Err
+}
+
+func isFunction(u unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == GVRFunction.Group && gvk.Version == GVRFunction.Version && gvk.Kind == "Function"
+}
+
+// waitForFunctionReady follows the conditions of the applied Function until all of them are true.
+// If the Function was updated, its conditions are only trusted once the controller updated them for the new spec.
+// It returns a *FunctionNotReadyError as soon as a condition failed, or when ctx is done.
+func waitForFunctionReady(ctx context.Context, c client.Client, u unstructured.Unstructured, updated bool) error {
+	notReady := &FunctionNotReadyError{
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+	var applied time.Time
+	if updated {
+		t, err := lastTransition(u)
+		if err != nil {
+			return err
+		}
+		applied = t
+	}
+
+	for {
+		w, err := c.Watch(ctx, objectListOptions(u))
+		if err != nil {
+			return err
+		}
+		done, err := watchFunction(ctx, w, applied, notReady)
+		w.Stop()
+		if done {
+			return err
+		}
+		// the API server closed the watch, watch again from the current state of the Function
+	}
+}
+
+// watchFunction returns true once the Function is ready or cannot become ready anymore, or false if the watch was closed
+func watchFunction(ctx context.Context, w watch.Interface, applied time.Time, notReady *FunctionNotReadyError) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			notReady.Err = ctx.Err()
+			return true, notReady
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+
+			switch event.Type {
+			case watch.Error:
+				return true, errors.FromObject(event.Object)
+			case watch.Deleted:
+				return true, fmt.Errorf("function %s/%s was deleted", notReady.Namespace, notReady.Name)
+			}
+
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			ready, err := functionReady(*obj, applied, notReady)
+			if ready || err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+// functionReady returns whether all conditions of the Function are true. Otherwise, it records the first condition that is not in notReady,
+// and returns notReady if the condition failed. Stale conditions, which the controller did not update since the Function was applied, are not ready.
+func functionReady(u unstructured.Unstructured, applied time.Time, notReady *FunctionNotReadyError) (bool, error) {
+	function, err := toFunction(u)
+	if err != nil {
+		return false, err
+	}
+
+	status := types.FunctionStatus{}
+	if function.Status != nil {
+		status = *function.Status
+	}
+	if stale(function.Generation, status, applied) {
+		return false, nil
+	}
+
+	for _, conditionType := range readinessConditions {
+		condition := status.Condition(conditionType)
+		if condition != nil && condition.Status == corev1.ConditionTrue {
+			continue
+		}
+
+		notReady.Condition = conditionType
+		notReady.Reason, notReady.Message, notReady.JobLogs = "", "", ""
+		if condition == nil {
+			return false, nil
+		}
+		notReady.Reason = condition.Reason
+		notReady.Message = condition.Message
+
+		if condition.Status != corev1.ConditionFalse || !terminalReasons[condition.Reason] {
+			return false, nil
+		}
+		if condition.Reason == types.ConditionReasonJobFailed {
+			notReady.JobLogs = buildJobLogs(u)
+		}
+		return false, notReady
+	}
+	return true, nil
+}
+
+// stale returns whether the status is for an older generation of the Function. Without an observed generation, the status is stale
+// until one of its conditions changed after the Function was applied, as the status of an updated Function is the one of its previous spec.
+func stale(generation int64, status types.FunctionStatus, applied time.Time) bool {
+	if status.ObservedGeneration != 0 {
+		return status.ObservedGeneration < generation
+	}
+	return !applied.IsZero() && !latestTransition(status).After(applied)
+}
+
+// lastTransition returns the time of the latest change of the conditions of the Function
+func lastTransition(u unstructured.Unstructured) (time.Time, error) {
+	function, err := toFunction(u)
+	if err != nil || function.Status == nil {
+		return time.Time{}, err
+	}
+	return latestTransition(*function.Status), nil
+}
+
+func latestTransition(status types.FunctionStatus) time.Time {
+	var latest time.Time
+	for _, c := range status.Conditions {
+		if c.LastTransitionTime.Time.After(latest) {
+			latest = c.LastTransitionTime.Time
+		}
+	}
+	return latest
+}
+
+func toFunction(u unstructured.Unstructured) (types.Function, error) {
+	var function types.Function
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &function)
+	return function, err
+}
+
+// buildJobLogs returns a command printing the logs of the build jobs of the Function
+func buildJobLogs(u unstructured.Unstructured) string {
+	return fmt.Sprintf("kubectl logs --namespace %s --selector serverless.kyma-project.io/function-name=%s,serverless.kyma-project.io/resource=build",
+		u.GetNamespace(), u.GetName())
+}
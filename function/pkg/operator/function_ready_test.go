@@ -0,0 +1,396 @@
+package operator
+
+import (
+	"context"
+	errs "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kyma-incubator/hydroform/function/pkg/client"
+	mockclient "github.com/kyma-incubator/hydroform/function/pkg/client/automock"
+	"github.com/kyma-incubator/hydroform/function/pkg/resources/types"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func Test_waitForFunctionReady(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name   string
+		events [][]watch.Event
+		// applied is the updated Function whose readiness is awaited, if any
+		applied       *unstructured.Unstructured
+		timeout       time.Duration
+		wantErr       string
+		wantCondition types.ConditionType
+		wantReason    types.ConditionReason
+		wantJobLogs   bool
+		wantTimeout   bool
+	}{
+		{
+			name: "should return nil once all conditions are true",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixFunction()},
+				{Type: watch.Modified, Object: fixFunction(fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"))},
+				{Type: watch.Modified, Object: fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "Unknown", "JobRunning"),
+				)},
+				{Type: watch.Modified, Object: fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "True", "JobFinished"),
+					fixCondition(types.ConditionRunning, "True", "DeploymentReady"),
+				)},
+			}},
+		},
+		{
+			name: "should return the build job when the build failed",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "False", "JobFailed"),
+				)},
+			}},
+			wantErr:       "function test-namespace/test-function is not ready, BuildReady is not true (JobFailed): message of BuildReady, see the logs of the build job: kubectl logs",
+			wantCondition: types.ConditionBuildReady,
+			wantReason:    types.ConditionReasonJobFailed,
+			wantJobLogs:   true,
+		},
+		{
+			name: "should return the failed condition",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "True", "JobFinished"),
+					fixCondition(types.ConditionRunning, "False", "DeploymentFailed"),
+				)},
+			}},
+			wantErr:       "Running is not true (DeploymentFailed)",
+			wantCondition: types.ConditionRunning,
+			wantReason:    "DeploymentFailed",
+		},
+		{
+			name: "should return the failed condition on a config map error",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixFunction(fixCondition(types.ConditionConfigurationReady, "False", "ConfigMapError"))},
+			}},
+			wantErr:       "ConfigurationReady is not true (ConfigMapError)",
+			wantCondition: types.ConditionConfigurationReady,
+			wantReason:    types.ConditionReasonConfigMapError,
+		},
+		{
+			name: "should wait for the status of the applied generation",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixGeneration(fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "True", "JobFinished"),
+					fixCondition(types.ConditionRunning, "True", "DeploymentReady"),
+				), 2, 1)},
+			}},
+			timeout:     100 * time.Millisecond,
+			wantErr:     "context deadline exceeded",
+			wantTimeout: true,
+		},
+		{
+			name: "should return the failed condition of the applied generation",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixGeneration(fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "False", "JobFailed"),
+				), 2, 1)},
+				{Type: watch.Modified, Object: fixGeneration(fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "False", "JobFailed"),
+				), 2, 2)},
+			}},
+			wantErr:       "BuildReady is not true (JobFailed)",
+			wantCondition: types.ConditionBuildReady,
+			wantReason:    types.ConditionReasonJobFailed,
+			wantJobLogs:   true,
+		},
+		{
+			name: "should ignore the conditions of the updated function until they change",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixFunction(
+					fixConditionAt(types.ConditionConfigurationReady, "True", "ConfigMapCreated", time.Unix(100, 0)),
+					fixConditionAt(types.ConditionBuildReady, "True", "JobFinished", time.Unix(100, 0)),
+					fixConditionAt(types.ConditionRunning, "True", "DeploymentReady", time.Unix(100, 0)),
+				)},
+				{Type: watch.Modified, Object: fixFunction(
+					fixConditionAt(types.ConditionConfigurationReady, "True", "ConfigMapUpdated", time.Unix(200, 0)),
+					fixConditionAt(types.ConditionBuildReady, "True", "JobFinished", time.Unix(100, 0)),
+					fixConditionAt(types.ConditionRunning, "True", "DeploymentReady", time.Unix(100, 0)),
+				)},
+			}},
+			applied: fixFunction(
+				fixConditionAt(types.ConditionConfigurationReady, "True", "ConfigMapCreated", time.Unix(100, 0)),
+				fixConditionAt(types.ConditionBuildReady, "True", "JobFinished", time.Unix(100, 0)),
+				fixConditionAt(types.ConditionRunning, "True", "DeploymentReady", time.Unix(100, 0)),
+			),
+		},
+		{
+			name: "should return the last condition that is not true on timeout",
+			events: [][]watch.Event{{
+				{Type: watch.Added, Object: fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "True", "JobFinished"),
+					fixCondition(types.ConditionRunning, "False", "MinimumReplicasUnavailable"),
+				)},
+			}},
+			timeout:       100 * time.Millisecond,
+			wantErr:       "context deadline exceeded, Running is not true (MinimumReplicasUnavailable)",
+			wantCondition: types.ConditionRunning,
+			wantReason:    "MinimumReplicasUnavailable",
+			wantTimeout:   true,
+		},
+		{
+			name: "should watch again when the watch was closed",
+			events: [][]watch.Event{
+				{{Type: watch.Added, Object: fixFunction()}},
+				{{Type: watch.Added, Object: fixFunction(
+					fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+					fixCondition(types.ConditionBuildReady, "True", "JobFinished"),
+					fixCondition(types.ConditionRunning, "True", "DeploymentReady"),
+				)}},
+			},
+		},
+		{
+			name: "should return error when the function was deleted",
+			events: [][]watch.Event{{
+				{Type: watch.Deleted, Object: fixFunction()},
+			}},
+			wantErr: "function test-namespace/test-function was deleted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			c := mockclient.NewMockClient(ctrl)
+			for i, events := range tt.events {
+				fakeWatcher := watch.NewFakeWithChanSize(len(events), false)
+				for _, event := range events {
+					fakeWatcher.Action(event.Type, event.Object)
+				}
+				// all but the last watch are closed by the server
+				if i < len(tt.events)-1 {
+					fakeWatcher.Stop()
+				}
+				c.EXPECT().
+					Watch(gomock.Any(), gomock.Any()).
+					Return(fakeWatcher, nil).
+					Times(1)
+			}
+
+			applied := fixFunction()
+			if tt.applied != nil {
+				applied = tt.applied
+			}
+			err := waitForFunctionReady(ctx, c, *applied, tt.applied != nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("waitForFunctionReady() error = %v, want nil", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("waitForFunctionReady() error = %v, want %s", err, tt.wantErr)
+			}
+			if tt.wantCondition == "" {
+				return
+			}
+			var notReady *FunctionNotReadyError
+			if !errs.As(err, &notReady) {
+				t.Fatalf("waitForFunctionReady() error = %T, want *FunctionNotReadyError", err)
+			}
+			if notReady.Condition != tt.wantCondition || notReady.Reason != tt.wantReason {
+				t.Errorf("waitForFunctionReady() condition = %s (%s), want %s (%s)", notReady.Condition, notReady.Reason, tt.wantCondition, tt.wantReason)
+			}
+			if (notReady.JobLogs != "") != tt.wantJobLogs {
+				t.Errorf("waitForFunctionReady() job logs = %q, want %v", notReady.JobLogs, tt.wantJobLogs)
+			}
+			if errs.Is(err, context.DeadlineExceeded) != tt.wantTimeout {
+				t.Errorf("waitForFunctionReady() timeout = %v, want %v", !tt.wantTimeout, tt.wantTimeout)
+			}
+		})
+	}
+
+	t.Run("should return error when watcher throws error", func(t *testing.T) {
+		c := mockclient.NewMockClient(ctrl)
+		c.EXPECT().
+			Watch(gomock.Any(), gomock.Any()).
+			Return(nil, errs.New("sample error")).
+			Times(1)
+
+		if err := waitForFunctionReady(context.Background(), c, *fixFunction(), false); err == nil {
+			t.Error("waitForFunctionReady() error = nil, want error")
+		}
+	})
+}
+
+func Test_genericOperator_Apply_waitForReady(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ready := fixFunction(
+		fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapCreated"),
+		fixCondition(types.ConditionBuildReady, "True", "JobFinished"),
+		fixCondition(types.ConditionRunning, "True", "DeploymentReady"),
+	)
+	c := func(events ...watch.Event) client.Client {
+		result := mockclient.NewMockClient(ctrl)
+		result.EXPECT().
+			Get(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(ready.DeepCopy(), nil).
+			Times(1)
+
+		fakeWatcher := watch.NewFakeWithChanSize(len(events), false)
+		for _, event := range events {
+			fakeWatcher.Action(event.Type, event.Object)
+		}
+		result.EXPECT().
+			Watch(gomock.Any(), gomock.Any()).
+			Return(fakeWatcher, nil).
+			Times(1)
+		return result
+	}
+
+	// the existing function is only ready once it is built
+	operator := NewGenericOperator(c(
+		watch.Event{Type: watch.Added, Object: fixFunction(
+			fixCondition(types.ConditionConfigurationReady, "True", "ConfigMapUpdated"),
+			fixCondition(types.ConditionBuildReady, "False", "JobFailed"),
+		)},
+	), *fixFunction())
+	err := operator.Apply(context.Background(), ApplyOptions{Options: Options{WaitForApply: true, WaitForReady: true}})
+	var notReady *FunctionNotReadyError
+	if !errs.As(err, &notReady) {
+		t.Fatalf("Apply() error = %v, want *FunctionNotReadyError", err)
+	}
+
+	operator = NewGenericOperator(c(watch.Event{Type: watch.Added, Object: ready}), *fixFunction())
+	if err := operator.Apply(context.Background(), ApplyOptions{Options: Options{WaitForReady: true}}); err != nil {
+		t.Errorf("Apply() error = %v, want nil", err)
+	}
+}
+
+func Test_genericOperator_Apply_waitForUpdatedFunction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	applied := time.Unix(100, 0)
+	fixReady := func(transition time.Time) *unstructured.Unstructured {
+		return fixFunction(
+			fixConditionAt(types.ConditionConfigurationReady, "True", "ConfigMapCreated", transition),
+			fixConditionAt(types.ConditionBuildReady, "True", "JobFinished", transition),
+			fixConditionAt(types.ConditionRunning, "True", "DeploymentReady", transition),
+		)
+	}
+
+	tests := []struct {
+		name       string
+		generation int64
+		events     []watch.Event
+	}{
+		{
+			name:       "should judge the conditions right away when the spec did not change",
+			generation: 1,
+			events:     []watch.Event{{Type: watch.Added, Object: fixReady(applied)}},
+		},
+		{
+			name:       "should ignore the conditions until they change when the spec changed",
+			generation: 2,
+			events: []watch.Event{
+				{Type: watch.Added, Object: fixFunction(
+					fixConditionAt(types.ConditionConfigurationReady, "True", "ConfigMapCreated", applied),
+					fixConditionAt(types.ConditionBuildReady, "False", "JobFailed", applied),
+				)},
+				{Type: watch.Modified, Object: fixReady(applied.Add(time.Minute))},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := fixReady(applied)
+			existing.SetGeneration(1)
+			existing.Object["spec"] = map[string]interface{}{"source": "module.exports = {old}"}
+			updated := fixReady(applied)
+			updated.SetGeneration(tt.generation)
+
+			c := mockclient.NewMockClient(ctrl)
+			c.EXPECT().
+				Get(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(existing, nil).
+				Times(1)
+			c.EXPECT().
+				Update(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(updated, nil).
+				Times(1)
+			fakeWatcher := watch.NewFakeWithChanSize(len(tt.events), false)
+			for _, event := range tt.events {
+				fakeWatcher.Action(event.Type, event.Object)
+			}
+			c.EXPECT().
+				Watch(gomock.Any(), gomock.Any()).
+				Return(fakeWatcher, nil).
+				Times(1)
+
+			// waiting for conditions that do not change would only end with the context
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			operator := NewGenericOperator(c, *fixFunction())
+			if err := operator.Apply(ctx, ApplyOptions{Options: Options{WaitForReady: true}}); err != nil {
+				t.Errorf("Apply() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func fixFunction(conditions ...interface{}) *unstructured.Unstructured {
+	function := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": "module.exports = {}",
+		},
+	}}
+	function.SetAPIVersion("serverless.kyma-project.io/v1alpha1")
+	function.SetKind("Function")
+	function.SetName("test-function")
+	function.SetNamespace("test-namespace")
+	if len(conditions) > 0 {
+		function.Object["status"] = map[string]interface{}{
+			"conditions": conditions,
+		}
+	}
+	return function
+}
+
+func fixCondition(conditionType types.ConditionType, status, reason string) interface{} {
+	return fixConditionAt(conditionType, status, reason, v1.Now().Time)
+}
+
+func fixConditionAt(conditionType types.ConditionType, status, reason string, transition time.Time) interface{} {
+	return map[string]interface{}{
+		"type":               string(conditionType),
+		"status":             status,
+		"reason":             reason,
+		"message":            "message of " + string(conditionType),
+		"lastTransitionTime": transition.UTC().Format(time.RFC3339),
+	}
+}
+
+func fixGeneration(function *unstructured.Unstructured, generation, observedGeneration int64) *unstructured.Unstructured {
+	function.SetGeneration(generation)
+	function.Object["status"].(map[string]interface{})["observedGeneration"] = observedGeneration
+	return function
+}
@@ -63,11 +63,16 @@ func (p genericOperator) Apply(ctx context.Context, opts ApplyOptions) error {
 }
 
 func (p genericOperator) apply(ctx context.Context, item unstructured.Unstructured, opts ApplyOptions) (*unstructured.Unstructured, client.PostStatusEntry, error) {
-	applied, statusEntry, err := applyObject(ctx, p.Client, item, opts.DryRun)
+	applied, statusEntry, previous, err := applyObjectGeneration(ctx, p.Client, item, opts.DryRun)
 	if err != nil {
 		return applied, statusEntry, err
 	}
-	if opts.WaitForApply {
+	switch {
+	case opts.WaitForReady && isFunction(*applied):
+		// an update of an unchanged spec keeps the generation, and the controller does not change the conditions
+		updated := statusEntry.StatusType == client.StatusTypeUpdated && applied.GetGeneration() > previous
+		err = waitForFunctionReady(ctx, p.Client, *applied, updated)
+	case opts.WaitForApply:
 		err = waitForObject(ctx, p.Client, *applied)
 	}
 	return applied, statusEntry, err
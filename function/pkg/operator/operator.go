@@ -25,13 +25,23 @@ type Operator interface {
 }
 
 func applyObject(ctx context.Context, c client.Client, u unstructured.Unstructured, stages []string) (*unstructured.Unstructured, client.PostStatusEntry, error) {
+	applied, statusEntry, _, err := applyObjectGeneration(ctx, c, u, stages)
+	return applied, statusEntry, err
+}
+
+// applyObjectGeneration works like applyObject, and also returns the generation of the object before it was applied, or 0 if it did not exist
+func applyObjectGeneration(ctx context.Context, c client.Client, u unstructured.Unstructured, stages []string) (*unstructured.Unstructured, client.PostStatusEntry, int64, error) {
 	// Check if object exists
 	response, err := c.Get(ctx, u.GetName(), metav1.GetOptions{})
 	objFound := response != nil
+	var previous int64
+	if objFound {
+		previous = response.GetGeneration()
+	}
 	isNotFoundErr := errors.IsNotFound(err)
 	if err != nil && !isNotFoundErr {
 		statusEntryFailed := client.NewPostStatusEntryApplyFailed(u)
-		return &u, statusEntryFailed, err
+		return &u, statusEntryFailed, previous, err
 	}
 
 	// If object is up to date return
@@ -43,7 +53,7 @@ func applyObject(ctx context.Context, c client.Client, u unstructured.Unstructur
 
 	if objFound && equal {
 		statusEntrySkipped := client.NewPostStatusEntrySkipped(*response)
-		return response, statusEntrySkipped, nil
+		return response, statusEntrySkipped, previous, nil
 	}
 
 	// If object needs update
@@ -58,11 +68,11 @@ func applyObject(ctx context.Context, c client.Client, u unstructured.Unstructur
 
 		if err != nil {
 			statusEntryFailed := client.NewPostStatusEntryApplyFailed(*response)
-			return &u, statusEntryFailed, err
+			return &u, statusEntryFailed, previous, err
 		}
 
 		statusEntryUpdated := client.NewPostStatusEntryUpdated(*response)
-		return response, statusEntryUpdated, nil
+		return response, statusEntryUpdated, previous, nil
 	}
 
 	response, err = c.Create(ctx, &u, metav1.CreateOptions{
@@ -70,24 +80,15 @@ func applyObject(ctx context.Context, c client.Client, u unstructured.Unstructur
 	})
 	if err != nil {
 		statusEntryFailed := client.NewPostStatusEntryApplyFailed(u)
-		return &u, statusEntryFailed, err
+		return &u, statusEntryFailed, previous, err
 	}
 
 	statusEntryCreated := client.NewStatusEntryCreated(*response)
-	return response, statusEntryCreated, nil
+	return response, statusEntryCreated, previous, nil
 }
 
 func waitForObject(ctx context.Context, c client.Client, u unstructured.Unstructured) error {
-	w, err := c.Watch(ctx, metav1.ListOptions{
-		TypeMeta: v1.TypeMeta{
-			Kind:       u.GetKind(),
-			APIVersion: u.GetAPIVersion(),
-		},
-		FieldSelector: fields.AndSelectors(
-			fields.OneTermEqualSelector("metadata.name", u.GetName()),
-			fields.OneTermEqualSelector("metadata.namespace", u.GetNamespace()),
-		).String(),
-	})
+	w, err := c.Watch(ctx, objectListOptions(u))
 	if err != nil {
 		return err
 	}
@@ -109,6 +110,20 @@ functionBlock:
 	return nil
 }
 
+// objectListOptions selects the given object only
+func objectListOptions(u unstructured.Unstructured) metav1.ListOptions {
+	return metav1.ListOptions{
+		TypeMeta: v1.TypeMeta{
+			Kind:       u.GetKind(),
+			APIVersion: u.GetAPIVersion(),
+		},
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermEqualSelector("metadata.name", u.GetName()),
+			fields.OneTermEqualSelector("metadata.namespace", u.GetNamespace()),
+		).String(),
+	}
+}
+
 func wipeRemoved(ctx context.Context, c client.Client, deletePredicate func(obj map[string]interface{}) (bool, error), opts Options) error {
 	list, err := c.List(ctx, v1.ListOptions{})
 	if err != nil {
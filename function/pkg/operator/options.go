@@ -13,6 +13,8 @@ type Options struct {
 	Callbacks
 	DryRun       []string
 	WaitForApply bool
+	// WaitForReady makes Apply wait until Functions are built and running, instead of only until they exist
+	WaitForReady bool
 }
 
 type ApplyOptions struct {
@@ -44,5 +44,50 @@ type Function struct {
 	APIVersion        string `json:"apiVersion"`
 	Kind              string
 	metav1.ObjectMeta `json:"metadata,omitempty"`
-	Spec              FunctionSpec `json:"spec,omitempty"`
+	Spec              FunctionSpec    `json:"spec,omitempty"`
+	Status            *FunctionStatus `json:"status,omitempty"`
+}
+
+type ConditionType string
+
+const (
+	ConditionConfigurationReady ConditionType = "ConfigurationReady"
+	ConditionBuildReady         ConditionType = "BuildReady"
+	ConditionRunning            ConditionType = "Running"
+)
+
+type ConditionReason string
+
+const (
+	ConditionReasonFunctionSpec       ConditionReason = "InvalidFunctionSpec"
+	ConditionReasonSourceUpdateFailed ConditionReason = "SourceUpdateFailed"
+	ConditionReasonConfigMapError     ConditionReason = "ConfigMapError"
+	ConditionReasonJobFailed          ConditionReason = "JobFailed"
+	ConditionReasonDeploymentFailed   ConditionReason = "DeploymentFailed"
+	ConditionReasonServiceFailed      ConditionReason = "ServiceFailed"
+	ConditionReasonHPAFailed          ConditionReason = "HorizontalPodAutoscalerFailed"
+)
+
+type Condition struct {
+	Type               ConditionType          `json:"type,omitempty"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             ConditionReason        `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
+type FunctionStatus struct {
+	Conditions []Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the Function the conditions are for, if the controller reports it
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// Condition returns the condition of the given type, or nil if the Function has none
+func (s FunctionStatus) Condition(t ConditionType) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
 }
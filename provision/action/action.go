@@ -1,6 +1,11 @@
 package action
 
+import "sync"
+
+// The actions set in this package apply to the next Hydroform operation, whichever goroutine runs it.
+// To set actions for a single operation, pass them as options with types.WithBeforeAction, types.WithAfterAction, and types.OnError instead.
 var (
+	mu     sync.Mutex
 	before Action
 	after  Action
 	args   []interface{}
@@ -13,19 +18,22 @@ type Action interface {
 
 // SetBefore defines which action will be executed before an Hydroform operation.
 func SetBefore(a Action) {
+	mu.Lock()
+	defer mu.Unlock()
 	before = a
 }
 
 // Before runs the action set with SetBefore. It is called and evaluated before each Hydroform operation (Provision, Status, Credentials and Deprovision)
 // After running, the set action is cleared.
 func Before() error {
-	// clear the action after running it
-	defer func() {
-		before = nil
-	}()
+	// clear the action before running it, so that it runs once
+	mu.Lock()
+	a, aArgs := before, args
+	before = nil
+	mu.Unlock()
 
-	if before != nil {
-		_, err := before.Run(args...)
+	if a != nil {
+		_, err := a.Run(aArgs...)
 		return err
 	}
 	return nil
@@ -33,19 +41,22 @@ func Before() error {
 
 // SetAfter defines which action will be executed after an Hydroform operation.
 func SetAfter(a Action) {
+	mu.Lock()
+	defer mu.Unlock()
 	after = a
 }
 
 // After runs the action set with SetAfter. It is called and evaluated after each Hydroform operation if there are no errors (Provision, Status, Credentials and Deprovision)
 // After running, the set action is cleared.
 func After() error {
-	// clear the action after running it
-	defer func() {
-		after = nil
-	}()
+	// clear the action before running it, so that it runs once
+	mu.Lock()
+	a, aArgs := after, args
+	after = nil
+	mu.Unlock()
 
-	if after != nil {
-		_, err := after.Run(args...)
+	if a != nil {
+		_, err := a.Run(aArgs...)
 		return err
 	}
 	return nil
@@ -54,11 +65,15 @@ func After() error {
 // SetArgs allows to define arbitrary arguments that Before and After actions will consume.
 // Calling SetArgs a second time clears the args from the previous call.
 func SetArgs(a ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
 	args = a
 }
 
 // Args returns the defined arguments for the actions
 func Args() []interface{} {
+	mu.Lock()
+	defer mu.Unlock()
 	return args
 }
 
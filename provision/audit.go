@@ -0,0 +1,125 @@
+package provision
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"regexp"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+const (
+	// auditTimeout limits how long the sinks can take to record an operation, which is recorded even if its context is done.
+	auditTimeout = 30 * time.Second
+	redacted     = "REDACTED"
+)
+
+// secretConfiguration matches the keys of the custom configurations holding secrets, such as client_secret or access_key.
+var secretConfiguration = regexp.MustCompile(`(?i)secret|password|passwd|token|key|credential`)
+
+// audit records the operation of the event in the audit sinks of the options, and returns the first error of the sinks.
+func audit(options *types.Options, event *types.Event, started time.Time) error {
+	if len(options.AuditSinks) == 0 {
+		return nil
+	}
+
+	r := &types.AuditRecord{
+		Time:      started,
+		Duration:  time.Since(started),
+		Actor:     options.AuditActor,
+		Operation: event.Operation,
+		Outcome:   types.AuditSucceeded,
+		Provider:  redactProvider(event.Provider),
+	}
+	if r.Actor == "" {
+		r.Actor = currentUser()
+	}
+	if event.Cluster != nil {
+		cluster := *event.Cluster
+		// the cluster info holds the state and the certificates of the cluster, and is no parameter
+		cluster.ClusterInfo = nil
+		r.Cluster = &cluster
+	}
+	if event.Err != nil {
+		r.Outcome = types.AuditFailed
+		r.Error = event.Err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+
+	var firstErr error
+	for _, s := range options.AuditSinks {
+		// record in all sinks even if one fails
+		if err := s.Record(ctx, r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// redactProvider returns a copy of the provider whose custom configurations holding secrets are redacted.
+// The typed configuration is left out, its values are part of the custom configurations already.
+func redactProvider(provider *types.Provider) *types.Provider {
+	if provider == nil {
+		return nil
+	}
+
+	r := *provider
+	r.Config = nil
+	if provider.CustomConfigurations != nil {
+		r.CustomConfigurations = redactConfigurations(provider.CustomConfigurations)
+	}
+	return &r
+}
+
+// redactConfigurations copies the given configurations, replacing the values of secret keys, including the ones of nested maps.
+func redactConfigurations(cfg map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(cfg))
+	for k, v := range cfg {
+		switch {
+		case secretConfiguration.MatchString(k):
+			r[k] = redacted
+		case isMap(v):
+			r[k] = redactMap(v)
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
+
+func isMap(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, map[string]string:
+		return true
+	}
+	return false
+}
+
+func redactMap(v interface{}) interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return redactConfigurations(m)
+	case map[string]string:
+		r := make(map[string]string, len(m))
+		for k, v := range m {
+			if secretConfiguration.MatchString(k) {
+				v = redacted
+			}
+			r[k] = v
+		}
+		return r
+	}
+	return v
+}
+
+// currentUser returns the name of the user running the process, who is the actor of the operations by default.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
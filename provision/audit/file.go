@@ -0,0 +1,46 @@
+// Package audit provides types.AuditSink implementations, which record the Hydroform operations for compliance:
+// File appends them to a JSON lines file, and KubernetesEvents creates an Event for each of them.
+//
+// Pass them with types.WithAuditSink, along with types.WithAuditActor to record who requested each operation.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+// File is a types.AuditSink appending each record to a file as a line of JSON.
+// Records are appended with a single write, so several processes can record in the same local file.
+type File struct {
+	// Path of the file, which is created if needed.
+	Path string
+
+	mu sync.Mutex
+}
+
+// Record appends the record to the file.
+func (f *File) Record(ctx context.Context, r *types.AuditRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "could not encode the audit record")
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "could not open the audit file")
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return errors.Wrap(err, "could not write the audit record")
+	}
+	return file.Close()
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "hydroform-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &File{Path: filepath.Join(dir, "audit.jsonl")}
+	record := testRecord()
+	require.NoError(t, f.Record(context.Background(), record))
+
+	// concurrent operations append whole lines
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, f.Record(context.Background(), record))
+		}()
+	}
+	wg.Wait()
+
+	file, err := os.Open(f.Path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		r := &types.AuditRecord{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), r), "Each line should be a record")
+		require.Equal(t, record, r)
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, 11, lines)
+
+	// the directory of the file has to exist
+	f = &File{Path: filepath.Join(dir, "missing", "audit.jsonl")}
+	require.Error(t, f.Record(context.Background(), record))
+}
+
+func testRecord() *types.AuditRecord {
+	return &types.AuditRecord{
+		Time:      time.Date(2020, 11, 2, 9, 30, 0, 0, time.UTC),
+		Duration:  8 * time.Minute,
+		Actor:     "alice",
+		Operation: types.ProvisionOperation,
+		Cluster:   &types.Cluster{Name: "hydro", KubernetesVersion: "1.18", NodeCount: 3, Location: "westeurope"},
+		Provider: &types.Provider{
+			Type:                 types.Azure,
+			ProjectName:          "my-resource-group",
+			CredentialsFilePath:  "/path/to/credentials.json",
+			CustomConfigurations: map[string]interface{}{"client_secret": "REDACTED"},
+		},
+		Outcome: types.AuditSucceeded,
+	}
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	defaultEventNamespace = "default"
+	component             = "hydroform"
+	// RecordAnnotation holds the JSON of the record on the Events created by KubernetesEvents.
+	RecordAnnotation = "hydroform.kyma-project.io/audit-record"
+	// maxMessageLength keeps long errors from making the Event too large to read
+	maxMessageLength = 1024
+)
+
+// KubernetesEvents is a types.AuditSink creating an Event in a Kubernetes cluster for each record.
+// The Event refers to the Hydroform cluster the operation ran on, its reason is the operation, such as Provision or ProvisionFailed,
+// and the full record is in its RecordAnnotation. Keep in mind that Kubernetes deletes Events after an hour by default,
+// so collect them with an event exporter to keep them longer.
+type KubernetesEvents struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewKubernetesEvents returns a sink creating Events in the given namespace of the cluster of the kubeconfig. The namespace defaults to "default".
+func NewKubernetesEvents(kubeconfigPath, namespace string) (*KubernetesEvents, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load the kubeconfig of the audit cluster")
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKubernetesEvents(client, namespace), nil
+}
+
+func newKubernetesEvents(client kubernetes.Interface, namespace string) *KubernetesEvents {
+	if namespace == "" {
+		namespace = defaultEventNamespace
+	}
+	return &KubernetesEvents{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Record creates the Event of the record.
+func (k *KubernetesEvents) Record(ctx context.Context, r *types.AuditRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "could not encode the audit record")
+	}
+
+	var clusterName string
+	if r.Cluster != nil {
+		clusterName = r.Cluster.Name
+	}
+	objectName := clusterName
+	if objectName == "" {
+		objectName = component
+	}
+	var providerType types.ProviderType
+	if r.Provider != nil {
+		providerType = r.Provider.Type
+	}
+
+	reason := strings.Title(string(r.Operation))
+	eventType := corev1.EventTypeNormal
+	message := fmt.Sprintf("%s of the %s cluster %s by %s %s", r.Operation, providerType, clusterName, r.Actor, r.Outcome)
+	if r.Outcome == types.AuditFailed {
+		reason += "Failed"
+		eventType = corev1.EventTypeWarning
+		message += ": " + r.Error
+	}
+	if len(message) > maxMessageLength {
+		message = message[:maxMessageLength]
+	}
+
+	timestamp := metav1.NewTime(r.Time)
+	e := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// like the events of the Kubernetes recorder
+			Name:        fmt.Sprintf("%s.%x", objectName, time.Now().UnixNano()),
+			Namespace:   k.namespace,
+			Labels:      map[string]string{"app.kubernetes.io/managed-by": component},
+			Annotations: map[string]string{RecordAnnotation: string(data)},
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "hydroform.kyma-project.io/v1alpha1",
+			Kind:       "Cluster",
+			Namespace:  k.namespace,
+			Name:       clusterName,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: component},
+		FirstTimestamp: timestamp,
+		LastTimestamp:  timestamp,
+		Count:          1,
+	}
+
+	_, err = k.client.CoreV1().Events(k.namespace).Create(ctx, e, metav1.CreateOptions{})
+	return errors.Wrap(err, "could not create the audit event")
+}
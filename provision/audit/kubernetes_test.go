@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetesEvents(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	k := newKubernetesEvents(client, "")
+
+	record := testRecord()
+	require.NoError(t, k.Record(context.Background(), record))
+
+	failed := testRecord()
+	failed.Operation = types.DeprovisionOperation
+	failed.Outcome = types.AuditFailed
+	failed.Error = "the resource group is locked"
+	require.NoError(t, k.Record(context.Background(), failed))
+
+	events, err := client.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 2, "The events should be created in the default namespace")
+
+	e := events.Items[0]
+	require.Equal(t, "Provision", e.Reason)
+	require.Equal(t, corev1.EventTypeNormal, e.Type)
+	require.Equal(t, "provision of the azure cluster hydro by alice succeeded", e.Message)
+	require.Equal(t, "hydro", e.InvolvedObject.Name)
+	require.Equal(t, "Cluster", e.InvolvedObject.Kind)
+	require.Equal(t, record.Time, e.FirstTimestamp.Time.UTC())
+
+	r := &types.AuditRecord{}
+	require.NoError(t, json.Unmarshal([]byte(e.Annotations[RecordAnnotation]), r), "The record should be annotated")
+	require.Equal(t, record, r)
+
+	e = events.Items[1]
+	require.Equal(t, "DeprovisionFailed", e.Reason)
+	require.Equal(t, corev1.EventTypeWarning, e.Type)
+	require.Equal(t, "deprovision of the azure cluster hydro by alice failed: the resource group is locked", e.Message)
+
+	// errors of the API are returned
+	client.PrependReactor("create", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	err = k.Record(context.Background(), record)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "forbidden")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	hf "github.com/kyma-incubator/hydroform/provision"
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+func main() {
+	name := flag.String("n", "kyma", "k3d cluster name")
+	agents := flag.Int("a", 1, "number of agent nodes")
+	deprovision := flag.Bool("d", false, "Deprovision option. Deletes the cluster if it exists.")
+	flag.Parse()
+
+	log.SetOutput(ioutil.Discard)
+
+	cluster := &types.Cluster{
+		Name:      *name,
+		NodeCount: *agents,
+	}
+	provider := &types.Provider{
+		Type: types.K3d,
+		Config: types.K3dConfig{
+			PortMappings: []string{"80:80@loadbalancer", "443:443@loadbalancer"},
+		},
+	}
+
+	if !*deprovision {
+		fmt.Println("Provisioning...")
+
+		cluster, err := hf.Provision(cluster, provider)
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return
+		}
+
+		fmt.Println("Provisioned successfully")
+
+		fmt.Println("Downloading the kubeconfig")
+
+		content, err := hf.Credentials(cluster, provider)
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return
+		}
+
+		err = ioutil.WriteFile("kubeconfig.yaml", content, 0600)
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return
+		}
+
+		fmt.Println("Kubeconfig downloaded")
+	} else {
+
+		fmt.Println("Deprovisioning...")
+
+		err := hf.Deprovision(cluster, provider)
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return
+		}
+
+		fmt.Println("Deprovisioned successfully")
+	}
+}
@@ -13,6 +13,8 @@ import (
 func main() {
 	projectName := flag.String("p", "", "kind project name")
 	nodeImage := flag.String("n", "", "kind node image of the cluster")
+	workers := flag.Int("w", 0, "number of worker nodes besides the control plane node")
+	ingress := flag.Bool("ingress", false, "Ingress option. Maps the ports 80 and 443 of the host to the cluster.")
 	persist := flag.Bool("persist", false, "Persistence option. With persistence enabled, hydroform will keep state and configuraion of clusters on the file system.")
 	deprovision := flag.Bool("d", false, "Deprovision option. Deletes the cluster if it exists.")
 	flag.Parse()
@@ -20,7 +22,8 @@ func main() {
 	log.SetOutput(ioutil.Discard)
 
 	cluster := &types.Cluster{
-		Name: "test-cluster",
+		Name:      "test-cluster",
+		NodeCount: *workers,
 	}
 	provider := &types.Provider{
 		Type:        types.Kind,
@@ -29,6 +32,9 @@ func main() {
 			"node_image": *nodeImage,
 		},
 	}
+	if *ingress {
+		provider.CustomConfigurations["extra_port_mappings"] = []string{"80:80", "443:443"}
+	}
 
 	var ops []types.Option
 	// add persistence option
@@ -0,0 +1,121 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+// ProvisionFleet provisions all clusters of the fleet in parallel, running at most concurrency operations at the same time. A concurrency of zero or less provisions all clusters at once.
+// The clusters are provisioned in isolation: the failure of one cluster does not stop the others, and each one works on its own copy of its provider.
+// The options apply to all members, followed by the options of each member. The report has a result for each member, with the provisioned cluster or its error.
+// If provisioning failed for any member, the returned error is a *types.FleetError listing the failures by provider, project, and cluster name.
+func ProvisionFleet(ctx context.Context, fleet []types.FleetMember, concurrency int, ops ...types.Option) (*types.FleetReport, error) {
+	return runFleet(ctx, types.ProvisionOperation, fleet, concurrency, ops, func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error) {
+		return ProvisionWithContext(ctx, m.Cluster, m.Provider, ops...)
+	})
+}
+
+// DeprovisionFleet deletes all clusters of the fleet in parallel, running at most concurrency operations at the same time. A concurrency of zero or less deletes all clusters at once.
+// Pass the clusters returned by ProvisionFleet. Like ProvisionFleet, the failure of one cluster does not stop the others.
+// If deprovisioning failed for any member, the returned error is a *types.FleetError listing the failures by provider, project, and cluster name.
+func DeprovisionFleet(ctx context.Context, fleet []types.FleetMember, concurrency int, ops ...types.Option) (*types.FleetReport, error) {
+	return runFleet(ctx, types.DeprovisionOperation, fleet, concurrency, ops, func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error) {
+		return m.Cluster, DeprovisionWithContext(ctx, m.Cluster, m.Provider, ops...)
+	})
+}
+
+// fleetOperation runs an operation on a single member of a fleet with the given options.
+type fleetOperation func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error)
+
+// runFleet runs the operation on all members of the fleet with bounded concurrency and collects their results in the order of the members.
+func runFleet(ctx context.Context, op types.Operation, fleet []types.FleetMember, concurrency int, ops []types.Option, f fleetOperation) (*types.FleetReport, error) {
+	if err := validateFleet(fleet); err != nil {
+		return nil, err
+	}
+
+	report := &types.FleetReport{
+		Operation: op,
+		Results:   make([]types.FleetResult, len(fleet)),
+	}
+	if concurrency <= 0 || concurrency > len(fleet) {
+		concurrency = len(fleet)
+	}
+	slots := make(chan struct{}, concurrency)
+
+	wg := sync.WaitGroup{}
+	for i, m := range fleet {
+		wg.Add(1)
+		go func(i int, m types.FleetMember) {
+			defer wg.Done()
+			res := &report.Results[i]
+			res.Cluster = m.Cluster
+			res.Provider = m.Provider
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				res.Err = ctx.Err()
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				res.Err = err
+				return
+			}
+
+			start := time.Now()
+			cl, err := runFleetMember(ctx, isolate(m), append(append([]types.Option{}, ops...), m.Ops...), f)
+			res.Duration = time.Since(start)
+			res.Err = err
+			if cl != nil {
+				res.Cluster = cl
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	return report, report.Err()
+}
+
+// runFleetMember runs the operation on a single member, turning a panic into an error so that it does not take down the other members.
+func runFleetMember(ctx context.Context, m types.FleetMember, ops []types.Option, f fleetOperation) (cl *types.Cluster, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return f(ctx, m, ops)
+}
+
+// isolate gives the member its own copy of its provider, since the provisioners change the custom configurations and several members often share one provider.
+func isolate(m types.FleetMember) types.FleetMember {
+	p := *m.Provider
+	if m.Provider.CustomConfigurations != nil {
+		p.CustomConfigurations = make(map[string]interface{}, len(m.Provider.CustomConfigurations))
+		for k, v := range m.Provider.CustomConfigurations {
+			p.CustomConfigurations[k] = v
+		}
+	}
+	m.Provider = &p
+	return m
+}
+
+// validateFleet checks that every member has a cluster and a provider, and that no cluster appears twice, since concurrent operations on the same cluster would corrupt its state.
+func validateFleet(fleet []types.FleetMember) error {
+	seen := map[types.FleetKey]bool{}
+	for i, m := range fleet {
+		if m.Cluster == nil || m.Provider == nil {
+			return fmt.Errorf("member %d of the fleet needs a cluster and a provider", i)
+		}
+		k := m.Key()
+		if seen[k] {
+			return fmt.Errorf("the %s cluster %s of project %s appears more than once in the fleet", k.Provider, k.Cluster, k.Project)
+		}
+		seen[k] = true
+	}
+	return nil
+}
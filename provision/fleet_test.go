@@ -0,0 +1,169 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func fleet(names ...string) []types.FleetMember {
+	var f []types.FleetMember
+	for _, n := range names {
+		cluster, provider := awsCluster(n)
+		f = append(f, types.FleetMember{Cluster: cluster, Provider: provider})
+	}
+	return f
+}
+
+func TestRunFleet(t *testing.T) {
+	t.Parallel()
+
+	var running, maxRunning int32
+	op := func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		switch m.Cluster.Name {
+		case "broken":
+			return nil, errors.New("quota exceeded")
+		case "panicking":
+			panic("nil pointer")
+		}
+		cl := *m.Cluster
+		cl.ClusterInfo = &types.ClusterInfo{Endpoint: "https://" + m.Cluster.Name}
+		return &cl, nil
+	}
+
+	report, err := runFleet(context.Background(), types.ProvisionOperation, fleet("one", "broken", "two", "panicking", "three"), 2, nil, op)
+	require.Error(t, err, "A failing member should fail the fleet")
+	require.LessOrEqual(t, maxRunning, int32(2), "No more members than the concurrency should run at once")
+
+	var fleetErr *types.FleetError
+	require.True(t, errors.As(err, &fleetErr))
+	require.Equal(t, 5, fleetErr.Total)
+	require.Len(t, fleetErr.Errors, 2)
+	require.EqualError(t, fleetErr.Errors[types.FleetKey{Provider: types.AWS, Project: "my-project", Cluster: "broken"}], "quota exceeded")
+	require.Contains(t, fleetErr.Errors[types.FleetKey{Provider: types.AWS, Project: "my-project", Cluster: "panicking"}].Error(), "panic: nil pointer")
+	require.Contains(t, err.Error(), "aws/my-project/broken: quota exceeded")
+
+	require.Len(t, report.Results, 5)
+	require.Len(t, report.Succeeded(), 3, "The other members should not be affected by the failures")
+	for i, name := range []string{"one", "broken", "two", "panicking", "three"} {
+		require.Equal(t, name, report.Results[i].Cluster.Name, "The results should keep the order of the members")
+	}
+	require.Equal(t, "https://two", report.Results[2].Cluster.ClusterInfo.Endpoint, "The result should contain the returned cluster")
+}
+
+func TestRunFleetErrorKeys(t *testing.T) {
+	t.Parallel()
+	f := fleet("same", "same")
+	f[1].Provider.ProjectName = "other-project"
+
+	_, err := runFleet(context.Background(), types.ProvisionOperation, f, 0, nil, func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error) {
+		return nil, fmt.Errorf("failed in %s", m.Provider.ProjectName)
+	})
+
+	var fleetErr *types.FleetError
+	require.True(t, errors.As(err, &fleetErr))
+	require.Len(t, fleetErr.Errors, 2, "Clusters with the same name in different projects should be kept apart")
+	require.EqualError(t, fleetErr.Errors[f[0].Key()], "failed in my-project")
+	require.EqualError(t, fleetErr.Errors[f[1].Key()], "failed in other-project")
+}
+
+func TestRunFleetIsolation(t *testing.T) {
+	t.Parallel()
+
+	f := fleet("one", "two", "three")
+	shared := &types.Provider{Type: types.GCP, ProjectName: "my-project", CustomConfigurations: map[string]interface{}{"zone": "a"}}
+	for i := range f {
+		f[i].Provider = shared
+	}
+	f[1].Ops = []types.Option{types.WithDataDir("two")}
+
+	mu := sync.Mutex{}
+	dirs := map[string]string{}
+	op := func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error) {
+		m.Provider.CustomConfigurations["cluster"] = m.Cluster.Name
+		o := &types.Options{}
+		for _, opt := range ops {
+			opt(o)
+		}
+		mu.Lock()
+		dirs[m.Cluster.Name] = o.DataDir
+		mu.Unlock()
+		return m.Cluster, nil
+	}
+
+	report, err := runFleet(context.Background(), types.ProvisionOperation, f, 0, []types.Option{types.WithDataDir("fleet")}, op)
+	require.NoError(t, err)
+	require.Len(t, report.Succeeded(), 3)
+	require.Equal(t, map[string]interface{}{"zone": "a"}, shared.CustomConfigurations, "The members should not change the shared provider")
+	require.Equal(t, shared, report.Results[0].Provider, "The result should contain the given provider")
+	require.Equal(t, map[string]string{"one": "fleet", "two": "two", "three": "fleet"}, dirs, "The options of a member should take precedence")
+}
+
+func TestRunFleetCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	op := func(ctx context.Context, m types.FleetMember, ops []types.Option) (*types.Cluster, error) {
+		cancel()
+		return m.Cluster, nil
+	}
+
+	report, err := runFleet(ctx, types.DeprovisionOperation, fleet("one", "two", "three"), 1, nil, op)
+	require.Error(t, err)
+	require.Len(t, report.Succeeded(), 1, "Only the member started before the cancellation should run")
+	for _, r := range report.Failed() {
+		require.Equal(t, context.Canceled, r.Err)
+	}
+}
+
+func TestValidateFleet(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, validateFleet(fleet("one", "two")))
+	require.Error(t, validateFleet(fleet("one", "one")), "A cluster should not appear twice")
+
+	f := fleet("one", "two")
+	f[1].Provider = nil
+	require.Error(t, validateFleet(f), "A member needs a provider")
+
+	_, err := ProvisionFleet(context.Background(), fleet("one", "one"), 1)
+	require.Error(t, err)
+}
+
+func TestProvisionFleet(t *testing.T) {
+	t.Parallel()
+
+	// k3d members with invalid names fail validation without running k3d
+	f := fleet("Invalid_Name", "another_invalid_name")
+	for i := range f {
+		f[i].Provider = &types.Provider{Type: types.K3d}
+	}
+	report, err := ProvisionFleet(context.Background(), f, 2)
+	require.Error(t, err)
+	require.Len(t, report.Failed(), 2)
+	for _, r := range report.Results {
+		require.Contains(t, r.Err.Error(), "input validation failed", fmt.Sprintf("%s should fail validation", r.Cluster.Name))
+	}
+
+	report, err = DeprovisionFleet(context.Background(), f, 0)
+	require.Error(t, err)
+	require.Equal(t, types.DeprovisionOperation, report.Operation)
+	require.Len(t, report.Failed(), 2)
+}
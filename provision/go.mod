@@ -8,6 +8,7 @@ require (
 	github.com/Azure/go-ntlmssp v0.0.0-20200615164410-66371956d46c // indirect
 	github.com/ChrisTrenkamp/goxpath v0.0.0-20190607011252-c5096ec8773d // indirect
 	github.com/apparentlymart/go-cidr v1.1.0 // indirect
+	github.com/aws/aws-sdk-go v1.31.9
 	github.com/gofrs/uuid v3.3.0+incompatible // indirect
 	github.com/hashicorp/aws-sdk-go-base v0.6.0 // indirect
 	github.com/hashicorp/go-azure-helpers v0.12.0 // indirect
@@ -19,13 +20,18 @@ require (
 	github.com/masterzen/winrm v0.0.0-20200615185753-c42b5136ff88 // indirect
 	github.com/mitchellh/cli v1.1.1
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db
+	// the EKS provisioner and its tests encode kubeconfigs with client-go, whose json-iterator panics with reflect2 v1.0.1 on Go 1.18 and later
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/packer-community/winrmcp v0.0.0-20180921211025-c76d91c1e7db // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.6.1
-	github.com/zclconf/go-cty v1.5.1 // indirect
+	github.com/zclconf/go-cty v1.5.1
 	github.com/zclconf/go-cty-yaml v1.0.2 // indirect
 	golang.org/x/net v0.0.0-20200602114024-627f9648deb9 // indirect
+	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
+	k8s.io/api v0.18.9
 	k8s.io/apimachinery v0.18.9
 	k8s.io/client-go v0.18.9
 	k8s.io/utils v0.0.0-20200411171748-3d5a2fe318e4 // indirect
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -0,0 +1,348 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/internal/errs"
+	terraform_operator "github.com/kyma-incubator/hydroform/provision/internal/operator/terraform"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/operator"
+	"github.com/kyma-incubator/hydroform/provision/internal/providerconfig"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	defaultProfile = "default"
+	defaultVPCCIDR = "10.0.0.0/16"
+
+	// EKS tokens are presigned STS requests naming the cluster in a header
+	tokenPrefix     = "k8s-aws-v1."
+	clusterIDHeader = "x-k8s-aws-id"
+	tokenExpiry     = 15 * time.Minute
+)
+
+// awsProvisioner implements Provisioner
+type awsProvisioner struct {
+	provisionOperator operator.Operator
+	// token returns a token authenticating at the cluster as the identity in the credentials file
+	token func(ctx context.Context, credentialsFile, profile, region, clusterName string) (string, error)
+}
+
+// Provision requests provisioning of a new Kubernetes cluster on AWS EKS with the given configurations.
+func (a *awsProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if err := a.ValidateInputs(cluster, provider); err != nil {
+		return cluster, err
+	}
+
+	config := a.loadConfigurations(cluster, provider)
+
+	clusterInfo, err := a.provisionOperator.Create(ctx, provider.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to provision aws cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Status returns the ClusterStatus for the requested cluster.
+func (a *awsProvisioner) Status(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	cfg := a.loadConfigurations(cluster, p)
+
+	return a.provisionOperator.Status(ctx, state, p.Type, cfg)
+}
+
+// Credentials returns the Kubeconfig file as a byte array for the requested cluster.
+// The kubeconfig authenticates through the AWS CLI (aws eks get-token) using the same credentials file and profile used for provisioning.
+// If the cluster carries no endpoint and certificate authority, they are read from its state in the state backend.
+func (a *awsProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+	cfg := a.loadConfigurations(cluster, p)
+	if err := a.loadClusterInfo(ctx, cluster, cfg); err != nil {
+		return nil, err
+	}
+
+	return kubeconfig(cluster, &api.AuthInfo{
+		Exec: &api.ExecConfig{
+			APIVersion: "client.authentication.k8s.io/v1alpha1",
+			Command:    "aws",
+			Args:       []string{"eks", "get-token", "--cluster-name", cluster.Name, "--region", cluster.Location},
+			Env: []api.ExecEnvVar{
+				{Name: "AWS_SHARED_CREDENTIALS_FILE", Value: p.CredentialsFilePath},
+				{Name: "AWS_PROFILE", Value: fmt.Sprintf("%v", cfg["profile"])},
+			},
+		},
+	})
+}
+
+// AdminCredentials returns a kubeconfig for the requested cluster that authenticates with a token of the identity in the credentials file.
+// Unlike the kubeconfig of Credentials, it does not need the AWS CLI. The token expires after 15 minutes.
+func (a *awsProvisioner) AdminCredentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+	cfg := a.loadConfigurations(cluster, p)
+	if err := a.loadClusterInfo(ctx, cluster, cfg); err != nil {
+		return nil, err
+	}
+
+	token, err := a.token(ctx, p.CredentialsFilePath, fmt.Sprintf("%v", cfg["profile"]), cluster.Location, cluster.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get a token for the eks cluster")
+	}
+
+	return kubeconfig(cluster, &api.AuthInfo{Token: token})
+}
+
+// loadClusterInfo rebuilds the ClusterInfo of the cluster from its state, unless the cluster carries the endpoint and certificate authority already.
+func (a *awsProvisioner) loadClusterInfo(ctx context.Context, cluster *types.Cluster, cfg map[string]interface{}) error {
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.Endpoint != "" && cluster.ClusterInfo.CertificateAuthorityData != nil {
+		return nil
+	}
+
+	// the caller lost the cluster info, rebuild it from the state
+	info, err := a.provisionOperator.ClusterInfo(ctx, types.AWS, cfg)
+	if err != nil {
+		return errors.Wrap(err, errs.NoClusterInfo)
+	}
+	if info.Endpoint == "" || info.CertificateAuthorityData == nil {
+		return errors.New(errs.NoClusterInfo)
+	}
+	cluster.ClusterInfo = info
+	return nil
+}
+
+// kubeconfig returns a kubeconfig for the cluster with the given user.
+func kubeconfig(cluster *types.Cluster, user *api.AuthInfo) ([]byte, error) {
+	userName := "cluster-user"
+	config := api.NewConfig()
+
+	config.Clusters[cluster.Name] = &api.Cluster{
+		Server:                   cluster.ClusterInfo.Endpoint,
+		CertificateAuthorityData: cluster.ClusterInfo.CertificateAuthorityData,
+	}
+
+	config.Contexts[cluster.Name] = &api.Context{
+		Cluster:  cluster.Name,
+		AuthInfo: userName,
+	}
+
+	config.CurrentContext = cluster.Name
+
+	config.AuthInfos[userName] = user
+
+	return clientcmd.Write(*config)
+}
+
+// eksToken returns a token authenticating at the EKS cluster with the given name, the same way aws eks get-token does:
+// a presigned request of the identity in the credentials file, which the cluster sends to STS to find out who is calling.
+func eksToken(ctx context.Context, credentialsFile, profile, region, clusterName string) (string, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region:      aws.String(region),
+			Credentials: credentials.NewSharedCredentials(credentialsFile, profile),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, _ := sts.New(sess).GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	req.SetContext(ctx)
+	req.HTTPRequest.Header.Add(clusterIDHeader, clusterName)
+	url, err := req.Presign(tokenExpiry)
+	if err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(url)), nil
+}
+
+// Update applies changes of the node count, machine type, or Kubernetes version to an existing cluster on AWS EKS.
+// The cluster needs to carry the internal state returned by Provision, otherwise the state is loaded from the data directory.
+func (a *awsProvisioner) Update(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := a.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	clusterInfo, err := a.provisionOperator.Update(ctx, state, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update aws cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Plan returns the changes provisioning, updating, or deprovisioning (with the PlanDestroy option) the cluster on AWS EKS would make, without applying any of them.
+func (a *awsProvisioner) Plan(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Plan, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	config := a.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	plan, err := a.provisionOperator.Plan(ctx, state, p.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan aws cluster")
+	}
+
+	return plan, nil
+}
+
+// Import brings an existing cluster on AWS EKS, created outside of Hydroform or whose state was lost, under the management of Hydroform.
+// It returns the cluster enriched with its new internal state, which the other operations need.
+// The built-in template also manages the network and node group of the cluster, which cannot be imported, so Import only works with a custom module that manages the cluster alone.
+func (a *awsProvisioner) Import(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := a.loadConfigurations(cluster, p)
+
+	clusterInfo, err := a.provisionOperator.Import(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to import aws cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Deprovision requests deprovisioning of an existing cluster on AWS EKS with the given configurations.
+func (a *awsProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return err
+	}
+
+	config := a.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	err := a.provisionOperator.Delete(ctx, state, p.Type, config)
+	if err != nil {
+		return errors.Wrap(err, "unable to deprovision aws cluster")
+	}
+
+	return nil
+}
+
+// New creates a new instance of awsProvisioner.
+func New(operatorType operator.Type, ops ...types.Option) *awsProvisioner {
+	// parse config
+	os := &types.Options{}
+	for _, o := range ops {
+		o(os)
+	}
+
+	var op operator.Operator
+	switch operatorType {
+	case operator.TerraformOperator:
+		tfOps := terraform_operator.ToTerraformOptions(os)
+		op = terraform_operator.New(tfOps...)
+	default:
+		op = &operator.Unknown{}
+	}
+
+	return &awsProvisioner{
+		provisionOperator: op,
+		token:             eksToken,
+	}
+}
+
+func (a *awsProvisioner) ValidateInputs(cluster *types.Cluster, provider *types.Provider) error {
+	var errMessage string
+	if cluster.NodeCount < 1 {
+		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 1)
+	}
+	// Matches the regex for an EKS cluster name.
+	if match, _ := regexp.MatchString(`^(?:[a-zA-Z](?:[-a-zA-Z0-9_]{0,98}[a-zA-Z0-9])?)$`, cluster.Name); !match {
+		errMessage += fmt.Sprintf(errs.Custom, "Cluster.Name must start with a letter followed by up to 99 letters, "+
+			"numbers, hyphens, or underscores, and cannot end with a hyphen or an underscore")
+	}
+	if cluster.Location == "" {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.Location")
+	}
+	if cluster.MachineType == "" {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.MachineType")
+	}
+	if cluster.KubernetesVersion == "" {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.KubernetesVersion")
+	}
+	if cluster.DiskSizeGB < 0 {
+		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.DiskSizeGB", 0)
+	}
+
+	if provider.CredentialsFilePath == "" {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CredentialsFilePath")
+	}
+	if provider.ProjectName == "" {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.ProjectName")
+	}
+	errMessage += providerconfig.Validate(provider.CustomConfigurations, types.AWSConfig{})
+
+	if errMessage != "" {
+		return errors.New("input validation failed with the following information: " + errMessage)
+	}
+
+	return nil
+}
+
+func (a *awsProvisioner) loadConfigurations(cluster *types.Cluster, provider *types.Provider) map[string]interface{} {
+	config := map[string]interface{}{}
+	config["cluster_name"] = cluster.Name
+	config["node_count"] = cluster.NodeCount
+	config["machine_type"] = cluster.MachineType
+	config["disk_size"] = cluster.DiskSizeGB
+	config["kubernetes_version"] = cluster.KubernetesVersion
+	config["location"] = cluster.Location
+	config["project"] = provider.ProjectName
+	config["credentials_file_path"] = provider.CredentialsFilePath
+	config["profile"] = defaultProfile
+	config["vpc_cidr"] = defaultVPCCIDR
+	if labels := cluster.Labels(); labels != nil {
+		config["labels"] = labels
+	}
+	for k, v := range provider.CustomConfigurations {
+		config[k] = v
+	}
+	return config
+}
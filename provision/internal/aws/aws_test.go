@@ -0,0 +1,431 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/internal/operator/mocks"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateInputs(t *testing.T) {
+	t.Parallel()
+	a := &awsProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	require.NoError(t, a.ValidateInputs(cluster, provider), "Validation should pass")
+
+	cluster.NodeCount = 0
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when number of nodes is < 1")
+	cluster.NodeCount = 2
+
+	cluster.Name = ""
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when cluster name is empty")
+	cluster.Name = "-invalid-start"
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
+	cluster.Name = "invalid-end_"
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when cluster name ends with '_'")
+	cluster.Name = "hydro-cluster"
+
+	cluster.Location = ""
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when cluster location is empty")
+	cluster.Location = "eu-central-1"
+
+	cluster.MachineType = ""
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when cluster machine type is empty")
+	cluster.MachineType = "m5.xlarge"
+
+	cluster.KubernetesVersion = ""
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when Kubernetes version is empty")
+	cluster.KubernetesVersion = "1.17"
+
+	cluster.DiskSizeGB = -1
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when disk size is less than 0")
+	cluster.DiskSizeGB = 30
+
+	provider.CredentialsFilePath = ""
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when credentials file path is empty")
+	provider.CredentialsFilePath = "/path/to/credentials"
+
+	provider.ProjectName = ""
+	require.Error(t, a.ValidateInputs(cluster, provider), "Validation should fail when project name is empty")
+}
+
+func TestLoadConfigurations(t *testing.T) {
+	t.Parallel()
+	a := &awsProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	config := a.loadConfigurations(cluster, provider)
+
+	require.Equal(t, cluster.Name, config["cluster_name"])
+	require.Equal(t, provider.CredentialsFilePath, config["credentials_file_path"])
+	require.Equal(t, cluster.NodeCount, config["node_count"])
+	require.Equal(t, cluster.MachineType, config["machine_type"])
+	require.Equal(t, cluster.DiskSizeGB, config["disk_size"])
+	require.Equal(t, cluster.KubernetesVersion, config["kubernetes_version"])
+	require.Equal(t, cluster.Location, config["location"])
+	require.Equal(t, provider.ProjectName, config["project"])
+	require.Equal(t, defaultProfile, config["profile"], "Default profile should be used if not configured")
+	require.Equal(t, defaultVPCCIDR, config["vpc_cidr"], "Default VPC CIDR should be used if not configured")
+	require.NotContains(t, config, "labels", "There should be no labels without expiry")
+
+	cluster.ExpiresAt = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	config = a.loadConfigurations(cluster, provider)
+	require.Equal(t, map[string]string{types.ExpiresAtLabel: "1614600000"}, config["labels"], "The expiry should be recorded in the labels")
+
+	// custom configurations override the defaults
+	provider.CustomConfigurations = map[string]interface{}{
+		"profile":  "ci",
+		"vpc_cidr": "10.250.0.0/16",
+	}
+	config = a.loadConfigurations(cluster, provider)
+
+	for k, v := range provider.CustomConfigurations {
+		require.Equal(t, v, config[k], fmt.Sprintf("Custom config %s is incorrect", k))
+	}
+}
+
+func TestProvision(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := awsProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	result := &types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: nil,
+		},
+	}
+	mockOp.On("Create", context.Background(), types.AWS, a.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	cluster, err := a.Provision(context.Background(), cluster, provider)
+	require.NoError(t, err, "Provision should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
+
+	badCluster := &types.Cluster{
+		Name:              "bad-cluster",
+		KubernetesVersion: "1.17",
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	mockOp.On("Create", context.Background(), types.AWS, a.loadConfigurations(badCluster, provider)).Return(nil, errors.New("Unable to provision cluster"))
+
+	_, err = a.Provision(context.Background(), badCluster, provider)
+	require.Error(t, err, "Provision should fail")
+}
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := &awsProvisioner{provisionOperator: mockOp}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	mockOp.On("ClusterInfo", context.Background(), types.AWS, a.loadConfigurations(cluster, provider)).Return(nil, errors.New("no state")).Once()
+	_, err := a.Credentials(context.Background(), cluster, provider)
+	require.Error(t, err, "Credentials should fail without cluster info and state")
+
+	// a cluster without info gets it from the state in the state backend
+	mockOp.On("ClusterInfo", context.Background(), types.AWS, a.loadConfigurations(cluster, provider)).Return(&types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+	}, nil).Once()
+
+	kubeconfig, err := a.Credentials(context.Background(), cluster, provider)
+	require.NoError(t, err, "Credentials should succeed")
+	mockOp.AssertExpectations(t)
+
+	config, err := clientcmd.Load(kubeconfig)
+	require.NoError(t, err, "Credentials should return a valid kubeconfig")
+	require.Equal(t, cluster.Name, config.CurrentContext)
+	require.Equal(t, "https://cluster-url.fake", config.Clusters[cluster.Name].Server)
+	require.Equal(t, []byte("My cert"), config.Clusters[cluster.Name].CertificateAuthorityData)
+
+	exec := config.AuthInfos[config.Contexts[cluster.Name].AuthInfo].Exec
+	require.NotNil(t, exec, "The kubeconfig should authenticate through the AWS CLI")
+	require.Equal(t, "aws", exec.Command)
+	require.Equal(t, []string{"eks", "get-token", "--cluster-name", cluster.Name, "--region", cluster.Location}, exec.Args)
+
+	a.token = func(ctx context.Context, credentialsFile, profile, region, clusterName string) (string, error) {
+		require.Equal(t, provider.CredentialsFilePath, credentialsFile)
+		require.Equal(t, defaultProfile, profile)
+		require.Equal(t, cluster.Location, region)
+		return "k8s-aws-v1.token-of-" + clusterName, nil
+	}
+	kubeconfig, err = a.AdminCredentials(context.Background(), cluster, provider)
+	require.NoError(t, err)
+	config, err = clientcmd.Load(kubeconfig)
+	require.NoError(t, err)
+	require.Equal(t, "k8s-aws-v1.token-of-hydro-cluster", config.AuthInfos["cluster-user"].Token)
+	require.Nil(t, config.AuthInfos["cluster-user"].Exec, "The admin kubeconfig should not need the AWS CLI")
+	require.Equal(t, "https://cluster-url.fake", config.Clusters[cluster.Name].Server)
+
+	a.token = func(ctx context.Context, credentialsFile, profile, region, clusterName string) (string, error) {
+		return "", errors.New("invalid credentials")
+	}
+	_, err = a.AdminCredentials(context.Background(), cluster, provider)
+	require.Error(t, err, "A failing token request should fail")
+}
+
+func TestEKSToken(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "hydroform-aws")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	credentialsFile := filepath.Join(dir, "credentials")
+	require.NoError(t, ioutil.WriteFile(credentialsFile, []byte("[hydro]\naws_access_key_id = AKIDEXAMPLE\naws_secret_access_key = secret\n"), 0600))
+
+	token, err := eksToken(context.Background(), credentialsFile, "hydro", "eu-central-1", "hydro-cluster")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, tokenPrefix))
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, tokenPrefix))
+	require.NoError(t, err)
+	presigned, err := url.Parse(string(data))
+	require.NoError(t, err)
+	query := presigned.Query()
+	require.Equal(t, "GetCallerIdentity", query.Get("Action"))
+	require.Contains(t, query.Get("X-Amz-Credential"), "AKIDEXAMPLE", "The request should be signed with the credentials of the profile")
+	require.Contains(t, query.Get("X-Amz-SignedHeaders"), clusterIDHeader, "The request should name the cluster")
+
+	_, err = eksToken(context.Background(), credentialsFile, "missing", "eu-central-1", "hydro-cluster")
+	require.Error(t, err, "A missing profile should fail")
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := awsProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: state,
+		},
+	}
+	mockOp.On("Update", context.Background(), state, types.AWS, a.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	cluster, err := a.Update(context.Background(), cluster, provider)
+	require.NoError(t, err, "Update should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Update")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Update", context.Background(), (*statefile.File)(nil), types.AWS, a.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to update cluster"))
+
+	_, err = a.Update(context.Background(), cluster, provider)
+	require.Error(t, err, "Update should fail")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := awsProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.Plan{
+		Changes: []types.ResourceChange{
+			{Address: "some_resource.cluster", Type: "some_resource", Action: types.UpdateAction},
+		},
+	}
+	mockOp.On("Plan", context.Background(), state, types.AWS, a.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	plan, err := a.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, result, plan, "The plan returned from the operator should be returned by Plan")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Plan", context.Background(), (*statefile.File)(nil), types.AWS, a.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to plan cluster"))
+
+	_, err = a.Plan(context.Background(), cluster, provider)
+	require.Error(t, err, "Plan should fail")
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := awsProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	info := &types.ClusterInfo{
+		Endpoint:      "1.2.3.4",
+		InternalState: &types.InternalState{TerraformState: &statefile.File{}},
+	}
+	mockOp.On("Import", context.Background(), types.AWS, a.loadConfigurations(cluster, provider)).Return(info, nil).Once()
+
+	imported, err := a.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, info, imported.ClusterInfo, "The cluster should carry the state of the imported cluster")
+
+	mockOp.On("Import", context.Background(), types.AWS, a.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to import cluster")).Once()
+
+	_, err = a.Import(context.Background(), cluster, provider)
+	require.Error(t, err, "Import should fail")
+}
+
+func TestDeprovision(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := awsProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.17",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+		ClusterInfo:       &types.ClusterInfo{},
+	}
+	provider := &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	var state *statefile.File
+	mockOp.On("Delete", context.Background(), state, types.AWS, a.loadConfigurations(cluster, provider)).Return(nil)
+
+	err := a.Deprovision(context.Background(), cluster, provider)
+	require.NoError(t, err, "Deprovision should succeed")
+
+	provider.CredentialsFilePath = "/wrong/credentials"
+	mockOp.On("Delete", context.Background(), state, types.AWS, a.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
+
+	err = a.Deprovision(context.Background(), cluster, provider)
+	require.Error(t, err, "Deprovision should fail")
+}
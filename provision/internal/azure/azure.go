@@ -1,6 +1,7 @@
 package azure
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -21,8 +22,8 @@ type azureProvisioner struct {
 }
 
 // Provision requests provisioning of a new Kubernetes cluster on Azure with the given configurations.
-func (a *azureProvisioner) Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
-	if err := a.validateInputs(cluster, provider); err != nil {
+func (a *azureProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if err := a.ValidateInputs(cluster, provider); err != nil {
 		return cluster, err
 	}
 
@@ -31,7 +32,7 @@ func (a *azureProvisioner) Provision(cluster *types.Cluster, provider *types.Pro
 		return cluster, err
 	}
 
-	clusterInfo, err := a.provisionOperator.Create(provider.Type, config)
+	clusterInfo, err := a.provisionOperator.Create(ctx, provider.Type, config)
 	if err != nil {
 		return cluster, errors.Wrap(err, "unable to provision azure cluster")
 	}
@@ -41,13 +42,13 @@ func (a *azureProvisioner) Provision(cluster *types.Cluster, provider *types.Pro
 }
 
 // Status returns the ClusterStatus for the requested cluster.
-func (a *azureProvisioner) Status(cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
+func (a *azureProvisioner) Status(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
 	var state *statefile.File
 	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	if err := a.validateInputs(cluster, p); err != nil {
+	if err := a.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 
@@ -56,27 +57,110 @@ func (a *azureProvisioner) Status(cluster *types.Cluster, p *types.Provider) (*t
 		return nil, err
 	}
 
-	return a.provisionOperator.Status(state, p.Type, cfg)
+	return a.provisionOperator.Status(ctx, state, p.Type, cfg)
 }
 
-// Credentials returns the Kubeconfig file as a byte array for the requested cluster.
-func (a *azureProvisioner) Credentials(cluster *types.Cluster, p *types.Provider) ([]byte, error) {
-	if err := a.validateInputs(cluster, p); err != nil {
+// Credentials returns the Kubeconfig file as a byte array for the requested cluster, taken from the outputs of its state.
+// If the cluster carries no state, it is loaded from the state backend.
+func (a *azureProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil || cluster.ClusterInfo.InternalState.TerraformState == nil {
-		// TODO add a way to get the kubeconfig from the state file if possible
-		return nil, errors.New(errs.EmptyClusterInfo)
+		// the caller lost the cluster info, rebuild it from the state
+		config, err := a.loadConfigurations(cluster, p)
+		if err != nil {
+			return nil, err
+		}
+		info, err := a.provisionOperator.ClusterInfo(ctx, p.Type, config)
+		if err != nil {
+			return nil, errors.Wrap(err, errs.NoClusterInfo)
+		}
+		cluster.ClusterInfo = info
 	}
 
-	kubeconfig := cluster.ClusterInfo.InternalState.TerraformState.State.Modules[""].OutputValues["kube_config"].Value.AsString()
+	state := cluster.ClusterInfo.InternalState.TerraformState.State
+	if state == nil || state.Modules[""] == nil || state.Modules[""].OutputValues["kube_config"] == nil {
+		return nil, errors.New("the state of the cluster has no kube_config output")
+	}
+
+	return []byte(state.Modules[""].OutputValues["kube_config"].Value.AsString()), nil
+}
+
+// Update applies changes of the node count, machine type, or Kubernetes version to an existing cluster on Azure.
+// The cluster needs to carry the internal state returned by Provision, otherwise the state is loaded from the data directory.
+func (a *azureProvisioner) Update(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config, err := a.loadConfigurations(cluster, p)
+	if err != nil {
+		return cluster, err
+	}
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
 
-	return []byte(kubeconfig), nil
+	clusterInfo, err := a.provisionOperator.Update(ctx, state, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update azure cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Plan returns the changes provisioning, updating, or deprovisioning (with the PlanDestroy option) the cluster on Azure would make, without applying any of them.
+func (a *azureProvisioner) Plan(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Plan, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	config, err := a.loadConfigurations(cluster, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	plan, err := a.provisionOperator.Plan(ctx, state, p.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan azure cluster")
+	}
+
+	return plan, nil
+}
+
+// Import brings an existing cluster on Azure, created outside of Hydroform or whose state was lost, under the management of Hydroform.
+// It returns the cluster enriched with its new internal state, which the other operations need.
+func (a *azureProvisioner) Import(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := a.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config, err := a.loadConfigurations(cluster, p)
+	if err != nil {
+		return cluster, err
+	}
+
+	clusterInfo, err := a.provisionOperator.Import(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to import azure cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
 }
 
 // Deprovision requests deprovisioning of an existing cluster on Azure with the given configurations.
-func (a *azureProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider) error {
-	if err := a.validateInputs(cluster, p); err != nil {
+func (a *azureProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if err := a.ValidateInputs(cluster, p); err != nil {
 		return err
 	}
 
@@ -90,7 +174,7 @@ func (a *azureProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	if err = a.provisionOperator.Delete(state, p.Type, config); err != nil {
+	if err = a.provisionOperator.Delete(ctx, state, p.Type, config); err != nil {
 		return errors.Wrap(err, "unable to deprovision azure cluster")
 	}
 
@@ -119,7 +203,7 @@ func New(operatorType operator.Type, ops ...types.Option) *azureProvisioner {
 	}
 }
 
-func (a *azureProvisioner) validateInputs(cluster *types.Cluster, provider *types.Provider) error {
+func (a *azureProvisioner) ValidateInputs(cluster *types.Cluster, provider *types.Provider) error {
 	var errMessage string
 	if cluster.NodeCount < 1 {
 		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 1)
@@ -163,6 +247,9 @@ func (a *azureProvisioner) loadConfigurations(cluster *types.Cluster, provider *
 	config["location"] = cluster.Location
 	config["project"] = provider.ProjectName
 	config["resource_group"] = provider.ProjectName
+	if labels := cluster.Labels(); labels != nil {
+		config["labels"] = labels
+	}
 
 	var err error
 	config["subscription_id"], config["tenant_id"], config["client_id"], config["client_secret"], err = azureCredentials(provider.CredentialsFilePath)
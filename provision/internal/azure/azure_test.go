@@ -1,6 +1,7 @@
 package azure
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -38,58 +39,58 @@ func TestValidateInputs(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, g.validateInputs(cluster, provider), "Validation should pass")
+	require.NoError(t, g.ValidateInputs(cluster, provider), "Validation should pass")
 
 	cluster.NodeCount = -5
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when number of nodes is < 1")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when number of nodes is < 1")
 	cluster.NodeCount = 2
 
 	cluster.Name = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name is empty")
 	cluster.Name = "This_name_is_for_sure_way_too_long_for_the_cluster"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name is too long")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name is too long")
 	cluster.Name = "-invalid-start"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
 	cluster.Name = "invalid-end-"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
 	cluster.Name = "hydro-cluster"
 
 	cluster.Location = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster location is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster location is empty")
 	cluster.Location = "europe-west3"
 
 	cluster.MachineType = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster machine type is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster machine type is empty")
 	cluster.Location = "type1"
 
 	cluster.KubernetesVersion = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when Kubernetes version is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when Kubernetes version is empty")
 	cluster.KubernetesVersion = "1.12"
 
 	cluster.DiskSizeGB = 0
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when disk size is 0 or less")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when disk size is 0 or less")
 	cluster.DiskSizeGB = 30
 
 	provider.CredentialsFilePath = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when credentials file path is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when credentials file path is empty")
 	provider.CredentialsFilePath = "/path/to/credentials"
 
 	provider.ProjectName = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when project name is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when project name is empty")
 	provider.ProjectName = "/my-resource-group"
 
 	delete(provider.CustomConfigurations, "target_provider")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target provider is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 	provider.CustomConfigurations["target_provider"] = "nimbus"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target provider is not supported")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is not supported")
 	provider.CustomConfigurations["target_provider"] = "azure"
 
 	delete(provider.CustomConfigurations, "target_secret")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target secret is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target secret is empty")
 	provider.CustomConfigurations["target_secret"] = "secret_name"
 
 	delete(provider.CustomConfigurations, "disk_type")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when disk type is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when disk type is empty")
 }
 
 func TestLoadConfigurations(t *testing.T) {
@@ -202,9 +203,9 @@ func TestProvision(t *testing.T) {
 	cfg, err := g.loadConfigurations(cluster, provider)
 	require.NoError(t, err)
 
-	mockOp.On("Create", types.Azure, cfg).Return(result, nil)
+	mockOp.On("Create", context.Background(), types.Azure, cfg).Return(result, nil)
 
-	cluster, err = g.Provision(cluster, provider)
+	cluster, err = g.Provision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Provision should succeed")
 	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
 
@@ -214,12 +215,184 @@ func TestProvision(t *testing.T) {
 
 	cfg, err = g.loadConfigurations(badCluster, provider)
 	require.NoError(t, err)
-	mockOp.On("Create", types.Azure, cfg).Return(badCluster, errors.New("Unable to provision cluster"))
+	mockOp.On("Create", context.Background(), types.Azure, cfg).Return(badCluster, errors.New("Unable to provision cluster"))
 
-	_, err = g.Provision(badCluster, provider)
+	_, err = g.Provision(context.Background(), badCluster, provider)
 	require.Error(t, err, "Provision should fail")
 }
 
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := azureProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Azure,
+		ProjectName:         "my-resource-group",
+		CredentialsFilePath: "./credentials-update.json",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "azure",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zones":           "europe-west3-b",
+		},
+	}
+
+	err := fakeCredentials(provider.CredentialsFilePath)
+	require.NoError(t, err, "Creating a fake credentials file should not have an error")
+	defer os.Remove(provider.CredentialsFilePath)
+
+	cfg, err := a.loadConfigurations(cluster, provider)
+	require.NoError(t, err)
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: state,
+		},
+	}
+	mockOp.On("Update", context.Background(), state, types.Azure, cfg).Return(result, nil)
+
+	cluster, err = a.Update(context.Background(), cluster, provider)
+	require.NoError(t, err, "Update should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Update")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Update", context.Background(), (*statefile.File)(nil), types.Azure, cfg).Return(nil, errors.New("Unable to update cluster"))
+
+	_, err = a.Update(context.Background(), cluster, provider)
+	require.Error(t, err, "Update should fail")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := azureProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Azure,
+		ProjectName:         "my-resource-group",
+		CredentialsFilePath: "./credentials-update.json",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "azure",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zones":           "europe-west3-b",
+		},
+	}
+
+	err := fakeCredentials(provider.CredentialsFilePath)
+	require.NoError(t, err, "Creating a fake credentials file should not have an error")
+	defer os.Remove(provider.CredentialsFilePath)
+
+	cfg, err := a.loadConfigurations(cluster, provider)
+	require.NoError(t, err)
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.Plan{
+		Changes: []types.ResourceChange{
+			{Address: "some_resource.cluster", Type: "some_resource", Action: types.UpdateAction},
+		},
+	}
+	mockOp.On("Plan", context.Background(), state, types.Azure, cfg).Return(result, nil)
+
+	plan, err := a.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, result, plan, "The plan returned from the operator should be returned by Plan")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Plan", context.Background(), (*statefile.File)(nil), types.Azure, cfg).Return(nil, errors.New("Unable to plan cluster"))
+
+	_, err = a.Plan(context.Background(), cluster, provider)
+	require.Error(t, err, "Plan should fail")
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	a := azureProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Azure,
+		ProjectName:         "my-resource-group",
+		CredentialsFilePath: "./credentials-import.json",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "azure",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zones":           "europe-west3-b",
+		},
+	}
+
+	err := fakeCredentials(provider.CredentialsFilePath)
+	require.NoError(t, err, "Creating a fake credentials file should not have an error")
+	defer os.Remove(provider.CredentialsFilePath)
+
+	cfg, err := a.loadConfigurations(cluster, provider)
+	require.NoError(t, err)
+
+	info := &types.ClusterInfo{
+		Endpoint:      "1.2.3.4",
+		InternalState: &types.InternalState{TerraformState: &statefile.File{}},
+	}
+	mockOp.On("Import", context.Background(), types.Azure, cfg).Return(info, nil).Once()
+
+	imported, err := a.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, info, imported.ClusterInfo, "The cluster should carry the state of the imported cluster")
+
+	mockOp.On("Import", context.Background(), types.Azure, cfg).Return(nil, errors.New("Unable to import cluster")).Once()
+
+	_, err = a.Import(context.Background(), cluster, provider)
+	require.Error(t, err, "Import should fail")
+}
+
 func TestDeprovision(t *testing.T) {
 	t.Parallel()
 	mockOp := &mocks.Operator{}
@@ -257,17 +430,17 @@ func TestDeprovision(t *testing.T) {
 	require.NoError(t, err)
 
 	var state *statefile.File
-	mockOp.On("Delete", state, types.Azure, cfg).Return(nil)
+	mockOp.On("Delete", context.Background(), state, types.Azure, cfg).Return(nil)
 
-	err = g.Deprovision(cluster, provider)
+	err = g.Deprovision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Deprovision should succeed")
 
 	provider.ProjectName = "invalid-resource-group"
 	cfg, err = g.loadConfigurations(cluster, provider)
 	require.NoError(t, err)
 
-	mockOp.On("Delete", state, types.Azure, cfg).Return(errors.New("Unable to deprovision cluster"))
+	mockOp.On("Delete", context.Background(), state, types.Azure, cfg).Return(errors.New("Unable to deprovision cluster"))
 
-	err = g.Deprovision(cluster, provider)
+	err = g.Deprovision(context.Background(), cluster, provider)
 	require.Error(t, err, "Deprovision should fail")
 }
@@ -5,4 +5,5 @@ const (
 	CannotBeLess     = "\n - %s cannot be less than %v"
 	Custom           = "\n - %v"
 	EmptyClusterInfo = "Cluster.ClusterInfo cannot be empty. Please provide the Cluster object returned from the Provision function."
+	NoClusterInfo    = "Cluster.ClusterInfo is empty and could not be rebuilt from the state of the cluster"
 )
@@ -11,7 +11,9 @@ import (
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/kyma-incubator/hydroform/provision/internal/errs"
 	"github.com/kyma-incubator/hydroform/provision/internal/operator"
+	"github.com/kyma-incubator/hydroform/provision/internal/operator/native"
 	terraform_operator "github.com/kyma-incubator/hydroform/provision/internal/operator/terraform"
+	"github.com/kyma-incubator/hydroform/provision/internal/providerconfig"
 	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,6 +42,8 @@ func New(operatorType operator.Type, ops ...types.Option) *gardenerProvisioner {
 	case operator.TerraformOperator:
 		tfOps := terraform_operator.ToTerraformOptions(os)
 		op = terraform_operator.New(tfOps...)
+	case operator.NativeOperator:
+		op = native.NewGardener(os)
 	default:
 		op = &operator.Unknown{}
 	}
@@ -48,14 +52,14 @@ func New(operatorType operator.Type, ops ...types.Option) *gardenerProvisioner {
 	}
 }
 
-func (g *gardenerProvisioner) Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
-	if err := g.validate(cluster, provider); err != nil {
+func (g *gardenerProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if err := g.ValidateInputs(cluster, provider); err != nil {
 		return cluster, err
 	}
 
 	config := g.loadConfigurations(cluster, provider)
 
-	clusterInfo, err := g.operator.Create(provider.Type, config)
+	clusterInfo, err := g.operator.Create(ctx, provider.Type, config)
 	if err != nil {
 		return cluster, errors.Wrap(err, "unable to provision gardener cluster")
 	}
@@ -64,23 +68,23 @@ func (g *gardenerProvisioner) Provision(cluster *types.Cluster, provider *types.
 }
 
 // Status returns the ClusterStatus for the requested cluster.
-func (g *gardenerProvisioner) Status(cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
+func (g *gardenerProvisioner) Status(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
 	var state *statefile.File
 	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	if err := g.validate(cluster, p); err != nil {
+	if err := g.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 
 	cfg := g.loadConfigurations(cluster, p)
 
-	return g.operator.Status(state, p.Type, cfg)
+	return g.operator.Status(ctx, state, p.Type, cfg)
 }
 
-func (g *gardenerProvisioner) Credentials(cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
-	if err := g.validate(cluster, provider); err != nil {
+func (g *gardenerProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	if err := g.ValidateInputs(cluster, provider); err != nil {
 		return nil, err
 	}
 
@@ -94,7 +98,7 @@ func (g *gardenerProvisioner) Credentials(cluster *types.Cluster, provider *type
 		return nil, err
 	}
 
-	s, err := k8s.CoreV1().Secrets(fmt.Sprintf("garden-%s", provider.ProjectName)).Get(context.Background(), fmt.Sprintf("%s.kubeconfig", cluster.Name), metav1.GetOptions{})
+	s, err := k8s.CoreV1().Secrets(fmt.Sprintf("garden-%s", provider.ProjectName)).Get(ctx, fmt.Sprintf("%s.kubeconfig", cluster.Name), metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -102,8 +106,70 @@ func (g *gardenerProvisioner) Credentials(cluster *types.Cluster, provider *type
 	return s.Data["kubeconfig"], nil
 }
 
-func (g *gardenerProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider) error {
-	if err := g.validate(cluster, p); err != nil {
+// Update applies changes of the node count, machine type, or Kubernetes version to an existing cluster on Gardener.
+// The cluster needs to carry the internal state returned by Provision, otherwise the state is loaded from the data directory.
+func (g *gardenerProvisioner) Update(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := g.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	clusterInfo, err := g.operator.Update(ctx, state, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update gardener cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Plan returns the changes provisioning, updating, or deprovisioning (with the PlanDestroy option) the cluster on Gardener would make, without applying any of them.
+func (g *gardenerProvisioner) Plan(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Plan, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	config := g.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	plan, err := g.operator.Plan(ctx, state, p.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan gardener cluster")
+	}
+
+	return plan, nil
+}
+
+// Import brings an existing cluster on Gardener, created outside of Hydroform or whose state was lost, under the management of Hydroform.
+// It returns the cluster enriched with its new internal state, which the other operations need.
+func (g *gardenerProvisioner) Import(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := g.loadConfigurations(cluster, p)
+
+	clusterInfo, err := g.operator.Import(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to import gardener cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+func (g *gardenerProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if err := g.ValidateInputs(cluster, p); err != nil {
 		return err
 	}
 
@@ -114,7 +180,7 @@ func (g *gardenerProvisioner) Deprovision(cluster *types.Cluster, p *types.Provi
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	err := g.operator.Delete(state, p.Type, config)
+	err := g.operator.Delete(ctx, state, p.Type, config)
 	if err != nil {
 		return errors.Wrap(err, "unable to deprovision gardener cluster")
 	}
@@ -122,7 +188,7 @@ func (g *gardenerProvisioner) Deprovision(cluster *types.Cluster, p *types.Provi
 	return nil
 }
 
-func (g *gardenerProvisioner) validate(cluster *types.Cluster, provider *types.Provider) error {
+func (g *gardenerProvisioner) ValidateInputs(cluster *types.Cluster, provider *types.Provider) error {
 	var errMessage string
 
 	// Cluster
@@ -155,59 +221,21 @@ func (g *gardenerProvisioner) validate(cluster *types.Cluster, provider *types.P
 		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.ProjectName")
 	}
 
-	// Custom gardener configuration
-	targetProvider, ok := provider.CustomConfigurations["target_provider"]
-	if ok {
-		if targetProvider != string(types.GCP) && targetProvider != string(types.AWS) && targetProvider != string(types.Azure) {
-			errMessage += fmt.Sprintf(errs.Custom, "Provider.CustomConfigurations['target_provider'] has to be one of: gcp, azure, aws")
+	// Custom gardener configuration, the rules of each target provider are the tags of its typed configuration
+	switch targetProvider := provider.CustomConfigurations["target_provider"]; targetProvider {
+	case string(types.GCP):
+		errMessage += providerconfig.Validate(provider.CustomConfigurations, types.GardenerGCPConfig{})
+	case string(types.Azure):
+		errMessage += providerconfig.Validate(provider.CustomConfigurations, types.GardenerAzureConfig{})
+		if _, ok := provider.CustomConfigurations["service_endpoints"]; !ok {
+			provider.CustomConfigurations["service_endpoints"] = []string{""}
 		}
-	} else {
+	case string(types.AWS):
+		errMessage += providerconfig.Validate(provider.CustomConfigurations, types.GardenerAWSConfig{})
+	case nil:
 		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['target_provider']")
-	}
-	if _, ok := provider.CustomConfigurations["target_secret"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['target_secret']")
-	}
-
-	if _, ok := provider.CustomConfigurations["disk_type"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['disk_type']")
-	}
-	if _, ok := provider.CustomConfigurations["worker_minimum"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['worker_minimum']")
-	}
-	if _, ok := provider.CustomConfigurations["worker_maximum"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['worker_maximum']")
-	}
-	if _, ok := provider.CustomConfigurations["worker_max_surge"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['worker_max_surge']")
-	}
-	if _, ok := provider.CustomConfigurations["worker_max_unavailable"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['worker_max_unavailable']")
-	}
-	if _, ok := provider.CustomConfigurations["workercidr"]; !ok && (targetProvider == string(types.GCP) || targetProvider == string(types.Azure)) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['workercidr']")
-	}
-	if _, ok := provider.CustomConfigurations["zones"]; !ok && (targetProvider == string(types.GCP) || targetProvider == string(types.AWS)) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['zone']")
-	}
-	if _, ok := provider.CustomConfigurations["vnetcidr"]; !ok && (targetProvider == string(types.Azure) || targetProvider == string(types.AWS)) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['vnetcidr']")
-	}
-
-	if _, ok := provider.CustomConfigurations["machine_image_name"]; !ok && targetProvider == string(types.Azure) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['machine_image_name']")
-	}
-	if _, ok := provider.CustomConfigurations["machine_image_version"]; !ok && targetProvider == string(types.Azure) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['machine_image_version']")
-	}
-
-	if _, ok := provider.CustomConfigurations["networking_type"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['networking_type']")
-	}
-	if _, ok := provider.CustomConfigurations["service_endpoints"]; !ok && targetProvider == string(types.Azure) {
-		provider.CustomConfigurations["service_endpoints"] = []string{""}
-	}
-	if _, ok := provider.CustomConfigurations["gcp_control_plane_zone"]; !ok && targetProvider == string(types.GCP) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['gcp_control_plane_zone']")
+	default:
+		errMessage += fmt.Sprintf(errs.Custom, "Provider.CustomConfigurations['target_provider'] has to be one of: gcp, azure, aws")
 	}
 
 	if errMessage != "" {
@@ -227,6 +255,9 @@ func (*gardenerProvisioner) loadConfigurations(cluster *types.Cluster, provider
 	config["location"] = cluster.Location
 	config["project"] = provider.ProjectName
 	config["namespace"] = fmt.Sprintf("garden-%s", provider.ProjectName)
+	if labels := cluster.Labels(); labels != nil {
+		config["labels"] = labels
+	}
 
 	for k, v := range provider.CustomConfigurations {
 		config[k] = v
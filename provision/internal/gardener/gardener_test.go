@@ -1,6 +1,7 @@
 package gardener
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -50,17 +51,17 @@ func TestValidate(t *testing.T) {
 
 		//gcp specific validation
 		delete(provider.CustomConfigurations, "target_provider")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 		provider.CustomConfigurations["target_provider"] = "nimbus"
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is not supported")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is not supported")
 		provider.CustomConfigurations["target_provider"] = "gcp"
 
 		delete(provider.CustomConfigurations, "zones")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when zone is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when zone is empty")
 		provider.CustomConfigurations["zones"] = "europe-west3-b"
 
 		delete(provider.CustomConfigurations, "gcp_control_plane_zone")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when gcp_control_plane_zone is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when gcp_control_plane_zone is empty")
 		provider.CustomConfigurations["gcp_control_plane_zone"] = "europe-west-4-b"
 	})
 
@@ -106,13 +107,13 @@ func TestValidate(t *testing.T) {
 		performBasicValidation(t, g, cluster, provider)
 
 		delete(provider.CustomConfigurations, "target_provider")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 		provider.CustomConfigurations["target_provider"] = "nimbus"
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is not supported")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is not supported")
 		provider.CustomConfigurations["target_provider"] = "azure"
 
 		delete(provider.CustomConfigurations, "vnetcidr")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when vnetcidr is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when vnetcidr is empty")
 		provider.CustomConfigurations["vnetcidr"] = "10.250.0.0/19"
 	})
 
@@ -152,93 +153,93 @@ func TestValidate(t *testing.T) {
 
 		//aws specific validation
 		delete(provider.CustomConfigurations, "target_provider")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 		provider.CustomConfigurations["target_provider"] = "nimbus"
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is not supported")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is not supported")
 		provider.CustomConfigurations["target_provider"] = "aws"
 
 		delete(provider.CustomConfigurations, "zones")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when zone is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when zone is empty")
 		provider.CustomConfigurations["zones"] = []string{"eu-west-1b"}
 
 		delete(provider.CustomConfigurations, "vnetcidr")
-		require.Error(t, g.validate(cluster, provider), "Validation should fail when vnetcidr is empty")
+		require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when vnetcidr is empty")
 		provider.CustomConfigurations["vnetcidr"] = "172.31.0.0/16"
 	})
 }
 
 func performBasicValidation(t *testing.T, g gardenerProvisioner, cluster *types.Cluster, provider *types.Provider) {
-	require.NoError(t, g.validate(cluster, provider), "Validation should pass")
+	require.NoError(t, g.ValidateInputs(cluster, provider), "Validation should pass")
 	cluster.NodeCount = -5
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when number of nodes is < 1")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when number of nodes is < 1")
 	cluster.NodeCount = 2
 
 	cluster.Name = ""
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when cluster name is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name is empty")
 	cluster.Name = "This_name_is_for_sure_way_too_long_for_the_cluster"
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when cluster name is too long")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name is too long")
 	cluster.Name = "-invalid-start"
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when cluster name starts with '-'")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
 	cluster.Name = "invalid-end-"
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when cluster name ends with '-'")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
 	cluster.Name = "hydro-cluster"
 
 	cluster.Location = ""
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when cluster location is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster location is empty")
 	cluster.Location = "europe-west3"
 
 	cluster.MachineType = ""
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when cluster machine type is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster machine type is empty")
 	cluster.MachineType = "type1"
 
 	cluster.KubernetesVersion = ""
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when Kubernetes version is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when Kubernetes version is empty")
 	cluster.KubernetesVersion = "1.12"
 
 	cluster.DiskSizeGB = 0
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when disk size is 0 or less")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when disk size is 0 or less")
 	cluster.DiskSizeGB = 30
 
 	provider.CredentialsFilePath = ""
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when credentials file path is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when credentials file path is empty")
 	provider.CredentialsFilePath = "/path/to/credentials"
 
 	provider.ProjectName = ""
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when project name is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when project name is empty")
 	provider.ProjectName = "my-project"
 
 	delete(provider.CustomConfigurations, "target_provider")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 	provider.CustomConfigurations["target_provider"] = "nimbus"
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when target provider is not supported")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is not supported")
 	provider.CustomConfigurations["target_provider"] = "gcp"
 
 	delete(provider.CustomConfigurations, "target_secret")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when target secret is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target secret is empty")
 	provider.CustomConfigurations["target_secret"] = "secret_name"
 
 	delete(provider.CustomConfigurations, "disk_type")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when disk type is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when disk type is empty")
 	provider.CustomConfigurations["disk_type"] = "pd-standard"
 
 	delete(provider.CustomConfigurations, "workercidr")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when workercidr is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when workercidr is empty")
 	provider.CustomConfigurations["workercidr"] = "10.250.0.0/19"
 
 	delete(provider.CustomConfigurations, "worker_minimum")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when worker_minimum is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when worker_minimum is empty")
 	provider.CustomConfigurations["worker_minimum"] = 2
 
 	delete(provider.CustomConfigurations, "worker_maximum")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when worker_maximum is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when worker_maximum is empty")
 	provider.CustomConfigurations["worker_maximum"] = 4
 
 	delete(provider.CustomConfigurations, "worker_max_surge")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when worker_max_surge is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when worker_max_surge is empty")
 	provider.CustomConfigurations["worker_max_surge"] = 4
 
 	delete(provider.CustomConfigurations, "worker_max_unavailable")
-	require.Error(t, g.validate(cluster, provider), "Validation should fail when worker_max_unavailable is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when worker_max_unavailable is empty")
 	provider.CustomConfigurations["worker_max_unavailable"] = 1
 }
 
@@ -330,21 +331,193 @@ func TestProvision(t *testing.T) {
 			TerraformState: nil,
 		},
 	}
-	mockOp.On("Create", types.Gardener, g.loadConfigurations(cluster, provider)).Return(result, nil)
+	mockOp.On("Create", context.Background(), types.Gardener, g.loadConfigurations(cluster, provider)).Return(result, nil)
 
-	cluster, err := g.Provision(cluster, provider)
+	cluster, err := g.Provision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Provision should succeed")
 	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
 
 	badCluster := &types.Cluster{
 		CPU: 1,
 	}
-	mockOp.On("Create", types.Gardener, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
+	mockOp.On("Create", context.Background(), types.Gardener, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
 
-	_, err = g.Provision(badCluster, provider)
+	_, err = g.Provision(context.Background(), badCluster, provider)
 	require.Error(t, err, "Provision should fail")
 }
 
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	g := gardenerProvisioner{
+		operator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider":        "gcp",
+			"target_secret":          "secret-name",
+			"disk_type":              "pd-standard",
+			"workercidr":             "10.250.0.0/19",
+			"worker_max_surge":       4,
+			"worker_max_unavailable": 1,
+			"worker_maximum":         4,
+			"worker_minimum":         2,
+			"zones":                  []string{"eu-west-1b"},
+			"gcp_control_plane_zone": "europe-west3-b",
+			"networking_type":        "calico",
+		},
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: state,
+		},
+	}
+	mockOp.On("Update", context.Background(), state, types.Gardener, g.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	cluster, err := g.Update(context.Background(), cluster, provider)
+	require.NoError(t, err, "Update should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Update")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Update", context.Background(), (*statefile.File)(nil), types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to update cluster"))
+
+	_, err = g.Update(context.Background(), cluster, provider)
+	require.Error(t, err, "Update should fail")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	g := gardenerProvisioner{
+		operator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider":        "gcp",
+			"target_secret":          "secret-name",
+			"disk_type":              "pd-standard",
+			"workercidr":             "10.250.0.0/19",
+			"worker_max_surge":       4,
+			"worker_max_unavailable": 1,
+			"worker_maximum":         4,
+			"worker_minimum":         2,
+			"zones":                  []string{"eu-west-1b"},
+			"gcp_control_plane_zone": "europe-west3-b",
+			"networking_type":        "calico",
+		},
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.Plan{
+		Changes: []types.ResourceChange{
+			{Address: "some_resource.cluster", Type: "some_resource", Action: types.UpdateAction},
+		},
+	}
+	mockOp.On("Plan", context.Background(), state, types.Gardener, g.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	plan, err := g.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, result, plan, "The plan returned from the operator should be returned by Plan")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Plan", context.Background(), (*statefile.File)(nil), types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to plan cluster"))
+
+	_, err = g.Plan(context.Background(), cluster, provider)
+	require.Error(t, err, "Plan should fail")
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	g := gardenerProvisioner{
+		operator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider":        "gcp",
+			"target_secret":          "secret-name",
+			"disk_type":              "pd-standard",
+			"workercidr":             "10.250.0.0/19",
+			"worker_max_surge":       4,
+			"worker_max_unavailable": 1,
+			"worker_maximum":         4,
+			"worker_minimum":         2,
+			"zones":                  []string{"eu-west-1b"},
+			"gcp_control_plane_zone": "europe-west3-b",
+			"networking_type":        "calico",
+		},
+	}
+
+	info := &types.ClusterInfo{
+		Endpoint:      "1.2.3.4",
+		InternalState: &types.InternalState{TerraformState: &statefile.File{}},
+	}
+	mockOp.On("Import", context.Background(), types.Gardener, g.loadConfigurations(cluster, provider)).Return(info, nil).Once()
+
+	imported, err := g.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, info, imported.ClusterInfo, "The cluster should carry the state of the imported cluster")
+
+	mockOp.On("Import", context.Background(), types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to import cluster")).Once()
+
+	_, err = g.Import(context.Background(), cluster, provider)
+	require.Error(t, err, "Import should fail")
+}
+
 func TestDeProvision(t *testing.T) {
 	t.Parallel()
 	mockOp := &mocks.Operator{}
@@ -381,14 +554,14 @@ func TestDeProvision(t *testing.T) {
 		},
 	}
 	var state *statefile.File
-	mockOp.On("Delete", state, types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil)
+	mockOp.On("Delete", context.Background(), state, types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil)
 
-	err := g.Deprovision(cluster, provider)
+	err := g.Deprovision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Deprovision should succeed")
 
 	provider.CredentialsFilePath = "/wrong/credentials"
-	mockOp.On("Delete", state, types.Gardener, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
+	mockOp.On("Delete", context.Background(), state, types.Gardener, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
 
-	err = g.Deprovision(cluster, provider)
+	err = g.Deprovision(context.Background(), cluster, provider)
 	require.Error(t, err, "Deprovision should fail")
 }
@@ -1,7 +1,9 @@
 package gcp
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"regexp"
 
 	"github.com/hashicorp/terraform/states/statefile"
@@ -11,24 +13,29 @@ import (
 	"github.com/kyma-incubator/hydroform/provision/internal/operator"
 	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
 
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
 // gcpProvisioner implements Provisioner
 type gcpProvisioner struct {
 	provisionOperator operator.Operator
+	// token returns an OAuth2 access token of the service account in the credentials file
+	token func(ctx context.Context, credentialsFile string) (string, error)
 }
 
 // Provision requests provisioning of a new Kubernetes cluster on GCP with the given configurations.
-func (g *gcpProvisioner) Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
-	if err := g.validateInputs(cluster, provider); err != nil {
+func (g *gcpProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if err := g.ValidateInputs(cluster, provider); err != nil {
 		return cluster, err
 	}
 
 	config := g.loadConfigurations(cluster, provider)
 
-	clusterInfo, err := g.provisionOperator.Create(provider.Type, config)
+	clusterInfo, err := g.provisionOperator.Create(ctx, provider.Type, config)
 	if err != nil {
 		return cluster, errors.Wrap(err, "unable to provision gcp cluster")
 	}
@@ -38,31 +45,75 @@ func (g *gcpProvisioner) Provision(cluster *types.Cluster, provider *types.Provi
 }
 
 // Status returns the ClusterStatus for the requested cluster.
-func (g *gcpProvisioner) Status(cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
+func (g *gcpProvisioner) Status(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
 	var state *statefile.File
 	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	if err := g.validateInputs(cluster, p); err != nil {
+	if err := g.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 
 	cfg := g.loadConfigurations(cluster, p)
 
-	return g.provisionOperator.Status(state, p.Type, cfg)
+	return g.provisionOperator.Status(ctx, state, p.Type, cfg)
 }
 
 // Credentials returns the Kubeconfig file as a byte array for the requested cluster.
-func (g *gcpProvisioner) Credentials(cluster *types.Cluster, p *types.Provider) ([]byte, error) {
-	if err := g.validateInputs(cluster, p); err != nil {
+// If the cluster carries no endpoint and certificate authority, they are read from its state in the state backend.
+func (g *gcpProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
-	if cluster.ClusterInfo == nil || cluster.ClusterInfo.Endpoint == "" || cluster.ClusterInfo.CertificateAuthorityData == nil {
-		// TODO add a way to get endpoint and CA from the state file if possible
-		return nil, errors.New(errs.EmptyClusterInfo)
+	if err := g.loadClusterInfo(ctx, cluster, p); err != nil {
+		return nil, err
 	}
 
+	return kubeconfig(cluster, &api.AuthInfo{
+		AuthProvider: &api.AuthProviderConfig{
+			Name: "gcp",
+		},
+	})
+}
+
+// AdminCredentials returns a kubeconfig for the requested cluster that authenticates with an access token of the service account in the credentials file.
+// Unlike the kubeconfig of Credentials, it does not need the gcp auth provider, which requires the Google Cloud SDK. The token expires after an hour.
+func (g *gcpProvisioner) AdminCredentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+	if err := g.loadClusterInfo(ctx, cluster, p); err != nil {
+		return nil, err
+	}
+
+	token, err := g.token(ctx, p.CredentialsFilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get an access token for the gcp service account")
+	}
+
+	return kubeconfig(cluster, &api.AuthInfo{Token: token})
+}
+
+// loadClusterInfo rebuilds the ClusterInfo of the cluster from its state, unless the cluster carries the endpoint and certificate authority already.
+func (g *gcpProvisioner) loadClusterInfo(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.Endpoint != "" && cluster.ClusterInfo.CertificateAuthorityData != nil {
+		return nil
+	}
+
+	info, err := g.provisionOperator.ClusterInfo(ctx, p.Type, g.loadConfigurations(cluster, p))
+	if err != nil {
+		return errors.Wrap(err, errs.NoClusterInfo)
+	}
+	if info.Endpoint == "" || info.CertificateAuthorityData == nil {
+		return errors.New(errs.NoClusterInfo)
+	}
+	cluster.ClusterInfo = info
+	return nil
+}
+
+// kubeconfig returns a kubeconfig for the cluster with the given user.
+func kubeconfig(cluster *types.Cluster, user *api.AuthInfo) ([]byte, error) {
 	userName := "cluster-user"
 	config := api.NewConfig()
 
@@ -78,18 +129,93 @@ func (g *gcpProvisioner) Credentials(cluster *types.Cluster, p *types.Provider)
 
 	config.CurrentContext = cluster.Name
 
-	config.AuthInfos[userName] = &api.AuthInfo{
-		AuthProvider: &api.AuthProviderConfig{
-			Name: "gcp",
-		},
-	}
+	config.AuthInfos[userName] = user
 
 	return clientcmd.Write(*config)
 }
 
+// serviceAccountToken returns an access token of the service account in the given credentials file.
+func serviceAccountToken(ctx context.Context, credentialsFile string) (string, error) {
+	data, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return "", err
+	}
+	creds, err := google.CredentialsFromJSON(ctx, data, cloudPlatformScope)
+	if err != nil {
+		return "", err
+	}
+	token, err := creds.TokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Update applies changes of the node count, machine type, or Kubernetes version to an existing cluster on GCP.
+// The cluster needs to carry the internal state returned by Provision, otherwise the state is loaded from the data directory.
+func (g *gcpProvisioner) Update(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := g.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	clusterInfo, err := g.provisionOperator.Update(ctx, state, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update gcp cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Plan returns the changes provisioning, updating, or deprovisioning (with the PlanDestroy option) the cluster on GCP would make, without applying any of them.
+func (g *gcpProvisioner) Plan(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Plan, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	config := g.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	plan, err := g.provisionOperator.Plan(ctx, state, p.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan gcp cluster")
+	}
+
+	return plan, nil
+}
+
+// Import brings an existing cluster on GCP, created outside of Hydroform or whose state was lost, under the management of Hydroform.
+// It returns the cluster enriched with its new internal state, which the other operations need.
+func (g *gcpProvisioner) Import(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := g.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := g.loadConfigurations(cluster, p)
+
+	clusterInfo, err := g.provisionOperator.Import(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to import gcp cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
 // Deprovision requests deprovisioning of an existing cluster on GCP with the given configurations.
-func (g *gcpProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider) error {
-	if err := g.validateInputs(cluster, p); err != nil {
+func (g *gcpProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if err := g.ValidateInputs(cluster, p); err != nil {
 		return err
 	}
 
@@ -100,7 +226,7 @@ func (g *gcpProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider)
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	err := g.provisionOperator.Delete(state, p.Type, config)
+	err := g.provisionOperator.Delete(ctx, state, p.Type, config)
 	if err != nil {
 		return errors.Wrap(err, "unable to deprovision gcp cluster")
 	}
@@ -127,10 +253,11 @@ func New(operatorType operator.Type, ops ...types.Option) *gcpProvisioner {
 
 	return &gcpProvisioner{
 		provisionOperator: op,
+		token:             serviceAccountToken,
 	}
 }
 
-func (g *gcpProvisioner) validateInputs(cluster *types.Cluster, provider *types.Provider) error {
+func (g *gcpProvisioner) ValidateInputs(cluster *types.Cluster, provider *types.Provider) error {
 	var errMessage string
 	if cluster.NodeCount < 1 {
 		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 1)
@@ -177,6 +304,9 @@ func (g *gcpProvisioner) loadConfigurations(cluster *types.Cluster, provider *ty
 	config["location"] = cluster.Location
 	config["project"] = provider.ProjectName
 	config["credentials_file_path"] = provider.CredentialsFilePath
+	if labels := cluster.Labels(); labels != nil {
+		config["labels"] = labels
+	}
 	for k, v := range provider.CustomConfigurations {
 		config[k] = v
 	}
@@ -1,6 +1,7 @@
 package gcp
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func TestValidateInputs(t *testing.T) {
@@ -38,58 +40,58 @@ func TestValidateInputs(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, g.validateInputs(cluster, provider), "Validation should pass")
+	require.NoError(t, g.ValidateInputs(cluster, provider), "Validation should pass")
 
 	cluster.NodeCount = -5
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when number of nodes is < 1")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when number of nodes is < 1")
 	cluster.NodeCount = 2
 
 	cluster.Name = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name is empty")
 	cluster.Name = "This_name_is_for_sure_way_too_long_for_the_cluster"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name is too long")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name is too long")
 	cluster.Name = "-invalid-start"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
 	cluster.Name = "invalid-end-"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
 	cluster.Name = "hydro-cluster"
 
 	cluster.Location = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster location is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster location is empty")
 	cluster.Location = "europe-west3"
 
 	cluster.MachineType = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when cluster machine type is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when cluster machine type is empty")
 	cluster.Location = "type1"
 
 	cluster.KubernetesVersion = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when Kubernetes version is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when Kubernetes version is empty")
 	cluster.KubernetesVersion = "1.12"
 
 	cluster.DiskSizeGB = 0
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when disk size is 0 or less")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when disk size is 0 or less")
 	cluster.DiskSizeGB = 30
 
 	provider.CredentialsFilePath = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when credentials file path is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when credentials file path is empty")
 	provider.CredentialsFilePath = "/path/to/credentials"
 
 	provider.ProjectName = ""
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when project name is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when project name is empty")
 	provider.ProjectName = "my-project"
 
 	delete(provider.CustomConfigurations, "target_provider")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target provider is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 	provider.CustomConfigurations["target_provider"] = "nimbus"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target provider is not supported")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target provider is not supported")
 	provider.CustomConfigurations["target_provider"] = "gcp"
 
 	delete(provider.CustomConfigurations, "target_secret")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target secret is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when target secret is empty")
 	provider.CustomConfigurations["target_secret"] = "secret_name"
 
 	delete(provider.CustomConfigurations, "disk_type")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when disk type is empty")
+	require.Error(t, g.ValidateInputs(cluster, provider), "Validation should fail when disk type is empty")
 }
 
 func TestLoadConfigurations(t *testing.T) {
@@ -134,6 +136,79 @@ func TestLoadConfigurations(t *testing.T) {
 	}
 }
 
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+	g := &gcpProvisioner{
+		token: func(ctx context.Context, credentialsFile string) (string, error) {
+			require.Equal(t, "/path/to/credentials", credentialsFile)
+			return "access-token", nil
+		},
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+		ClusterInfo: &types.ClusterInfo{
+			Endpoint:                 "10.0.0.1",
+			CertificateAuthorityData: []byte("ca"),
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+		},
+	}
+
+	kubeconfig, err := g.Credentials(context.Background(), cluster, provider)
+	require.NoError(t, err)
+	config, err := clientcmd.Load(kubeconfig)
+	require.NoError(t, err)
+	require.Equal(t, "gcp", config.AuthInfos["cluster-user"].AuthProvider.Name)
+
+	kubeconfig, err = g.AdminCredentials(context.Background(), cluster, provider)
+	require.NoError(t, err)
+	config, err = clientcmd.Load(kubeconfig)
+	require.NoError(t, err)
+	require.Equal(t, "access-token", config.AuthInfos["cluster-user"].Token)
+	require.Nil(t, config.AuthInfos["cluster-user"].AuthProvider, "The admin kubeconfig should not need the gcp auth provider")
+	require.Equal(t, "https://10.0.0.1", config.Clusters["hydro-cluster"].Server)
+
+	// a cluster without info gets it from the state in the state backend
+	mockOp := &mocks.Operator{}
+	g.provisionOperator = mockOp
+	info := cluster.ClusterInfo
+	cluster.ClusterInfo = nil
+	mockOp.On("ClusterInfo", context.Background(), types.GCP, g.loadConfigurations(cluster, provider)).Return(info, nil).Once()
+	kubeconfig, err = g.Credentials(context.Background(), cluster, provider)
+	require.NoError(t, err)
+	config, err = clientcmd.Load(kubeconfig)
+	require.NoError(t, err)
+	require.Equal(t, "https://10.0.0.1", config.Clusters["hydro-cluster"].Server, "Credentials should rebuild the cluster info from the state")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("ClusterInfo", context.Background(), types.GCP, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("no state")).Once()
+	_, err = g.Credentials(context.Background(), cluster, provider)
+	require.Error(t, err, "Credentials should fail without cluster info and state")
+	mockOp.AssertExpectations(t)
+	cluster.ClusterInfo = info
+
+	g.token = func(ctx context.Context, credentialsFile string) (string, error) {
+		return "", errors.New("invalid key")
+	}
+	_, err = g.AdminCredentials(context.Background(), cluster, provider)
+	require.Error(t, err, "A failing token request should fail")
+}
+
 func TestProvision(t *testing.T) {
 	t.Parallel()
 	mockOp := &mocks.Operator{}
@@ -172,21 +247,175 @@ func TestProvision(t *testing.T) {
 			TerraformState: nil,
 		},
 	}
-	mockOp.On("Create", types.GCP, g.loadConfigurations(cluster, provider)).Return(result, nil)
+	mockOp.On("Create", context.Background(), types.GCP, g.loadConfigurations(cluster, provider)).Return(result, nil)
 
-	cluster, err := g.Provision(cluster, provider)
+	cluster, err := g.Provision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Provision should succeed")
 	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
 
 	badCluster := &types.Cluster{
 		CPU: 1,
 	}
-	mockOp.On("Create", types.GCP, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
+	mockOp.On("Create", context.Background(), types.GCP, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
 
-	_, err = g.Provision(badCluster, provider)
+	_, err = g.Provision(context.Background(), badCluster, provider)
 	require.Error(t, err, "Provision should fail")
 }
 
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider":        "gcp",
+			"target_secret":          "secret-name",
+			"disk_type":              "pd-standard",
+			"zones":                  []string{"eu-west-1b"},
+			"gcp_control_plane_zone": "europe-west3-b",
+		},
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: state,
+		},
+	}
+	mockOp.On("Update", context.Background(), state, types.GCP, g.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	cluster, err := g.Update(context.Background(), cluster, provider)
+	require.NoError(t, err, "Update should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Update")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Update", context.Background(), (*statefile.File)(nil), types.GCP, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to update cluster"))
+
+	_, err = g.Update(context.Background(), cluster, provider)
+	require.Error(t, err, "Update should fail")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider":        "gcp",
+			"target_secret":          "secret-name",
+			"disk_type":              "pd-standard",
+			"zones":                  []string{"eu-west-1b"},
+			"gcp_control_plane_zone": "europe-west3-b",
+		},
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.Plan{
+		Changes: []types.ResourceChange{
+			{Address: "some_resource.cluster", Type: "some_resource", Action: types.UpdateAction},
+		},
+	}
+	mockOp.On("Plan", context.Background(), state, types.GCP, g.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	plan, err := g.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, result, plan, "The plan returned from the operator should be returned by Plan")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Plan", context.Background(), (*statefile.File)(nil), types.GCP, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to plan cluster"))
+
+	_, err = g.Plan(context.Background(), cluster, provider)
+	require.Error(t, err, "Plan should fail")
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider":        "gcp",
+			"target_secret":          "secret-name",
+			"disk_type":              "pd-standard",
+			"zones":                  []string{"eu-west-1b"},
+			"gcp_control_plane_zone": "europe-west3-b",
+		},
+	}
+
+	info := &types.ClusterInfo{
+		Endpoint:      "1.2.3.4",
+		InternalState: &types.InternalState{TerraformState: &statefile.File{}},
+	}
+	mockOp.On("Import", context.Background(), types.GCP, g.loadConfigurations(cluster, provider)).Return(info, nil).Once()
+
+	imported, err := g.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, info, imported.ClusterInfo, "The cluster should carry the state of the imported cluster")
+
+	mockOp.On("Import", context.Background(), types.GCP, g.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to import cluster")).Once()
+
+	_, err = g.Import(context.Background(), cluster, provider)
+	require.Error(t, err, "Import should fail")
+}
+
 func TestDeprovision(t *testing.T) {
 	t.Parallel()
 	mockOp := &mocks.Operator{}
@@ -218,14 +447,14 @@ func TestDeprovision(t *testing.T) {
 	}
 
 	var state *statefile.File
-	mockOp.On("Delete", state, types.GCP, g.loadConfigurations(cluster, provider)).Return(nil)
+	mockOp.On("Delete", context.Background(), state, types.GCP, g.loadConfigurations(cluster, provider)).Return(nil)
 
-	err := g.Deprovision(cluster, provider)
+	err := g.Deprovision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Deprovision should succeed")
 
 	provider.CredentialsFilePath = "/wrong/credentials"
-	mockOp.On("Delete", state, types.GCP, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
+	mockOp.On("Delete", context.Background(), state, types.GCP, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
 
-	err = g.Deprovision(cluster, provider)
+	err = g.Deprovision(context.Background(), cluster, provider)
 	require.Error(t, err, "Deprovision should fail")
 }
@@ -0,0 +1,213 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	systemNamespace = "kube-system"
+	// requestTimeout limits each request, so that an unreachable cluster does not block the status
+	requestTimeout = 15 * time.Second
+)
+
+// Checker runs health checks against a cluster.
+type Checker struct {
+	newClient func(kubeconfig []byte) (kubernetes.Interface, error)
+}
+
+// NewChecker creates a Checker that connects to the cluster with a kubeconfig.
+func NewChecker() *Checker {
+	return &Checker{
+		newClient: newClient,
+	}
+}
+
+// Check connects to the cluster with the given kubeconfig and returns one condition per health check:
+// the API server must be reachable, all nodes must be ready, and all pods in the kube-system namespace must be ready.
+// The checks depending on the API server are unknown if it is not reachable.
+// All checks are unknown if the kubeconfig cannot authenticate, since that says nothing about the health of the cluster.
+func (c *Checker) Check(ctx context.Context, kubeconfig []byte) []types.Condition {
+	client, err := c.newClient(kubeconfig)
+	if err != nil {
+		return unauthenticated("InvalidCredentials", err)
+	}
+
+	if err := checkAPIServer(ctx, client); err != nil {
+		switch {
+		case k8serrors.IsUnauthorized(err) || k8serrors.IsForbidden(err):
+			return unauthenticated("CredentialsRejected", err)
+		case credentialPluginFailed(err):
+			return unauthenticated("CredentialPluginFailed", err)
+		}
+		return unreachable("RequestFailed", err)
+	}
+
+	return []types.Condition{
+		{Type: types.APIServerReachable, Status: types.ConditionTrue},
+		checkNodes(ctx, client),
+		checkSystemPods(ctx, client),
+	}
+}
+
+// Phase returns the phase of a provisioned cluster according to its conditions.
+// The cluster stays provisioned if it is unknown whether the API server is reachable.
+func Phase(conditions []types.Condition) types.Phase {
+	phase := types.Provisioned
+	for _, c := range conditions {
+		if c.Status == types.ConditionTrue {
+			continue
+		}
+		if c.Type == types.APIServerReachable {
+			if c.Status == types.ConditionUnknown {
+				return types.Provisioned
+			}
+			return types.Errored
+		}
+		phase = types.Degraded
+	}
+	return phase
+}
+
+func newClient(kubeconfig []byte) (kubernetes.Interface, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = requestTimeout
+
+	return kubernetes.NewForConfig(config)
+}
+
+func checkAPIServer(ctx context.Context, client kubernetes.Interface) error {
+	// any answer of the API server will do, even if the namespace is missing
+	_, err := client.CoreV1().Namespaces().Get(ctx, systemNamespace, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func checkNodes(ctx context.Context, client kubernetes.Interface) types.Condition {
+	cond := types.Condition{Type: types.NodesReady}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		cond.Status = types.ConditionUnknown
+		cond.Reason = "RequestFailed"
+		cond.Message = errors.Wrap(err, "could not list the nodes").Error()
+		return cond
+	}
+	if len(nodes.Items) == 0 {
+		cond.Status = types.ConditionFalse
+		cond.Reason = "NoNodes"
+		cond.Message = "the cluster has no nodes"
+		return cond
+	}
+
+	var notReady []string
+	for _, n := range nodes.Items {
+		if !nodeReady(n) {
+			notReady = append(notReady, n.Name)
+		}
+	}
+	if len(notReady) > 0 {
+		cond.Status = types.ConditionFalse
+		cond.Reason = "NodesNotReady"
+		cond.Message = notReadyMessage("nodes", notReady, len(nodes.Items))
+		return cond
+	}
+
+	cond.Status = types.ConditionTrue
+	return cond
+}
+
+func checkSystemPods(ctx context.Context, client kubernetes.Interface) types.Condition {
+	cond := types.Condition{Type: types.SystemPodsReady}
+
+	pods, err := client.CoreV1().Pods(systemNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		cond.Status = types.ConditionUnknown
+		cond.Reason = "RequestFailed"
+		cond.Message = errors.Wrap(err, "could not list the system pods").Error()
+		return cond
+	}
+
+	var notReady []string
+	for _, p := range pods.Items {
+		// pods of completed jobs are fine
+		if p.Status.Phase != corev1.PodSucceeded && !podReady(p) {
+			notReady = append(notReady, p.Name)
+		}
+	}
+	if len(notReady) > 0 {
+		cond.Status = types.ConditionFalse
+		cond.Reason = "PodsNotReady"
+		cond.Message = notReadyMessage("system pods", notReady, len(pods.Items))
+		return cond
+	}
+
+	cond.Status = types.ConditionTrue
+	return cond
+}
+
+func nodeReady(n corev1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func podReady(p corev1.Pod) bool {
+	if p.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// unreachable returns the conditions of a cluster whose API server cannot be reached.
+func unreachable(reason string, err error) []types.Condition {
+	return []types.Condition{
+		{Type: types.APIServerReachable, Status: types.ConditionFalse, Reason: reason, Message: err.Error()},
+		{Type: types.NodesReady, Status: types.ConditionUnknown, Reason: "APIServerUnreachable"},
+		{Type: types.SystemPodsReady, Status: types.ConditionUnknown, Reason: "APIServerUnreachable"},
+	}
+}
+
+// unauthenticated returns the conditions of a cluster that cannot be checked because its credentials do not work.
+func unauthenticated(reason string, err error) []types.Condition {
+	return []types.Condition{
+		{Type: types.APIServerReachable, Status: types.ConditionUnknown, Reason: reason, Message: err.Error()},
+		{Type: types.NodesReady, Status: types.ConditionUnknown, Reason: reason},
+		{Type: types.SystemPodsReady, Status: types.ConditionUnknown, Reason: reason},
+	}
+}
+
+// credentialPluginFailed tells if a request failed because the exec credential plugin of the kubeconfig could not get a token,
+// for example because the command line tool of the provider is not installed.
+func credentialPluginFailed(err error) bool {
+	return strings.Contains(err.Error(), "getting credentials:")
+}
+
+func notReadyMessage(kind string, names []string, total int) string {
+	sort.Strings(names)
+	return fmt.Sprintf("%d of %d %s are not ready: %s", len(names), total, kind, strings.Join(names, ", "))
+}
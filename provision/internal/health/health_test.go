@@ -0,0 +1,162 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckHealthyCluster(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset(
+		node("node-1", corev1.ConditionTrue),
+		node("node-2", corev1.ConditionTrue),
+		pod("kube-dns", corev1.PodRunning, corev1.ConditionTrue),
+		pod("kube-proxy", corev1.PodRunning, corev1.ConditionTrue),
+		pod("setup-job", corev1.PodSucceeded, corev1.ConditionFalse),
+	)
+
+	conditions := checker(client).Check(context.Background(), []byte("kubeconfig"))
+
+	require.Len(t, conditions, 3)
+	for _, c := range conditions {
+		require.Equal(t, types.ConditionTrue, c.Status, "Check %s should pass", c.Type)
+	}
+	require.Equal(t, types.Provisioned, Phase(conditions))
+}
+
+func TestCheckDegradedCluster(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset(
+		node("node-1", corev1.ConditionTrue),
+		node("node-2", corev1.ConditionFalse),
+		pod("kube-dns", corev1.PodPending, corev1.ConditionFalse),
+		pod("kube-proxy", corev1.PodRunning, corev1.ConditionTrue),
+	)
+
+	conditions := checker(client).Check(context.Background(), []byte("kubeconfig"))
+
+	require.Equal(t, types.Condition{Type: types.APIServerReachable, Status: types.ConditionTrue}, conditions[0])
+	require.Equal(t, types.Condition{
+		Type:    types.NodesReady,
+		Status:  types.ConditionFalse,
+		Reason:  "NodesNotReady",
+		Message: "1 of 2 nodes are not ready: node-2",
+	}, conditions[1])
+	require.Equal(t, types.Condition{
+		Type:    types.SystemPodsReady,
+		Status:  types.ConditionFalse,
+		Reason:  "PodsNotReady",
+		Message: "1 of 2 system pods are not ready: kube-dns",
+	}, conditions[2])
+	require.Equal(t, types.Degraded, Phase(conditions))
+
+	conditions = checker(fake.NewSimpleClientset()).Check(context.Background(), []byte("kubeconfig"))
+	require.Equal(t, types.ConditionFalse, conditions[1].Status, "A cluster without nodes should not be ready")
+	require.Equal(t, "NoNodes", conditions[1].Reason)
+}
+
+func TestCheckUnreachableCluster(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	conditions := checker(client).Check(context.Background(), []byte("kubeconfig"))
+
+	require.Equal(t, types.ConditionFalse, conditions[0].Status)
+	require.Equal(t, "connection refused", conditions[0].Message)
+	require.Equal(t, types.ConditionUnknown, conditions[1].Status, "Nodes cannot be checked without API server")
+	require.Equal(t, types.ConditionUnknown, conditions[2].Status, "System pods cannot be checked without API server")
+	require.Equal(t, types.Errored, Phase(conditions))
+}
+
+func TestCheckUnauthenticatedCluster(t *testing.T) {
+	t.Parallel()
+	c := &Checker{newClient: func([]byte) (kubernetes.Interface, error) {
+		return nil, errors.New("invalid kubeconfig")
+	}}
+	conditions := c.Check(context.Background(), []byte("kubeconfig"))
+	require.Equal(t, types.ConditionUnknown, conditions[0].Status, "Invalid credentials say nothing about the API server")
+	require.Equal(t, "InvalidCredentials", conditions[0].Reason)
+	require.Equal(t, types.Provisioned, Phase(conditions), "Invalid credentials should not change the phase")
+
+	for reason, err := range map[string]error{
+		"CredentialsRejected":    k8serrors.NewUnauthorized("invalid token"),
+		"CredentialPluginFailed": errors.New(`Get "https://cluster": getting credentials: exec: executable aws not found`),
+	} {
+		err := err
+		client := fake.NewSimpleClientset()
+		client.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, err
+		})
+
+		conditions := checker(client).Check(context.Background(), []byte("kubeconfig"))
+		for _, c := range conditions {
+			require.Equal(t, types.ConditionUnknown, c.Status, "Check %s should be unknown", c.Type)
+			require.Equal(t, reason, c.Reason)
+		}
+		require.Equal(t, types.Provisioned, Phase(conditions))
+	}
+
+	_, err := newClient([]byte("not a kubeconfig"))
+	require.Error(t, err, "An invalid kubeconfig should fail")
+
+	_, err = newClient([]byte(gcpKubeconfig))
+	require.Error(t, err, "The gcp auth provider is not registered, so a kubeconfig using it should fail")
+}
+
+const gcpKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: https://cluster
+contexts:
+- name: cluster
+  context:
+    cluster: cluster
+    user: user
+current-context: cluster
+users:
+- name: user
+  user:
+    auth-provider:
+      name: gcp
+`
+
+func checker(client kubernetes.Interface) *Checker {
+	return &Checker{newClient: func([]byte) (kubernetes.Interface, error) {
+		return client, nil
+	}}
+}
+
+func node(name string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func pod(name string, phase corev1.PodPhase, ready corev1.ConditionStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: systemNamespace},
+		Status: corev1.PodStatus{
+			Phase:      phase,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+		},
+	}
+}
@@ -0,0 +1,192 @@
+package k3d
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/errs"
+	"github.com/kyma-incubator/hydroform/provision/internal/operator"
+	"github.com/kyma-incubator/hydroform/provision/internal/operator/native"
+	"github.com/kyma-incubator/hydroform/provision/internal/providerconfig"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+// port mappings in the k3d format: [HOST:][HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]
+var portMappingRegex = regexp.MustCompile(`^(?:[0-9.]+:)?(?:\d{1,5}:)?\d{1,5}(?:/(?:tcp|udp|TCP|UDP))?(?:@[\w:*\[\]-]+)?$`)
+
+// k3dProvisioner implements Provisioner
+type k3dProvisioner struct {
+	provisionOperator operator.Operator
+	// kubeconfig returns the admin kubeconfig of the cluster with the given name.
+	kubeconfig func(ctx context.Context, name string) ([]byte, error)
+}
+
+// New creates a new instance of k3dProvisioner.
+// k3d clusters are always managed by the native operator, which runs the k3d command line tool.
+func New(operatorType operator.Type, ops ...types.Option) *k3dProvisioner {
+	// parse config
+	os := &types.Options{}
+	for _, o := range ops {
+		o(os)
+	}
+
+	k := &k3dProvisioner{}
+	switch operatorType {
+	case operator.NativeOperator:
+		op := native.NewK3d(os)
+		k.provisionOperator = op
+		k.kubeconfig = op.Kubeconfig
+	default:
+		k.provisionOperator = &operator.Unknown{}
+		k.kubeconfig = func(context.Context, string) ([]byte, error) {
+			return nil, errors.New("unknown operator")
+		}
+	}
+	return k
+}
+
+// Provision requests provisioning of a new Kubernetes cluster on k3d with the given configurations.
+func (k *k3dProvisioner) Provision(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	config := k.loadConfigurations(cluster, p)
+
+	clusterInfo, err := k.provisionOperator.Create(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to provision k3d cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Status returns the ClusterStatus for the requested cluster.
+func (k *k3dProvisioner) Status(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	cfg := k.loadConfigurations(cluster, p)
+
+	return k.provisionOperator.Status(ctx, nil, p.Type, cfg)
+}
+
+// Credentials returns the admin kubeconfig of the requested cluster, as written by k3d.
+func (k *k3dProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	return k.kubeconfig(ctx, cluster.Name)
+}
+
+// Update returns an error, since k3d cannot change an existing cluster.
+func (k *k3dProvisioner) Update(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	return cluster, k.RejectUpdate()
+}
+
+// RejectUpdate returns the error of Update, so that Hydroform does not check the cluster before updating it in vain.
+func (k *k3dProvisioner) RejectUpdate() error {
+	return errors.New("k3d clusters cannot be updated, deprovision and provision the cluster again instead")
+}
+
+// Plan returns whether provisioning or deprovisioning (with the PlanDestroy option) the cluster on k3d would create or delete it, without doing so.
+func (k *k3dProvisioner) Plan(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Plan, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
+
+	config := k.loadConfigurations(cluster, p)
+
+	plan, err := k.provisionOperator.Plan(ctx, nil, p.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan k3d cluster")
+	}
+
+	return plan, nil
+}
+
+// Import returns the information of an existing k3d cluster created outside of Hydroform.
+func (k *k3dProvisioner) Import(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := k.loadConfigurations(cluster, p)
+
+	clusterInfo, err := k.provisionOperator.Import(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to import k3d cluster")
+	}
+
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Deprovision requests deprovisioning of an existing cluster on k3d, including its registry.
+func (k *k3dProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return err
+	}
+
+	config := k.loadConfigurations(cluster, p)
+
+	err := k.provisionOperator.Delete(ctx, nil, p.Type, config)
+	if err != nil {
+		return errors.Wrap(err, "unable to deprovision k3d cluster")
+	}
+
+	return nil
+}
+
+func (k *k3dProvisioner) ValidateInputs(cluster *types.Cluster, provider *types.Provider) error {
+	var errMessage string
+	// Matches the k3d cluster names, which are prefixed with k3d- for the docker resources.
+	if match, _ := regexp.MatchString(`^(?:[a-z](?:[-a-z0-9]{0,30}[a-z0-9])?)$`, cluster.Name); !match {
+		errMessage += fmt.Sprintf(errs.Custom, "Cluster.Name must start with a lowercase letter followed by up to 31 lowercase letters, "+
+			"numbers, or hyphens, and cannot end with a hyphen")
+	}
+	if cluster.NodeCount < 0 {
+		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 0)
+	}
+
+	if provider.CustomConfigurations != nil {
+		errMessage += providerconfig.Validate(provider.CustomConfigurations, types.K3dConfig{})
+		if mappings, ok := provider.CustomConfigurations["port_mappings"].([]string); ok {
+			for _, m := range mappings {
+				if !portMappingRegex.MatchString(m) {
+					errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("Provider.CustomConfigurations['port_mappings'] has an invalid mapping %q, use [HOSTPORT:]CONTAINERPORT[/PROTOCOL][@NODEFILTER]", m))
+				}
+			}
+		}
+	}
+
+	if errMessage != "" {
+		return errors.New("input validation failed with the following information: " + errMessage)
+	}
+
+	return nil
+}
+
+func (k *k3dProvisioner) loadConfigurations(cluster *types.Cluster, p *types.Provider) map[string]interface{} {
+	config := map[string]interface{}{}
+	config["cluster_name"] = cluster.Name
+	config["project"] = p.ProjectName
+	config["agents"] = cluster.NodeCount
+	config["servers"] = 1
+	if cluster.KubernetesVersion != "" {
+		config["image"] = fmt.Sprintf("rancher/k3s:v%s-k3s1", strings.TrimPrefix(cluster.KubernetesVersion, "v"))
+	}
+	if labels := cluster.Labels(); labels != nil {
+		config["labels"] = labels
+	}
+	for k, v := range p.CustomConfigurations {
+		config[k] = v
+	}
+	return config
+}
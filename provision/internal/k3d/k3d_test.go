@@ -0,0 +1,120 @@
+package k3d
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/internal/operator/mocks"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateInputs(t *testing.T) {
+	t.Parallel()
+	k := &k3dProvisioner{}
+
+	cluster := &types.Cluster{
+		Name:      "kyma",
+		NodeCount: 2,
+	}
+	provider := &types.Provider{
+		Type: types.K3d,
+		CustomConfigurations: map[string]interface{}{
+			"servers":       1,
+			"port_mappings": []string{"80:80@loadbalancer", "443:443/tcp@loadbalancer", "127.0.0.1:8080:80@agent[0]"},
+		},
+	}
+
+	require.NoError(t, k.ValidateInputs(cluster, provider), "Validation should pass")
+
+	cluster.Name = "This_name_is_for_sure_way_too_long_for_a_k3d_cluster"
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when cluster name is invalid")
+	cluster.Name = "kyma"
+
+	cluster.NodeCount = -1
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when the agent count is negative")
+	cluster.NodeCount = 0
+
+	provider.CustomConfigurations["servers"] = 0
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail without server nodes")
+	provider.CustomConfigurations["servers"] = 3
+
+	provider.CustomConfigurations["port_mappings"] = []string{"eighty"}
+	err := k.ValidateInputs(cluster, provider)
+	require.Error(t, err, "Validation should fail when a port mapping is invalid")
+	require.Contains(t, err.Error(), `invalid mapping "eighty"`)
+}
+
+func TestLoadConfigurations(t *testing.T) {
+	t.Parallel()
+	k := &k3dProvisioner{}
+
+	cluster := &types.Cluster{
+		Name:              "kyma",
+		NodeCount:         2,
+		KubernetesVersion: "1.20.4",
+	}
+	provider := &types.Provider{
+		Type:                 types.K3d,
+		CustomConfigurations: map[string]interface{}{"port_mappings": []string{"80:80@loadbalancer"}},
+	}
+
+	config := k.loadConfigurations(cluster, provider)
+	require.Equal(t, "kyma", config["cluster_name"])
+	require.Equal(t, 2, config["agents"], "The node count should be the number of agents")
+	require.Equal(t, 1, config["servers"], "A single server should be the default")
+	require.Equal(t, "rancher/k3s:v1.20.4-k3s1", config["image"], "The image should follow the Kubernetes version")
+	require.Equal(t, []string{"80:80@loadbalancer"}, config["port_mappings"])
+
+	provider.CustomConfigurations["image"] = "rancher/k3s:latest"
+	require.Equal(t, "rancher/k3s:latest", k.loadConfigurations(cluster, provider)["image"], "A custom image should take precedence")
+}
+
+func TestProvision(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	k := &k3dProvisioner{provisionOperator: mockOp}
+
+	cluster := &types.Cluster{Name: "kyma"}
+	provider := &types.Provider{Type: types.K3d}
+
+	result := &types.ClusterInfo{
+		Endpoint: "https://0.0.0.0:6443",
+		Status:   &types.ClusterStatus{Phase: types.Provisioned},
+	}
+	mockOp.On("Create", context.Background(), types.K3d, k.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	cluster, err := k.Provision(context.Background(), cluster, provider)
+	require.NoError(t, err, "Provision should succeed")
+	require.Equal(t, result, cluster.ClusterInfo)
+
+	badCluster := &types.Cluster{Name: "bad"}
+	mockOp.On("Create", context.Background(), types.K3d, k.loadConfigurations(badCluster, provider)).Return(nil, errors.New("docker is not running"))
+	_, err = k.Provision(context.Background(), badCluster, provider)
+	require.Error(t, err, "Provision should fail")
+}
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+	k := &k3dProvisioner{kubeconfig: func(ctx context.Context, name string) ([]byte, error) {
+		return []byte("kubeconfig of " + name), nil
+	}}
+
+	kubeconfig, err := k.Credentials(context.Background(), &types.Cluster{Name: "kyma"}, &types.Provider{Type: types.K3d})
+	require.NoError(t, err)
+	require.Equal(t, "kubeconfig of kyma", string(kubeconfig))
+}
+
+func TestDeprovision(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	k := &k3dProvisioner{provisionOperator: mockOp}
+
+	cluster := &types.Cluster{Name: "kyma"}
+	provider := &types.Provider{Type: types.K3d}
+
+	mockOp.On("Delete", context.Background(), (*statefile.File)(nil), types.K3d, k.loadConfigurations(cluster, provider)).Return(nil)
+	require.NoError(t, k.Deprovision(context.Background(), cluster, provider), "Deprovision should succeed")
+}
@@ -0,0 +1,140 @@
+package kind
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/errs"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// custom configurations merged into the kind cluster configuration
+const (
+	controlPlaneNodesKey = "control_plane_nodes"
+	extraPortMappingsKey = "extra_port_mappings"
+	registryMirrorKey    = "registry_mirror"
+	featureGatesKey      = "feature_gates"
+	kindConfigKey        = "kind_config"
+)
+
+var (
+	portMappingRegex = regexp.MustCompile(`^(\d{1,5}):(\d{1,5})(?:/(TCP|UDP|SCTP))?$`)
+	featureGateRegex = regexp.MustCompile(`^([A-Za-z0-9]+)=(true|false)$`)
+)
+
+// clusterConfig is the kind cluster configuration, see https://kind.sigs.k8s.io/docs/user/configuration/.
+type clusterConfig struct {
+	Kind                    string          `json:"kind"`
+	APIVersion              string          `json:"apiVersion"`
+	FeatureGates            map[string]bool `json:"featureGates,omitempty"`
+	ContainerdConfigPatches []string        `json:"containerdConfigPatches,omitempty"`
+	Nodes                   []node          `json:"nodes"`
+}
+
+type node struct {
+	Role              string        `json:"role"`
+	ExtraPortMappings []portMapping `json:"extraPortMappings,omitempty"`
+}
+
+type portMapping struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// kindConfig builds the kind cluster configuration with the given number of worker nodes from the custom configurations.
+// It returns the configuration base64-encoded, so that it fits into a terraform variable.
+func kindConfig(workers int, custom map[string]interface{}) (string, error) {
+	cfg := clusterConfig{
+		Kind:       "Cluster",
+		APIVersion: "kind.x-k8s.io/v1alpha4",
+	}
+
+	controlPlanes := 1
+	if n, ok := custom[controlPlaneNodesKey].(int); ok && n > 0 {
+		controlPlanes = n
+	}
+	for i := 0; i < controlPlanes; i++ {
+		cfg.Nodes = append(cfg.Nodes, node{Role: "control-plane"})
+	}
+	for i := 0; i < workers; i++ {
+		cfg.Nodes = append(cfg.Nodes, node{Role: "worker"})
+	}
+
+	// the ports are mapped on the first control plane node, since it always exists
+	for _, m := range stringList(custom[extraPortMappingsKey]) {
+		parts := portMappingRegex.FindStringSubmatch(m)
+		if parts == nil {
+			return "", errors.Errorf("invalid port mapping %q", m)
+		}
+		host, _ := strconv.Atoi(parts[1])
+		container, _ := strconv.Atoi(parts[2])
+		cfg.Nodes[0].ExtraPortMappings = append(cfg.Nodes[0].ExtraPortMappings, portMapping{
+			HostPort:      host,
+			ContainerPort: container,
+			Protocol:      parts[3],
+		})
+	}
+
+	if mirror, ok := custom[registryMirrorKey].(string); ok && mirror != "" {
+		cfg.ContainerdConfigPatches = append(cfg.ContainerdConfigPatches, fmt.Sprintf(
+			"[plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.\"docker.io\"]\n  endpoint = [%q]", mirror))
+	}
+
+	for _, g := range stringList(custom[featureGatesKey]) {
+		parts := featureGateRegex.FindStringSubmatch(g)
+		if parts == nil {
+			return "", errors.Errorf("invalid feature gate %q", g)
+		}
+		if cfg.FeatureGates == nil {
+			cfg.FeatureGates = map[string]bool{}
+		}
+		cfg.FeatureGates[parts[1]] = parts[2] == "true"
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "could not write the kind cluster configuration")
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// validateKindConfig checks the custom configurations merged into the kind cluster configuration.
+func validateKindConfig(custom map[string]interface{}) string {
+	var errMessage string
+	for _, m := range stringList(custom[extraPortMappingsKey]) {
+		if match := portMappingRegex.FindStringSubmatch(m); match == nil || !validPort(match[1]) || !validPort(match[2]) {
+			errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("Provider.CustomConfigurations['%s'] has an invalid mapping %q, use hostPort:containerPort[/TCP|UDP|SCTP]", extraPortMappingsKey, m))
+		}
+	}
+	for _, g := range stringList(custom[featureGatesKey]) {
+		if !featureGateRegex.MatchString(g) {
+			errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("Provider.CustomConfigurations['%s'] has an invalid gate %q, use Name=true or Name=false", featureGatesKey, g))
+		}
+	}
+	if mirror, ok := custom[registryMirrorKey].(string); ok && mirror != "" && !strings.HasPrefix(mirror, "http://") && !strings.HasPrefix(mirror, "https://") {
+		errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("Provider.CustomConfigurations['%s'] has to be a URL such as http://kind-registry:5000", registryMirrorKey))
+	}
+	return errMessage
+}
+
+func validPort(s string) bool {
+	p, err := strconv.Atoi(s)
+	return err == nil && p > 0 && p < 65536
+}
+
+func stringList(v interface{}) []string {
+	switch t := v.(type) {
+	case []string:
+		return t
+	case string:
+		if t != "" {
+			return []string{t}
+		}
+	}
+	return nil
+}
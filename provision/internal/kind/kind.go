@@ -1,16 +1,18 @@
 package kind
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 
+	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/kyma-incubator/hydroform/provision/internal/errs"
 	"github.com/kyma-incubator/hydroform/provision/internal/operator"
 	terraform_operator "github.com/kyma-incubator/hydroform/provision/internal/operator/terraform"
+	"github.com/kyma-incubator/hydroform/provision/internal/providerconfig"
 	"github.com/kyma-incubator/hydroform/provision/types"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/pkg/errors"
 )
@@ -21,14 +23,14 @@ type kindProvisioner struct {
 }
 
 // Provision requests provisioning of a new Kubernetes cluster on Kind with the given configurations.
-func (k *kindProvisioner) Provision(cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
-	if err := k.validateInputs(cluster, p); err != nil {
+func (k *kindProvisioner) Provision(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 
 	config := k.loadConfigurations(cluster, p)
 
-	clusterInfo, err := k.provisionOperator.Create(p.Type, config)
+	clusterInfo, err := k.provisionOperator.Create(ctx, p.Type, config)
 	if err != nil {
 		return cluster, errors.Wrap(err, "unable to provision kind cluster")
 	}
@@ -38,62 +40,131 @@ func (k *kindProvisioner) Provision(cluster *types.Cluster, p *types.Provider) (
 }
 
 // Status returns the ClusterStatus for the requested cluster.
-func (k *kindProvisioner) Status(cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
+func (k *kindProvisioner) Status(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.ClusterStatus, error) {
 	var state *statefile.File
 	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	if err := k.validateInputs(cluster, p); err != nil {
+	if err := k.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 
 	cfg := k.loadConfigurations(cluster, p)
 
-	return k.provisionOperator.Status(state, p.Type, cfg)
+	return k.provisionOperator.Status(ctx, state, p.Type, cfg)
 }
 
-// Credentials returns the Kubeconfig file as a byte array for the requested cluster.
-func (k *kindProvisioner) Credentials(cluster *types.Cluster, p *types.Provider) ([]byte, error) {
-	if err := k.validateInputs(cluster, p); err != nil {
+// Credentials returns the admin kubeconfig kind wrote for the requested cluster, taken from its state.
+// If the cluster carries no state, it is loaded from the state backend.
+func (k *kindProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, p *types.Provider) ([]byte, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
 		return nil, err
 	}
 	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil || cluster.ClusterInfo.InternalState.TerraformState == nil {
-		// TODO add a way to get the kubeconfig from the state file if possible
+		// the caller lost the cluster info, rebuild it from the state
+		info, err := k.provisionOperator.ClusterInfo(ctx, p.Type, k.loadConfigurations(cluster, p))
+		if err != nil {
+			return nil, errors.Wrap(err, errs.NoClusterInfo)
+		}
+		cluster.ClusterInfo = info
+	}
+
+	return kubeconfigFromState(cluster.ClusterInfo.InternalState.TerraformState)
+}
+
+// kubeconfigFromState returns the admin kubeconfig kind wrote for the cluster, which the kind resource keeps in the state.
+func kubeconfigFromState(sf *statefile.File) ([]byte, error) {
+	if sf.State == nil {
 		return nil, errors.New(errs.EmptyClusterInfo)
 	}
+	addr := addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "kind", Name: "kind-cluster"}.Absolute(addrs.RootModuleInstance)
+	rs := sf.State.Resource(addr)
+	if rs == nil {
+		return nil, errors.Errorf("the state has no %s resource", addr)
+	}
+	is := rs.Instance(addrs.NoKey)
+	if is == nil || is.Current == nil {
+		return nil, errors.Errorf("the state has no instance of the %s resource", addr)
+	}
+
+	var attrs struct {
+		Kubeconfig string `json:"kubeconfig"`
+	}
+	if err := json.Unmarshal(is.Current.AttrsJSON, &attrs); err != nil {
+		return nil, errors.Wrapf(err, "could not read the attributes of the %s resource", addr)
+	}
+	if attrs.Kubeconfig == "" {
+		return nil, errors.Errorf("the %s resource has no kubeconfig in the state", addr)
+	}
+	return []byte(attrs.Kubeconfig), nil
+}
+
+// Update applies changes of the node count, machine type, or Kubernetes version to an existing cluster on Kind.
+// The cluster needs to carry the internal state returned by Provision, otherwise the state is loaded from the data directory.
+func (k *kindProvisioner) Update(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
+	}
+
+	config := k.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
+
+	clusterInfo, err := k.provisionOperator.Update(ctx, state, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update kind cluster")
+	}
 
-	userName := "cluster-user"
-	config := api.NewConfig()
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
+}
+
+// Plan returns the changes provisioning, updating, or deprovisioning (with the PlanDestroy option) the cluster on Kind would make, without applying any of them.
+func (k *kindProvisioner) Plan(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Plan, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return nil, err
+	}
 
-	// TODO check if there's a better way to read kubeconfig
-	// resources := cluster.ClusterInfo.InternalState.TerraformState.State.Modules[""].Resources["kind.kind-cluster"]
-	// fmt.Println(string(resources.Instances[nil].Current.AttrsJSON))
+	config := k.loadConfigurations(cluster, p)
+
+	var state *statefile.File
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		state = cluster.ClusterInfo.InternalState.TerraformState
+	}
 
-	config.Clusters[cluster.Name] = &api.Cluster{
-		Server:                   fmt.Sprintf("https://%v", cluster.ClusterInfo.Endpoint),
-		CertificateAuthorityData: cluster.ClusterInfo.CertificateAuthorityData,
+	plan, err := k.provisionOperator.Plan(ctx, state, p.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan kind cluster")
 	}
 
-	config.Contexts[cluster.Name] = &api.Context{
-		Cluster:  cluster.Name,
-		AuthInfo: userName,
+	return plan, nil
+}
+
+// Import brings an existing cluster on Kind, created outside of Hydroform or whose state was lost, under the management of Hydroform.
+// It returns the cluster enriched with its new internal state, which the other operations need.
+func (k *kindProvisioner) Import(ctx context.Context, cluster *types.Cluster, p *types.Provider) (*types.Cluster, error) {
+	if err := k.ValidateInputs(cluster, p); err != nil {
+		return cluster, err
 	}
 
-	config.CurrentContext = cluster.Name
+	config := k.loadConfigurations(cluster, p)
 
-	config.AuthInfos[userName] = &api.AuthInfo{
-		AuthProvider: &api.AuthProviderConfig{
-			Name: "kind",
-		},
+	clusterInfo, err := k.provisionOperator.Import(ctx, p.Type, config)
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to import kind cluster")
 	}
 
-	return clientcmd.Write(*config)
+	cluster.ClusterInfo = clusterInfo
+	return cluster, nil
 }
 
 // Deprovision requests deprovisioning of an existing cluster on Kind with the given configurations.
-func (k *kindProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider) error {
-	if err := k.validateInputs(cluster, p); err != nil {
+func (k *kindProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, p *types.Provider) error {
+	if err := k.ValidateInputs(cluster, p); err != nil {
 		return err
 	}
 
@@ -104,7 +175,7 @@ func (k *kindProvisioner) Deprovision(cluster *types.Cluster, p *types.Provider)
 		state = cluster.ClusterInfo.InternalState.TerraformState
 	}
 
-	err := k.provisionOperator.Delete(state, p.Type, config)
+	err := k.provisionOperator.Delete(ctx, state, p.Type, config)
 	if err != nil {
 		return errors.Wrap(err, "unable to deprovision kind cluster")
 	}
@@ -134,7 +205,7 @@ func New(operatorType operator.Type, ops ...types.Option) *kindProvisioner {
 	}
 }
 
-func (k *kindProvisioner) validateInputs(cluster *types.Cluster, provider *types.Provider) error {
+func (k *kindProvisioner) ValidateInputs(cluster *types.Cluster, provider *types.Provider) error {
 
 	var errMessage string
 	// Matches the regex for a GCP cluster name.
@@ -145,11 +216,13 @@ func (k *kindProvisioner) validateInputs(cluster *types.Cluster, provider *types
 	if provider.ProjectName == "" {
 		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.ProjectName")
 	}
+	if cluster.NodeCount < 0 {
+		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 0)
+	}
 
 	if provider.CustomConfigurations != nil {
-		if _, ok := provider.CustomConfigurations["node_image"]; !ok {
-			errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfiguration.node_image")
-		}
+		errMessage += providerconfig.Validate(provider.CustomConfigurations, types.KindConfig{})
+		errMessage += validateKindConfig(provider.CustomConfigurations)
 	}
 
 	if errMessage != "" {
@@ -163,8 +236,17 @@ func (k *kindProvisioner) loadConfigurations(cluster *types.Cluster, p *types.Pr
 	config := map[string]interface{}{}
 	config["cluster_name"] = cluster.Name
 	config["project"] = p.ProjectName
+	if labels := cluster.Labels(); labels != nil {
+		config["labels"] = labels
+	}
 	for k, v := range p.CustomConfigurations {
 		config[k] = v
 	}
+
+	// the node count is the number of workers, besides the control plane nodes
+	// the custom configurations were validated already, so building the kind configuration does not fail
+	if kc, err := kindConfig(cluster.NodeCount, p.CustomConfigurations); err == nil {
+		config[kindConfigKey] = kc
+	}
 	return config
 }
@@ -1,9 +1,14 @@
 package kind
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/kyma-incubator/hydroform/provision/internal/operator/mocks"
 	"github.com/kyma-incubator/hydroform/provision/types"
@@ -26,24 +31,24 @@ func TestValidateInputs(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, k.validateInputs(cluster, provider), "Validation should pass")
+	require.NoError(t, k.ValidateInputs(cluster, provider), "Validation should pass")
 
 	cluster.Name = ""
-	require.Error(t, k.validateInputs(cluster, provider), "Validation should fail when cluster name is empty")
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when cluster name is empty")
 	cluster.Name = "This_name_is_for_sure_way_too_long_for_the_cluster"
-	require.Error(t, k.validateInputs(cluster, provider), "Validation should fail when cluster name is too long")
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when cluster name is too long")
 	cluster.Name = "-invalid-start"
-	require.Error(t, k.validateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when cluster name starts with '-'")
 	cluster.Name = "invalid-end-"
-	require.Error(t, k.validateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when cluster name ends with '-'")
 	cluster.Name = "hydro-cluster"
 
 	provider.ProjectName = ""
-	require.Error(t, k.validateInputs(cluster, provider), "Validation should fail when project name is empty")
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when project name is empty")
 	provider.ProjectName = "my-project"
 
 	delete(provider.CustomConfigurations, "node_image")
-	require.Error(t, k.validateInputs(cluster, provider), "Validation should fail when target provider is empty")
+	require.Error(t, k.ValidateInputs(cluster, provider), "Validation should fail when target provider is empty")
 	provider.CustomConfigurations["target_provider"] = "somerepo/image:v0.0.0"
 }
 
@@ -100,21 +105,142 @@ func TestProvision(t *testing.T) {
 			TerraformState: nil,
 		},
 	}
-	mockOp.On("Create", types.Kind, k.loadConfigurations(cluster, provider)).Return(result, nil)
+	mockOp.On("Create", context.Background(), types.Kind, k.loadConfigurations(cluster, provider)).Return(result, nil)
 
-	cluster, err := k.Provision(cluster, provider)
+	cluster, err := k.Provision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Provision should succeed")
 	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
 
 	badCluster := &types.Cluster{
 		Name: "",
 	}
-	mockOp.On("Create", types.Kind, k.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
+	mockOp.On("Create", context.Background(), types.Kind, k.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
 
-	_, err = k.Provision(badCluster, provider)
+	_, err = k.Provision(context.Background(), badCluster, provider)
 	require.Error(t, err, "Provision should fail")
 }
 
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	k := kindProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		Name: "test-cluster",
+	}
+	provider := &types.Provider{
+		Type:        types.Kind,
+		ProjectName: "my-project",
+		CustomConfigurations: map[string]interface{}{
+			"node_image": "somerepo/image:v0.0.0",
+		},
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.ClusterInfo{
+		CertificateAuthorityData: []byte("My cert"),
+		Endpoint:                 "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: state,
+		},
+	}
+	mockOp.On("Update", context.Background(), state, types.Kind, k.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	cluster, err := k.Update(context.Background(), cluster, provider)
+	require.NoError(t, err, "Update should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Update")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Update", context.Background(), (*statefile.File)(nil), types.Kind, k.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to update cluster"))
+
+	_, err = k.Update(context.Background(), cluster, provider)
+	require.Error(t, err, "Update should fail")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	k := kindProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		Name: "test-cluster",
+	}
+	provider := &types.Provider{
+		Type:        types.Kind,
+		ProjectName: "my-project",
+		CustomConfigurations: map[string]interface{}{
+			"node_image": "somerepo/image:v0.0.0",
+		},
+	}
+
+	state := &statefile.File{}
+	cluster.ClusterInfo = &types.ClusterInfo{
+		InternalState: &types.InternalState{TerraformState: state},
+	}
+
+	result := &types.Plan{
+		Changes: []types.ResourceChange{
+			{Address: "some_resource.cluster", Type: "some_resource", Action: types.UpdateAction},
+		},
+	}
+	mockOp.On("Plan", context.Background(), state, types.Kind, k.loadConfigurations(cluster, provider)).Return(result, nil)
+
+	plan, err := k.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, result, plan, "The plan returned from the operator should be returned by Plan")
+
+	cluster.ClusterInfo = nil
+	mockOp.On("Plan", context.Background(), (*statefile.File)(nil), types.Kind, k.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to plan cluster"))
+
+	_, err = k.Plan(context.Background(), cluster, provider)
+	require.Error(t, err, "Plan should fail")
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	k := kindProvisioner{
+		provisionOperator: mockOp,
+	}
+
+	cluster := &types.Cluster{
+		Name: "test-cluster",
+	}
+	provider := &types.Provider{
+		Type:        types.Kind,
+		ProjectName: "my-project",
+		CustomConfigurations: map[string]interface{}{
+			"node_image": "somerepo/image:v0.0.0",
+		},
+	}
+
+	info := &types.ClusterInfo{
+		Endpoint:      "1.2.3.4",
+		InternalState: &types.InternalState{TerraformState: &statefile.File{}},
+	}
+	mockOp.On("Import", context.Background(), types.Kind, k.loadConfigurations(cluster, provider)).Return(info, nil).Once()
+
+	imported, err := k.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, info, imported.ClusterInfo, "The cluster should carry the state of the imported cluster")
+
+	mockOp.On("Import", context.Background(), types.Kind, k.loadConfigurations(cluster, provider)).Return(nil, errors.New("Unable to import cluster")).Once()
+
+	_, err = k.Import(context.Background(), cluster, provider)
+	require.Error(t, err, "Import should fail")
+}
+
 func TestDeprovision(t *testing.T) {
 	t.Parallel()
 	mockOp := &mocks.Operator{}
@@ -134,14 +260,137 @@ func TestDeprovision(t *testing.T) {
 	}
 
 	var state *statefile.File
-	mockOp.On("Delete", state, types.Kind, k.loadConfigurations(cluster, provider)).Return(nil)
+	mockOp.On("Delete", context.Background(), state, types.Kind, k.loadConfigurations(cluster, provider)).Return(nil)
 
-	err := k.Deprovision(cluster, provider)
+	err := k.Deprovision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Deprovision should succeed")
 
 	provider.ProjectName = ""
-	mockOp.On("Delete", state, types.Kind, k.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
+	mockOp.On("Delete", context.Background(), state, types.Kind, k.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
 
-	err = k.Deprovision(cluster, provider)
+	err = k.Deprovision(context.Background(), cluster, provider)
 	require.Error(t, err, "Deprovision should fail")
 }
+
+func TestKindConfig(t *testing.T) {
+	t.Parallel()
+
+	kc, err := kindConfig(2, map[string]interface{}{
+		"control_plane_nodes": 3,
+		"extra_port_mappings": []string{"80:80", "8443:443/TCP"},
+		"registry_mirror":     "http://kind-registry:5000",
+		"feature_gates":       []string{"EphemeralContainers=true"},
+	})
+	require.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(kc)
+	require.NoError(t, err)
+
+	require.Equal(t, `apiVersion: kind.x-k8s.io/v1alpha4
+containerdConfigPatches:
+- |-
+  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+    endpoint = ["http://kind-registry:5000"]
+featureGates:
+  EphemeralContainers: true
+kind: Cluster
+nodes:
+- extraPortMappings:
+  - containerPort: 80
+    hostPort: 80
+  - containerPort: 443
+    hostPort: 8443
+    protocol: TCP
+  role: control-plane
+- role: control-plane
+- role: control-plane
+- role: worker
+- role: worker
+`, string(data))
+
+	kc, err = kindConfig(0, map[string]interface{}{})
+	require.NoError(t, err)
+	data, err = base64.StdEncoding.DecodeString(kc)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "nodes:\n- role: control-plane\n", "A single control plane node should be the default")
+
+	_, err = kindConfig(0, map[string]interface{}{"extra_port_mappings": []string{"80"}})
+	require.Error(t, err)
+}
+
+func TestValidateKindConfig(t *testing.T) {
+	t.Parallel()
+	k := &kindProvisioner{}
+
+	cluster := &types.Cluster{Name: "hydro-cluster", NodeCount: 2}
+	provider := &types.Provider{
+		Type:        types.Kind,
+		ProjectName: "my-project",
+		CustomConfigurations: map[string]interface{}{
+			"node_image":          "kindest/node:v1.17.0",
+			"control_plane_nodes": 3,
+			"extra_port_mappings": []string{"80:80", "443:443/TCP"},
+			"registry_mirror":     "http://kind-registry:5000",
+			"feature_gates":       []string{"EphemeralContainers=true"},
+		},
+	}
+	require.NoError(t, k.ValidateInputs(cluster, provider))
+
+	cluster.NodeCount = -1
+	provider.CustomConfigurations["control_plane_nodes"] = 0
+	provider.CustomConfigurations["extra_port_mappings"] = []string{"80:99999"}
+	provider.CustomConfigurations["registry_mirror"] = "kind-registry:5000"
+	provider.CustomConfigurations["feature_gates"] = []string{"EphemeralContainers"}
+	err := k.ValidateInputs(cluster, provider)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Cluster.NodeCount cannot be less than 0")
+	require.Contains(t, err.Error(), "Provider.CustomConfigurations['control_plane_nodes'] cannot be less than 1")
+	require.Contains(t, err.Error(), `invalid mapping "80:99999"`)
+	require.Contains(t, err.Error(), "Provider.CustomConfigurations['registry_mirror'] has to be a URL")
+	require.Contains(t, err.Error(), `invalid gate "EphemeralContainers"`)
+}
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+	mockOp := &mocks.Operator{}
+	k := &kindProvisioner{provisionOperator: mockOp}
+
+	cluster := &types.Cluster{Name: "hydro-cluster"}
+	provider := &types.Provider{
+		Type:                 types.Kind,
+		ProjectName:          "my-project",
+		CustomConfigurations: map[string]interface{}{"node_image": "kindest/node:v1.17.0"},
+	}
+
+	mockOp.On("ClusterInfo", context.Background(), types.Kind, k.loadConfigurations(cluster, provider)).Return(nil, errors.New("no state")).Once()
+	_, err := k.Credentials(context.Background(), cluster, provider)
+	require.Error(t, err, "Credentials should fail without a state")
+
+	kubeconfig := "apiVersion: v1\nkind: Config\ncurrent-context: kind-hydro-cluster\n"
+	attrs, err := json.Marshal(map[string]interface{}{"name": "hydro-cluster", "kubeconfig": kubeconfig})
+	require.NoError(t, err)
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "kind", Name: "kind-cluster"}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{Status: states.ObjectReady, AttrsJSON: attrs},
+			addrs.ProviderConfig{Type: addrs.NewLegacyProvider("kind")}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+	cluster.ClusterInfo = &types.ClusterInfo{InternalState: &types.InternalState{TerraformState: &statefile.File{State: state}}}
+
+	res, err := k.Credentials(context.Background(), cluster, provider)
+	require.NoError(t, err)
+	require.Equal(t, kubeconfig, string(res), "Credentials should return the kubeconfig written by kind")
+
+	// a cluster without info gets it from the state in the state backend
+	lost := &types.Cluster{Name: "hydro-cluster"}
+	mockOp.On("ClusterInfo", context.Background(), types.Kind, k.loadConfigurations(lost, provider)).Return(cluster.ClusterInfo, nil).Once()
+	res, err = k.Credentials(context.Background(), lost, provider)
+	require.NoError(t, err)
+	require.Equal(t, kubeconfig, string(res), "Credentials should rebuild the cluster info from the state")
+	require.Equal(t, cluster.ClusterInfo, lost.ClusterInfo, "The rebuilt cluster info should be set on the cluster")
+	mockOp.AssertExpectations(t)
+
+	cluster.ClusterInfo.InternalState.TerraformState = &statefile.File{State: states.NewState()}
+	_, err = k.Credentials(context.Background(), cluster, provider)
+	require.Error(t, err, "Credentials should fail if the state has no kind cluster")
+}
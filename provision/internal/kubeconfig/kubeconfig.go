@@ -0,0 +1,293 @@
+// Package kubeconfig derives kubeconfigs from the admin kubeconfig of a cluster that work without the command line tools of its provider.
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	defaultNamespace      = "kube-system"
+	defaultExecAPIVersion = "client.authentication.k8s.io/v1beta1"
+	// managedByLabel marks the resources created for the ServiceAccount, so that they can be told apart from those of the user
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedBy      = "hydroform"
+	// tokenTimeout limits how long the token controller may take to fill in the token of the ServiceAccount
+	tokenTimeout = 30 * time.Second
+)
+
+// Generator creates ServiceAccounts in a cluster and returns kubeconfigs with their tokens.
+type Generator struct {
+	newClient    func(kubeconfig []byte) (kubernetes.Interface, error)
+	pollInterval time.Duration
+}
+
+// NewGenerator creates a Generator that connects to the cluster with its admin kubeconfig.
+func NewGenerator() *Generator {
+	return &Generator{
+		newClient:    newClient,
+		pollInterval: time.Second,
+	}
+}
+
+// ForServiceAccount connects to the cluster with the admin kubeconfig, creates the ServiceAccount, binds it to its ClusterRole, and returns a kubeconfig authenticating with its token.
+// The server and the certificate authority of the returned kubeconfig are those of the current context of the admin kubeconfig, and are embedded in it.
+// Existing resources are reused, so calling it again returns the token of the existing ServiceAccount.
+func (g *Generator) ForServiceAccount(ctx context.Context, admin []byte, sa types.ServiceAccount) ([]byte, error) {
+	if err := validateServiceAccount(&sa); err != nil {
+		return nil, err
+	}
+
+	cluster, err := currentCluster(admin)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := g.newClient(admin)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to the cluster with the admin kubeconfig")
+	}
+
+	if err := ensureNamespace(ctx, client, sa.Namespace); err != nil {
+		return nil, err
+	}
+	if err := ensureServiceAccount(ctx, client, sa); err != nil {
+		return nil, err
+	}
+	if err := ensureClusterRoleBinding(ctx, client, sa); err != nil {
+		return nil, err
+	}
+	token, err := g.token(ctx, client, sa)
+	if err != nil {
+		return nil, err
+	}
+
+	config := api.NewConfig()
+	config.Clusters[cluster.name] = cluster.cluster
+	config.AuthInfos[sa.Name] = &api.AuthInfo{Token: token}
+	contextName := fmt.Sprintf("%s@%s", sa.Name, cluster.name)
+	config.Contexts[contextName] = &api.Context{
+		Cluster:   cluster.name,
+		AuthInfo:  sa.Name,
+		Namespace: sa.Namespace,
+	}
+	config.CurrentContext = contextName
+
+	return clientcmd.Write(*config)
+}
+
+// WithExecAuth returns the given kubeconfig with the user of its current context replaced by one running the credential plugin.
+// Only the current context and its cluster are kept.
+func WithExecAuth(kubeconfig []byte, exec types.ExecAuth) ([]byte, error) {
+	if exec.Command == "" {
+		return nil, errors.New("the command of the credential plugin cannot be empty")
+	}
+	if exec.APIVersion == "" {
+		exec.APIVersion = defaultExecAPIVersion
+	}
+
+	cluster, err := currentCluster(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	execConfig := &api.ExecConfig{
+		APIVersion: exec.APIVersion,
+		Command:    exec.Command,
+		Args:       exec.Args,
+	}
+	// sorted, so that the same plugin always gives the same kubeconfig
+	names := make([]string, 0, len(exec.Env))
+	for name := range exec.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		execConfig.Env = append(execConfig.Env, api.ExecEnvVar{Name: name, Value: exec.Env[name]})
+	}
+
+	userName := fmt.Sprintf("%s-exec", cluster.name)
+	config := api.NewConfig()
+	config.Clusters[cluster.name] = cluster.cluster
+	config.AuthInfos[userName] = &api.AuthInfo{Exec: execConfig}
+	config.Contexts[cluster.name] = &api.Context{
+		Cluster:   cluster.name,
+		AuthInfo:  userName,
+		Namespace: cluster.namespace,
+	}
+	config.CurrentContext = cluster.name
+
+	return clientcmd.Write(*config)
+}
+
+func validateServiceAccount(sa *types.ServiceAccount) error {
+	if sa.Name == "" {
+		return errors.New("the name of the service account cannot be empty")
+	}
+	if sa.ClusterRole == "" {
+		return errors.New("the cluster role of the service account cannot be empty")
+	}
+	if sa.Namespace == "" {
+		sa.Namespace = defaultNamespace
+	}
+	return nil
+}
+
+// namedCluster is the cluster of the current context of a kubeconfig.
+type namedCluster struct {
+	name      string
+	namespace string
+	cluster   *api.Cluster
+}
+
+// currentCluster returns the cluster of the current context of the kubeconfig, with its certificate authority embedded.
+func currentCluster(kubeconfig []byte) (*namedCluster, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the kubeconfig")
+	}
+	if err := api.FlattenConfig(config); err != nil {
+		return nil, errors.Wrap(err, "could not embed the files referenced by the kubeconfig")
+	}
+
+	current, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, errors.Errorf("the kubeconfig has no current context %q", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[current.Cluster]
+	if !ok {
+		return nil, errors.Errorf("the kubeconfig has no cluster %q", current.Cluster)
+	}
+
+	return &namedCluster{
+		name:      current.Cluster,
+		namespace: current.Namespace,
+		cluster: &api.Cluster{
+			Server:                   cluster.Server,
+			TLSServerName:            cluster.TLSServerName,
+			InsecureSkipTLSVerify:    cluster.InsecureSkipTLSVerify,
+			CertificateAuthorityData: cluster.CertificateAuthorityData,
+		},
+	}, nil
+}
+
+func ensureNamespace(ctx context.Context, client kubernetes.Interface, name string) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "could not create the namespace %s", name)
+	}
+	return nil
+}
+
+func ensureServiceAccount(ctx context.Context, client kubernetes.Interface, sa types.ServiceAccount) error {
+	obj := &corev1.ServiceAccount{ObjectMeta: objectMeta(sa.Name, sa.Namespace)}
+	if _, err := client.CoreV1().ServiceAccounts(sa.Namespace).Create(ctx, obj, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "could not create the service account %s/%s", sa.Namespace, sa.Name)
+	}
+	return nil
+}
+
+// ensureClusterRoleBinding binds the ServiceAccount to its ClusterRole. The role of a binding cannot change, so a binding to another role is replaced.
+func ensureClusterRoleBinding(ctx context.Context, client kubernetes.Interface, sa types.ServiceAccount) error {
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: objectMeta(bindingName(sa), ""),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     sa.ClusterRole,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      sa.Name,
+			Namespace: sa.Namespace,
+		}},
+	}
+
+	bindings := client.RbacV1().ClusterRoleBindings()
+	current, err := bindings.Get(ctx, binding.Name, metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+	case err != nil:
+		return errors.Wrapf(err, "could not get the cluster role binding %s", binding.Name)
+	case current.RoleRef == binding.RoleRef:
+		return nil
+	default:
+		if err := bindings.Delete(ctx, binding.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "could not replace the cluster role binding %s", binding.Name)
+		}
+	}
+
+	if _, err := bindings.Create(ctx, binding, metav1.CreateOptions{}); err != nil {
+		return errors.Wrapf(err, "could not create the cluster role binding %s", binding.Name)
+	}
+	return nil
+}
+
+// token creates a token Secret for the ServiceAccount, unless it exists, and waits for the token controller to fill in the token.
+// The Secret is created explicitly, since newer clusters no longer create one for each ServiceAccount.
+func (g *Generator) token(ctx context.Context, client kubernetes.Interface, sa types.ServiceAccount) (string, error) {
+	secrets := client.CoreV1().Secrets(sa.Namespace)
+	secret := &corev1.Secret{
+		ObjectMeta: objectMeta(tokenSecretName(sa), sa.Namespace),
+		Type:       corev1.SecretTypeServiceAccountToken,
+	}
+	secret.Annotations = map[string]string{corev1.ServiceAccountNameKey: sa.Name}
+	if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return "", errors.Wrapf(err, "could not create the token secret of the service account %s/%s", sa.Namespace, sa.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenTimeout)
+	defer cancel()
+
+	var token string
+	err := wait.PollImmediateUntil(g.pollInterval, func() (bool, error) {
+		s, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		token = string(s.Data[corev1.ServiceAccountTokenKey])
+		return token != "", nil
+	}, ctx.Done())
+	if err != nil {
+		return "", errors.Wrapf(err, "the token of the service account %s/%s was not issued", sa.Namespace, sa.Name)
+	}
+	return token, nil
+}
+
+func objectMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    map[string]string{managedByLabel: managedBy},
+	}
+}
+
+func bindingName(sa types.ServiceAccount) string {
+	return fmt.Sprintf("hydroform:%s:%s", sa.Namespace, sa.Name)
+}
+
+func tokenSecretName(sa types.ServiceAccount) string {
+	return fmt.Sprintf("%s-token", sa.Name)
+}
+
+func newClient(kubeconfig []byte) (kubernetes.Interface, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(config)
+}
@@ -0,0 +1,121 @@
+package kubeconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const adminKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: hydro
+  cluster:
+    server: https://10.0.0.1
+    certificate-authority-data: Y2E=
+contexts:
+- name: hydro
+  context:
+    cluster: hydro
+    user: admin
+current-context: hydro
+users:
+- name: admin
+  user:
+    auth-provider:
+      name: gcp
+`
+
+func TestForServiceAccount(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	// the token controller fills in the token of new token secrets
+	client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		secret := action.(k8stesting.CreateAction).GetObject().(*corev1.Secret)
+		secret.Data = map[string][]byte{corev1.ServiceAccountTokenKey: []byte("sa-token")}
+		return false, nil, nil
+	})
+
+	sa := types.ServiceAccount{Name: "ci", Namespace: "ci-system", ClusterRole: "view"}
+	kubeconfig, err := generator(client).ForServiceAccount(context.Background(), []byte(adminKubeconfig), sa)
+	require.NoError(t, err)
+
+	config, err := clientcmd.Load(kubeconfig)
+	require.NoError(t, err)
+	require.Equal(t, "ci@hydro", config.CurrentContext)
+	require.Equal(t, "ci-system", config.Contexts["ci@hydro"].Namespace)
+	require.Equal(t, "https://10.0.0.1", config.Clusters["hydro"].Server)
+	require.Equal(t, []byte("ca"), config.Clusters["hydro"].CertificateAuthorityData, "The CA of the admin kubeconfig should be embedded")
+	require.Equal(t, "sa-token", config.AuthInfos["ci"].Token)
+	require.Nil(t, config.AuthInfos["ci"].AuthProvider, "The kubeconfig should not need an auth provider")
+
+	_, err = client.CoreV1().ServiceAccounts("ci-system").Get(context.Background(), "ci", metav1.GetOptions{})
+	require.NoError(t, err, "The service account should be created")
+	binding, err := client.RbacV1().ClusterRoleBindings().Get(context.Background(), "hydroform:ci-system:ci", metav1.GetOptions{})
+	require.NoError(t, err, "The service account should be bound to its role")
+	require.Equal(t, "view", binding.RoleRef.Name)
+
+	// another role replaces the binding, the existing service account and token are reused
+	sa.ClusterRole = "edit"
+	_, err = generator(client).ForServiceAccount(context.Background(), []byte(adminKubeconfig), sa)
+	require.NoError(t, err)
+	binding, err = client.RbacV1().ClusterRoleBindings().Get(context.Background(), "hydroform:ci-system:ci", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "edit", binding.RoleRef.Name)
+	require.Equal(t, []rbacv1.Subject{{Kind: "ServiceAccount", Name: "ci", Namespace: "ci-system"}}, binding.Subjects)
+}
+
+func TestForServiceAccountErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := generator(fake.NewSimpleClientset()).ForServiceAccount(context.Background(), []byte(adminKubeconfig), types.ServiceAccount{ClusterRole: "view"})
+	require.Error(t, err, "A service account without name should fail")
+	_, err = generator(fake.NewSimpleClientset()).ForServiceAccount(context.Background(), []byte(adminKubeconfig), types.ServiceAccount{Name: "ci"})
+	require.Error(t, err, "A service account without cluster role should fail")
+
+	// no token controller fills in the token
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = generator(fake.NewSimpleClientset()).ForServiceAccount(ctx, []byte(adminKubeconfig), types.ServiceAccount{Name: "ci", ClusterRole: "view"})
+	require.Error(t, err, "A token that is never issued should fail")
+}
+
+func TestWithExecAuth(t *testing.T) {
+	t.Parallel()
+
+	kubeconfig, err := WithExecAuth([]byte(adminKubeconfig), types.ExecAuth{
+		Command: "gke-gcloud-auth-plugin",
+		Env:     map[string]string{"B": "2", "A": "1"},
+	})
+	require.NoError(t, err)
+
+	config, err := clientcmd.Load(kubeconfig)
+	require.NoError(t, err)
+	user := config.AuthInfos[config.Contexts[config.CurrentContext].AuthInfo]
+	require.Nil(t, user.AuthProvider, "The auth provider should be replaced")
+	require.Equal(t, "gke-gcloud-auth-plugin", user.Exec.Command)
+	require.Equal(t, "client.authentication.k8s.io/v1beta1", user.Exec.APIVersion)
+	require.Equal(t, "A", user.Exec.Env[0].Name, "The environment should be sorted")
+	require.Equal(t, "https://10.0.0.1", config.Clusters["hydro"].Server)
+
+	_, err = WithExecAuth([]byte(adminKubeconfig), types.ExecAuth{})
+	require.Error(t, err, "A plugin without command should fail")
+}
+
+func generator(client kubernetes.Interface) *Generator {
+	return &Generator{
+		newClient:    func([]byte) (kubernetes.Interface, error) { return client, nil },
+		pollInterval: 10 * time.Millisecond,
+	}
+}
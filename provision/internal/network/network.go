@@ -0,0 +1,36 @@
+// Package network splits the network ranges of clusters into the subnets their providers need.
+package network
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// GardenerAWSSubnets splits the VPC network range of a Gardener cluster on AWS into a worker, a public, and an internal subnet for each zone.
+func GardenerAWSSubnets(baseNet string, zoneCount int) (workerNets, publicNets, internalNets []string, err error) {
+	_, cidr, err := net.ParseCIDR(baseNet)
+	if err != nil {
+		return
+	}
+	if zoneCount < 1 {
+		err = errors.New("There must be at least 1 zone defined.")
+	}
+
+	// each zone gets its own subnet
+	const subnetSize = 64
+	for i := 0; i < zoneCount; i++ {
+		// workers subnet
+		cidr.IP[2] = byte(i * subnetSize)
+		cidr.Mask = net.CIDRMask(19, 8*net.IPv4len)
+		workerNets = append(workerNets, cidr.String())
+
+		// public and internal share the subnet and divide it further
+		cidr.Mask = net.CIDRMask(20, 8*net.IPv4len)
+		cidr.IP[2] = byte(i*subnetSize + subnetSize/2) // first half of the subnet after worker
+		publicNets = append(publicNets, cidr.String())
+		cidr.IP[2] = byte(int(cidr.IP[2]) + subnetSize/4) // second half of the subnet after worker
+		internalNets = append(internalNets, cidr.String())
+	}
+	return
+}
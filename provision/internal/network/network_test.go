@@ -0,0 +1,22 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGardenerAWSSubnets(t *testing.T) {
+	t.Parallel()
+
+	workers, public, internal, err := GardenerAWSSubnets("10.250.0.0/16", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.250.0.0/19", "10.250.64.0/19"}, workers)
+	require.Equal(t, []string{"10.250.32.0/20", "10.250.96.0/20"}, public)
+	require.Equal(t, []string{"10.250.48.0/20", "10.250.112.0/20"}, internal)
+
+	_, _, _, err = GardenerAWSSubnets("10.250.0.0", 2)
+	require.Error(t, err, "An invalid network range should fail")
+	_, _, _, err = GardenerAWSSubnets("10.250.0.0/16", 0)
+	require.Error(t, err, "At least one zone is needed")
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
 	statefile "github.com/hashicorp/terraform/states/statefile"
@@ -15,13 +17,36 @@ type Operator struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: p, cfg
-func (_m *Operator) Create(p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
-	ret := _m.Called(p, cfg)
+// ClusterInfo provides a mock function with given fields: ctx, p, cfg
+func (_m *Operator) ClusterInfo(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, p, cfg)
+
+	var r0 *types.ClusterInfo
+	if rf, ok := ret.Get(0).(func(context.Context, types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
+		r0 = rf(ctx, p, cfg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ClusterInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, p, cfg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, p, cfg
+func (_m *Operator) Create(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, p, cfg)
 
 	var r0 *types.ClusterInfo
-	if rf, ok := ret.Get(0).(func(types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
-		r0 = rf(p, cfg)
+	if rf, ok := ret.Get(0).(func(context.Context, types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
+		r0 = rf(ctx, p, cfg)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*types.ClusterInfo)
@@ -29,8 +54,8 @@ func (_m *Operator) Create(p types.ProviderType, cfg map[string]interface{}) (*t
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(types.ProviderType, map[string]interface{}) error); ok {
-		r1 = rf(p, cfg)
+	if rf, ok := ret.Get(1).(func(context.Context, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, p, cfg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -38,13 +63,13 @@ func (_m *Operator) Create(p types.ProviderType, cfg map[string]interface{}) (*t
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: state, p, cfg
-func (_m *Operator) Delete(state *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
-	ret := _m.Called(state, p, cfg)
+// Delete provides a mock function with given fields: ctx, state, p, cfg
+func (_m *Operator) Delete(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
+	ret := _m.Called(ctx, state, p, cfg)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*statefile.File, types.ProviderType, map[string]interface{}) error); ok {
-		r0 = rf(state, p, cfg)
+	if rf, ok := ret.Get(0).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) error); ok {
+		r0 = rf(ctx, state, p, cfg)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -52,13 +77,59 @@ func (_m *Operator) Delete(state *statefile.File, p types.ProviderType, cfg map[
 	return r0
 }
 
-// Status provides a mock function with given fields: state, p, cfg
-func (_m *Operator) Status(state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
-	ret := _m.Called(state, p, cfg)
+// Import provides a mock function with given fields: ctx, p, cfg
+func (_m *Operator) Import(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, p, cfg)
+
+	var r0 *types.ClusterInfo
+	if rf, ok := ret.Get(0).(func(context.Context, types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
+		r0 = rf(ctx, p, cfg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ClusterInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, p, cfg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Plan provides a mock function with given fields: ctx, state, p, cfg
+func (_m *Operator) Plan(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.Plan, error) {
+	ret := _m.Called(ctx, state, p, cfg)
+
+	var r0 *types.Plan
+	if rf, ok := ret.Get(0).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) *types.Plan); ok {
+		r0 = rf(ctx, state, p, cfg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Plan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, state, p, cfg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Status provides a mock function with given fields: ctx, state, p, cfg
+func (_m *Operator) Status(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+	ret := _m.Called(ctx, state, p, cfg)
 
 	var r0 *types.ClusterStatus
-	if rf, ok := ret.Get(0).(func(*statefile.File, types.ProviderType, map[string]interface{}) *types.ClusterStatus); ok {
-		r0 = rf(state, p, cfg)
+	if rf, ok := ret.Get(0).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) *types.ClusterStatus); ok {
+		r0 = rf(ctx, state, p, cfg)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*types.ClusterStatus)
@@ -66,8 +137,31 @@ func (_m *Operator) Status(state *statefile.File, p types.ProviderType, cfg map[
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*statefile.File, types.ProviderType, map[string]interface{}) error); ok {
-		r1 = rf(state, p, cfg)
+	if rf, ok := ret.Get(1).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, state, p, cfg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, state, p, cfg
+func (_m *Operator) Update(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, state, p, cfg)
+
+	var r0 *types.ClusterInfo
+	if rf, ok := ret.Get(0).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
+		r0 = rf(ctx, state, p, cfg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ClusterInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *statefile.File, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, state, p, cfg)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,368 @@
+// Package native contains operators that manage clusters directly through the API of their provider, without Terraform.
+package native
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+
+	// Gardener refuses to delete a Shoot without this annotation
+	deletionConfirmation = "confirmation.gardener.cloud/deletion"
+)
+
+var (
+	shootResource  = schema.GroupVersionResource{Group: "core.gardener.cloud", Version: "v1beta1", Resource: "shoots"}
+	secretResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// Gardener is an Operator that creates, watches, and deletes Gardener Shoots through the API of the garden cluster.
+// The kubeconfig of the garden cluster is read from the credentials file path of the provider.
+// Gardener itself keeps the state of the clusters, so the given state files are ignored and ClusterInfo carries no internal state.
+type Gardener struct {
+	ops *types.Options
+	// newClient creates a client of the garden cluster from the kubeconfig at the given path.
+	newClient func(kubeconfigPath string) (dynamic.Interface, error)
+	// pollInterval is the time between two checks of the last operation of a Shoot.
+	pollInterval time.Duration
+}
+
+// NewGardener creates a new native Gardener operator with the given options.
+func NewGardener(ops *types.Options) *Gardener {
+	if ops == nil {
+		ops = &types.Options{}
+	}
+	return &Gardener{
+		ops:          ops,
+		newClient:    newClient,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+func newClient(kubeconfigPath string) (dynamic.Interface, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load the kubeconfig of the garden cluster")
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// Create creates the Shoot of the cluster and waits until Gardener reconciled it.
+// If the Shoot already exists, Create waits for it, so that running Create again after ctx is done resumes the operation.
+func (g *Gardener) Create(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	shoots, err := g.shoots(cfg)
+	if err != nil {
+		return nil, err
+	}
+	shoot, err := shootFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if _, err := shoots.Create(ctx, shoot, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, errors.Wrapf(err, "could not create the shoot %s", shoot.GetName())
+	}
+	g.report(types.ResourceStarted, shoot.GetName(), types.CreateAction, 0, "")
+
+	if err := g.waitForReconcile(ctx, shoots, shoot.GetName(), types.CreateAction, timeouts(g.ops).Create, start); err != nil {
+		return nil, err
+	}
+	return g.clusterInfo(ctx, cfg)
+}
+
+// Status returns the phase matching the last operation of the Shoot of the cluster.
+// The health of a provisioned cluster is not checked by the operator.
+func (g *Gardener) Status(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cs := &types.ClusterStatus{
+		Phase: types.Unknown,
+	}
+	shoots, err := g.shoots(cfg)
+	if err != nil {
+		return cs, err
+	}
+
+	shoot, err := shoots.Get(ctx, str(cfg["cluster_name"]), metav1.GetOptions{})
+	if err != nil {
+		return cs, errors.Wrapf(err, "could not get the shoot %s", str(cfg["cluster_name"]))
+	}
+	cs.Phase = phase(shoot)
+	return cs, nil
+}
+
+// Update applies the configuration to the Shoot of the cluster and waits until Gardener reconciled it.
+// Fields of the Shoot the configuration does not set, such as the ones Gardener defaulted, are kept.
+func (g *Gardener) Update(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	shoots, err := g.shoots(cfg)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := shootFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	shoot, err := shoots.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the shoot %s", desired.GetName())
+	}
+	current, _, _ := unstructured.NestedMap(shoot.Object, "spec")
+	shoot.Object["spec"] = mergeSpec(current, desired.Object["spec"].(map[string]interface{}))
+	if len(desired.GetLabels()) > 0 {
+		labels := shoot.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range desired.GetLabels() {
+			labels[k] = v
+		}
+		shoot.SetLabels(labels)
+	}
+
+	start := time.Now()
+	if _, err := shoots.Update(ctx, shoot, metav1.UpdateOptions{}); err != nil {
+		return nil, errors.Wrapf(err, "could not update the shoot %s", desired.GetName())
+	}
+	g.report(types.ResourceStarted, desired.GetName(), types.UpdateAction, 0, "")
+
+	if err := g.waitForReconcile(ctx, shoots, desired.GetName(), types.UpdateAction, timeouts(g.ops).Update, start); err != nil {
+		return nil, err
+	}
+	return g.clusterInfo(ctx, cfg)
+}
+
+// Plan describes the change Create, Update, or Delete (with the PlanDestroy option) would make to the Shoot of the cluster.
+func (g *Gardener) Plan(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.Plan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	shoots, err := g.shoots(cfg)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := shootFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &types.Plan{Changes: []types.ResourceChange{}}
+	change := types.ResourceChange{Address: shootAddress(desired.GetName()), Type: "Shoot"}
+
+	shoot, err := shoots.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		if !g.ops.PlanDestroy {
+			change.Action = types.CreateAction
+			plan.Changes = append(plan.Changes, change)
+		}
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not get the shoot %s", desired.GetName())
+	case g.ops.PlanDestroy:
+		change.Action = types.DeleteAction
+		plan.Changes = append(plan.Changes, change)
+	default:
+		current, _, _ := unstructured.NestedMap(shoot.Object, "spec")
+		if !reflect.DeepEqual(current, mergeSpec(current, desired.Object["spec"].(map[string]interface{}))) {
+			change.Action = types.UpdateAction
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+	return plan, nil
+}
+
+// Import returns the information of an existing Shoot. Since Gardener keeps the state of the Shoot, there is nothing else to import.
+func (g *Gardener) Import(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	shoots, err := g.shoots(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := shoots.Get(ctx, str(cfg["cluster_name"]), metav1.GetOptions{}); err != nil {
+		return nil, errors.Wrapf(err, "could not get the shoot %s", str(cfg["cluster_name"]))
+	}
+	return g.clusterInfo(ctx, cfg)
+}
+
+// ClusterInfo returns the information of an existing Shoot, which Gardener keeps.
+func (g *Gardener) ClusterInfo(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	return g.Import(ctx, p, cfg)
+}
+
+// Delete confirms the deletion of the Shoot of the cluster, deletes it, and waits until Gardener removed it.
+// Deleting a Shoot that does not exist succeeds.
+func (g *Gardener) Delete(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	shoots, err := g.shoots(cfg)
+	if err != nil {
+		return err
+	}
+	name := str(cfg["cluster_name"])
+
+	start := time.Now()
+	confirmation := fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, deletionConfirmation)
+	if _, err := shoots.Patch(ctx, name, k8stypes.MergePatchType, []byte(confirmation), metav1.PatchOptions{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "could not confirm the deletion of the shoot %s", name)
+	}
+	if err := shoots.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "could not delete the shoot %s", name)
+	}
+	g.report(types.ResourceStarted, name, types.DeleteAction, 0, "")
+
+	return g.poll(ctx, timeouts(g.ops).Delete, func() (bool, error) {
+		shoot, err := shoots.Get(ctx, name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			g.report(types.ResourceCompleted, name, types.DeleteAction, time.Since(start), "")
+			return true, nil
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "could not get the shoot %s", name)
+		}
+		g.report(types.ResourceInProgress, name, types.DeleteAction, time.Since(start), lastOperation(shoot))
+		return false, nil
+	})
+}
+
+// waitForReconcile waits until Gardener reconciled the latest generation of the Shoot, or the operation failed.
+func (g *Gardener) waitForReconcile(ctx context.Context, shoots dynamic.ResourceInterface, name string, action types.ChangeAction, timeout time.Duration, start time.Time) error {
+	return g.poll(ctx, timeout, func() (bool, error) {
+		shoot, err := shoots.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "could not get the shoot %s", name)
+		}
+
+		observed, _, _ := unstructured.NestedInt64(shoot.Object, "status", "observedGeneration")
+		if observed < shoot.GetGeneration() {
+			// Gardener did not pick up the latest change yet
+			return false, nil
+		}
+
+		switch phase(shoot) {
+		case types.Provisioned:
+			g.report(types.ResourceCompleted, name, action, time.Since(start), lastOperation(shoot))
+			return true, nil
+		case types.Errored:
+			state, _, _ := unstructured.NestedString(shoot.Object, "status", "lastOperation", "state")
+			// Gardener retries operations in the Error state by itself
+			if state != stateError {
+				msg := lastOperation(shoot)
+				g.report(types.ProgressError, name, action, time.Since(start), msg)
+				return false, errors.Errorf("gardener could not reconcile the shoot %s: %s", name, msg)
+			}
+		}
+		g.report(types.ResourceInProgress, name, action, time.Since(start), lastOperation(shoot))
+		return false, nil
+	})
+}
+
+// poll calls done every poll interval until it returns true or an error, or until the timeout or ctx is done.
+func (g *Gardener) poll(ctx context.Context, timeout time.Duration, done func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(g.pollInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := done()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "gave up waiting for the shoot after %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// clusterInfo reads the endpoint and certificate authority of the cluster from the kubeconfig secret Gardener creates for the Shoot.
+func (g *Gardener) clusterInfo(ctx context.Context, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	client, err := g.newClient(str(cfg["credentials_file_path"]))
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s.kubeconfig", str(cfg["cluster_name"]))
+	secret, err := client.Resource(secretResource).Namespace(str(cfg["namespace"])).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the secret %s", name)
+	}
+	data, _, _ := unstructured.NestedString(secret.Object, "data", "kubeconfig")
+	kubeconfig, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decode the kubeconfig in the secret %s", name)
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the kubeconfig in the secret %s", name)
+	}
+
+	return &types.ClusterInfo{
+		Endpoint:                 config.Host,
+		CertificateAuthorityData: config.CAData,
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+	}, nil
+}
+
+func (g *Gardener) shoots(cfg map[string]interface{}) (dynamic.ResourceInterface, error) {
+	client, err := g.newClient(str(cfg["credentials_file_path"]))
+	if err != nil {
+		return nil, err
+	}
+	return client.Resource(shootResource).Namespace(str(cfg["namespace"])), nil
+}
+
+func (g *Gardener) report(t types.ProgressEventType, name string, action types.ChangeAction, elapsed time.Duration, msg string) {
+	report(g.ops, t, shootAddress(name), action, elapsed, msg)
+}
+
+func shootAddress(name string) string {
+	return fmt.Sprintf("shoot.%s", name)
+}
@@ -0,0 +1,276 @@
+package native
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const shootKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: hydro
+  cluster:
+    server: https://api.hydro.my-project.shoot.example.com
+    certificate-authority-data: Y2VydGlmaWNhdGU=
+contexts:
+- name: hydro
+  context:
+    cluster: hydro
+    user: admin
+current-context: hydro
+users:
+- name: admin
+  user:
+    token: secret-token
+`
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"cluster_name":           "hydro",
+		"credentials_file_path":  "/path/to/garden/kubeconfig",
+		"namespace":              "garden-my-project",
+		"project":                "my-project",
+		"kubernetes_version":     "1.17.8",
+		"location":               "eu-west-1",
+		"machine_type":           "m5.xlarge",
+		"disk_size":              30,
+		"target_provider":        "aws",
+		"target_profile":         "aws",
+		"target_secret":          "aws-secret",
+		"disk_type":              "gp2",
+		"vnetcidr":               "10.250.0.0/16",
+		"networking_nodes":       "10.250.0.0/16",
+		"networking_type":        "calico",
+		"zones":                  []string{"eu-west-1a", "eu-west-1b"},
+		"worker_minimum":         2,
+		"worker_maximum":         4,
+		"worker_max_surge":       1,
+		"worker_max_unavailable": 0,
+		"privileged_containers":  true,
+	}
+}
+
+func kubeconfigSecret() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "hydro.kubeconfig", "namespace": "garden-my-project"},
+		"data":       map[string]interface{}{"kubeconfig": base64.StdEncoding.EncodeToString([]byte(shootKubeconfig))},
+	}}
+}
+
+func shootWithOperation(opType, state string) *unstructured.Unstructured {
+	shoot, _ := shootFromConfig(testConfig())
+	if state != "" {
+		shoot.Object["status"] = map[string]interface{}{
+			"lastOperation": map[string]interface{}{
+				"type":        opType,
+				"state":       state,
+				"progress":    int64(100),
+				"description": "Shoot cluster state has been successfully reconciled.",
+			},
+		}
+	}
+	return shoot
+}
+
+func newTestGardener(client *fake.FakeDynamicClient, ops *types.Options) *Gardener {
+	g := NewGardener(ops)
+	g.pollInterval = 10 * time.Millisecond
+	g.newClient = func(kubeconfigPath string) (dynamic.Interface, error) {
+		return client, nil
+	}
+	return g
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), kubeconfigSecret())
+	var events []types.ProgressEvent
+	g := newTestGardener(client, &types.Options{Progress: func(e types.ProgressEvent) {
+		events = append(events, e)
+	}})
+	shoots := client.Resource(shootResource).Namespace("garden-my-project")
+
+	// play Gardener, reconciling the shoot once it is created
+	go func() {
+		for {
+			shoot, err := shoots.Get(context.Background(), "hydro", metav1.GetOptions{})
+			if err == nil {
+				shoot.Object["status"] = shootWithOperation("Create", stateSucceeded).Object["status"]
+				_, _ = shoots.Update(context.Background(), shoot, metav1.UpdateOptions{})
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	info, err := g.Create(context.Background(), types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.Equal(t, "https://api.hydro.my-project.shoot.example.com", info.Endpoint)
+	require.Equal(t, []byte("certificate"), info.CertificateAuthorityData)
+	require.Equal(t, types.Provisioned, info.Status.Phase)
+	require.Nil(t, info.InternalState, "Gardener keeps the state of the shoot")
+
+	shoot, err := shoots.Get(context.Background(), "hydro", metav1.GetOptions{})
+	require.NoError(t, err)
+	region, _, _ := unstructured.NestedString(shoot.Object, "spec", "region")
+	require.Equal(t, "eu-west-1", region)
+
+	require.Equal(t, types.ResourceStarted, events[0].Type)
+	require.Equal(t, types.ResourceCompleted, events[len(events)-1].Type)
+	require.Equal(t, "shoot.hydro", events[0].Resource)
+
+	// creating an existing shoot resumes waiting for it
+	info, err = g.Create(context.Background(), types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.Equal(t, types.Provisioned, info.Status.Phase)
+}
+
+func TestCreateFailed(t *testing.T) {
+	t.Parallel()
+
+	shoot := shootWithOperation("Create", stateFailed)
+	require.NoError(t, unstructured.SetNestedSlice(shoot.Object, []interface{}{
+		map[string]interface{}{"description": "quota exceeded"},
+	}, "status", "lastErrors"))
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), shoot, kubeconfigSecret())
+	g := newTestGardener(client, nil)
+
+	_, err := g.Create(context.Background(), types.Gardener, testConfig())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "quota exceeded", "The errors of Gardener should be reported")
+
+	// a shoot that never gets reconciled times out
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme(), kubeconfigSecret())
+	g = newTestGardener(client, &types.Options{Timeouts: &types.Timeouts{Create: 50 * time.Millisecond}})
+	_, err = g.Create(context.Background(), types.Gardener, testConfig())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "gave up waiting")
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		opType string
+		state  string
+		phase  types.Phase
+	}{
+		{"", "", types.Provisioning},
+		{"Create", stateProcessing, types.Provisioning},
+		{"Reconcile", stateSucceeded, types.Provisioned},
+		{"Reconcile", stateError, types.Errored},
+		{"Create", stateFailed, types.Errored},
+		{"Delete", stateProcessing, types.Deleting},
+	}
+
+	for _, c := range cases {
+		client := fake.NewSimpleDynamicClient(runtime.NewScheme(), shootWithOperation(c.opType, c.state))
+		cs, err := newTestGardener(client, nil).Status(context.Background(), nil, types.Gardener, testConfig())
+		require.NoError(t, err)
+		require.Equal(t, c.phase, cs.Phase, "%s %s", c.opType, c.state)
+	}
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	cs, err := newTestGardener(client, nil).Status(context.Background(), nil, types.Gardener, testConfig())
+	require.Error(t, err, "A missing shoot should fail")
+	require.Equal(t, types.Unknown, cs.Phase)
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	shoot := shootWithOperation("Reconcile", stateSucceeded)
+	// a field defaulted by Gardener
+	require.NoError(t, unstructured.SetNestedField(shoot.Object, "100.96.0.0/11", "spec", "networking", "pods"))
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), shoot, kubeconfigSecret())
+	g := newTestGardener(client, nil)
+
+	cfg := testConfig()
+	cfg["kubernetes_version"] = "1.18.5"
+	info, err := g.Update(context.Background(), nil, types.Gardener, cfg)
+	require.NoError(t, err)
+	require.Equal(t, types.Provisioned, info.Status.Phase)
+
+	updated, err := client.Resource(shootResource).Namespace("garden-my-project").Get(context.Background(), "hydro", metav1.GetOptions{})
+	require.NoError(t, err)
+	version, _, _ := unstructured.NestedString(updated.Object, "spec", "kubernetes", "version")
+	require.Equal(t, "1.18.5", version)
+	pods, _, _ := unstructured.NestedString(updated.Object, "spec", "networking", "pods")
+	require.Equal(t, "100.96.0.0/11", pods, "Fields defaulted by Gardener should be kept")
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	plan, err := newTestGardener(client, nil).Plan(context.Background(), nil, types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.Equal(t, []types.ResourceChange{{Address: "shoot.hydro", Type: "Shoot", Action: types.CreateAction}}, plan.Changes)
+
+	plan, err = newTestGardener(client, &types.Options{PlanDestroy: true}).Plan(context.Background(), nil, types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), "Destroying a missing shoot should not change anything")
+
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme(), shootWithOperation("Reconcile", stateSucceeded))
+	g := newTestGardener(client, nil)
+	plan, err = g.Plan(context.Background(), nil, types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), "An unchanged configuration should not change anything")
+
+	cfg := testConfig()
+	cfg["machine_type"] = "m5.2xlarge"
+	plan, err = g.Plan(context.Background(), nil, types.Gardener, cfg)
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Count(types.UpdateAction))
+
+	plan, err = newTestGardener(client, &types.Options{PlanDestroy: true}).Plan(context.Background(), nil, types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Count(types.DeleteAction))
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), shootWithOperation("Reconcile", stateSucceeded), kubeconfigSecret())
+	info, err := newTestGardener(client, nil).Import(context.Background(), types.Gardener, testConfig())
+	require.NoError(t, err)
+	require.Equal(t, "https://api.hydro.my-project.shoot.example.com", info.Endpoint)
+
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme(), kubeconfigSecret())
+	_, err = newTestGardener(client, nil).Import(context.Background(), types.Gardener, testConfig())
+	require.Error(t, err, "Importing a missing shoot should fail")
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), shootWithOperation("Reconcile", stateSucceeded))
+	g := newTestGardener(client, nil)
+
+	require.NoError(t, g.Delete(context.Background(), nil, types.Gardener, testConfig()))
+
+	var verbs []string
+	for _, a := range client.Actions() {
+		verbs = append(verbs, a.GetVerb())
+		if patch, ok := a.(k8stesting.PatchAction); ok {
+			require.Contains(t, string(patch.GetPatch()), `"confirmation.gardener.cloud/deletion":"true"`)
+		}
+	}
+	require.Equal(t, []string{"patch", "delete", "get"}, verbs, "The deletion should be confirmed before deleting the shoot")
+
+	require.NoError(t, g.Delete(context.Background(), nil, types.Gardener, testConfig()), "Deleting a missing shoot should succeed")
+}
@@ -0,0 +1,299 @@
+package native
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	k3dCLI = "k3d"
+	// k3dMajorVersion is the major version of k3d whose flags Create uses, such as --registry-create without a value and the node filters like server[0].
+	k3dMajorVersion = 4
+)
+
+// k3dVersion matches the version of the k3d command line tool in the output of k3d version, such as "k3d version v4.4.8".
+var k3dVersion = regexp.MustCompile(`k3d version v(\d+)\.`)
+
+// cliRunner runs a command line tool with the given arguments and returns its standard output.
+type cliRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCLI runs the command line tool on the local machine. The error contains what the tool printed to its standard error.
+func runCLI(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed: %s", name, strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// K3d is an Operator that creates and deletes k3d clusters through the k3d command line tool v4, which has to be in the PATH.
+// Each cluster gets a registry named k3d-<cluster>-registry in the network of the cluster.
+// Docker keeps the state of the clusters, so the given state files are ignored and ClusterInfo carries no internal state.
+type K3d struct {
+	ops *types.Options
+	run cliRunner
+}
+
+// k3dNode is a node of a cluster as listed by k3d.
+type k3dNode struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+	State struct {
+		Running bool   `json:"Running"`
+		Status  string `json:"Status"`
+	} `json:"State"`
+}
+
+// k3dCluster is a cluster as listed by k3d.
+type k3dCluster struct {
+	Name  string    `json:"name"`
+	Nodes []k3dNode `json:"nodes"`
+}
+
+// NewK3d creates a new native k3d operator with the given options.
+func NewK3d(ops *types.Options) *K3d {
+	if ops == nil {
+		ops = &types.Options{}
+	}
+	return &K3d{
+		ops: ops,
+		run: runCLI,
+	}
+}
+
+// Create creates the k3d cluster with its registry and waits until it is running.
+func (k *K3d) Create(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := k.checkVersion(ctx); err != nil {
+		return nil, err
+	}
+	name := str(cfg["cluster_name"])
+	timeout := timeouts(k.ops).Create
+
+	args := []string{"cluster", "create", name,
+		"--servers", fmt.Sprintf("%d", integer(cfg["servers"])),
+		"--agents", fmt.Sprintf("%d", integer(cfg["agents"])),
+		"--registry-create",
+		"--wait", "--timeout", timeout.String(),
+		// the kubeconfig is returned by Credentials, the one of the user stays untouched
+		"--kubeconfig-update-default=false", "--kubeconfig-switch-context=false",
+	}
+	if image := str(cfg["image"]); image != "" {
+		args = append(args, "--image", image)
+	}
+	for _, m := range list(cfg["port_mappings"]) {
+		args = append(args, "--port", str(m))
+	}
+	if labels, ok := cfg["labels"].(map[string]string); ok {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		// the labels are recorded on the container of the first server, which every cluster has
+		for _, k := range keys {
+			args = append(args, "--label", fmt.Sprintf("%s=%s@server[0]", k, labels[k]))
+		}
+	}
+
+	start := time.Now()
+	report(k.ops, types.ResourceStarted, k3dAddress(name), types.CreateAction, 0, "")
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := k.run(ctx, k3dCLI, args...); err != nil {
+		report(k.ops, types.ProgressError, k3dAddress(name), types.CreateAction, time.Since(start), err.Error())
+		return nil, errors.Wrapf(err, "could not create the k3d cluster %s", name)
+	}
+	report(k.ops, types.ResourceCompleted, k3dAddress(name), types.CreateAction, time.Since(start), "")
+
+	return k.clusterInfo(ctx, name)
+}
+
+// Status returns Provisioned if all nodes of the k3d cluster are running, and Errored otherwise.
+func (k *K3d) Status(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+	cs := &types.ClusterStatus{
+		Phase: types.Unknown,
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name := str(cfg["cluster_name"])
+	c, err := k.cluster(ctx, name)
+	if err != nil {
+		return cs, err
+	}
+	if c == nil {
+		return cs, errors.Errorf("the k3d cluster %s does not exist", name)
+	}
+
+	cs.Phase = types.Provisioned
+	for _, n := range c.Nodes {
+		if !n.State.Running {
+			cs.Phase = types.Errored
+		}
+	}
+	return cs, nil
+}
+
+// Update is not supported, since k3d cannot change an existing cluster. Delete the cluster and create it again instead.
+func (k *K3d) Update(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	return nil, errors.New("k3d clusters cannot be updated, deprovision and provision the cluster again instead")
+}
+
+// Plan describes whether Create or Delete (with the PlanDestroy option) would create or delete the k3d cluster.
+func (k *K3d) Plan(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.Plan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name := str(cfg["cluster_name"])
+	c, err := k.cluster(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &types.Plan{Changes: []types.ResourceChange{}}
+	change := types.ResourceChange{Address: k3dAddress(name), Type: "k3d_cluster"}
+	switch {
+	case c == nil && !k.ops.PlanDestroy:
+		change.Action = types.CreateAction
+		plan.Changes = append(plan.Changes, change)
+	case c != nil && k.ops.PlanDestroy:
+		change.Action = types.DeleteAction
+		plan.Changes = append(plan.Changes, change)
+	}
+	return plan, nil
+}
+
+// Import returns the information of an existing k3d cluster. Since Docker keeps the state of the cluster, there is nothing else to import.
+func (k *K3d) Import(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name := str(cfg["cluster_name"])
+	c, err := k.cluster(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, errors.Errorf("the k3d cluster %s does not exist", name)
+	}
+	return k.clusterInfo(ctx, name)
+}
+
+// ClusterInfo returns the information of an existing k3d cluster, which k3d keeps.
+func (k *K3d) ClusterInfo(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	return k.Import(ctx, p, cfg)
+}
+
+// Delete deletes the k3d cluster and its registry. Deleting a cluster that does not exist succeeds.
+func (k *K3d) Delete(ctx context.Context, _ *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	name := str(cfg["cluster_name"])
+	ctx, cancel := context.WithTimeout(ctx, timeouts(k.ops).Delete)
+	defer cancel()
+
+	c, err := k.cluster(ctx, name)
+	if err != nil || c == nil {
+		return err
+	}
+
+	start := time.Now()
+	report(k.ops, types.ResourceStarted, k3dAddress(name), types.DeleteAction, 0, "")
+	if _, err := k.run(ctx, k3dCLI, "cluster", "delete", name); err != nil {
+		report(k.ops, types.ProgressError, k3dAddress(name), types.DeleteAction, time.Since(start), err.Error())
+		return errors.Wrapf(err, "could not delete the k3d cluster %s", name)
+	}
+	report(k.ops, types.ResourceCompleted, k3dAddress(name), types.DeleteAction, time.Since(start), "")
+	return nil
+}
+
+// Kubeconfig returns the admin kubeconfig of the k3d cluster.
+func (k *K3d) Kubeconfig(ctx context.Context, name string) ([]byte, error) {
+	out, err := k.run(ctx, k3dCLI, "kubeconfig", "get", name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the kubeconfig of the k3d cluster %s", name)
+	}
+	return out, nil
+}
+
+// checkVersion fails unless the k3d command line tool has the major version whose flags Create uses.
+func (k *K3d) checkVersion(ctx context.Context) error {
+	out, err := k.run(ctx, k3dCLI, "version")
+	if err != nil {
+		return errors.Wrap(err, "could not get the version of k3d")
+	}
+	m := k3dVersion.FindSubmatch(out)
+	if m == nil {
+		return errors.Errorf("could not read the version of k3d from: %s", strings.TrimSpace(string(out)))
+	}
+	if major, _ := strconv.Atoi(string(m[1])); major != k3dMajorVersion {
+		return errors.Errorf("k3d v%s is not supported, install k3d v%d", m[1], k3dMajorVersion)
+	}
+	return nil
+}
+
+// cluster returns the k3d cluster with the given name, or nil if it does not exist.
+func (k *K3d) cluster(ctx context.Context, name string) (*k3dCluster, error) {
+	out, err := k.run(ctx, k3dCLI, "cluster", "list", "--output", "json")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list the k3d clusters")
+	}
+
+	var clusters []k3dCluster
+	if err := json.Unmarshal(out, &clusters); err != nil {
+		return nil, errors.Wrap(err, "could not read the k3d clusters")
+	}
+	for i := range clusters {
+		if clusters[i].Name == name {
+			return &clusters[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (k *K3d) clusterInfo(ctx context.Context, name string) (*types.ClusterInfo, error) {
+	kubeconfig, err := k.Kubeconfig(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the kubeconfig of the k3d cluster %s", name)
+	}
+
+	return &types.ClusterInfo{
+		Endpoint:                 config.Host,
+		CertificateAuthorityData: config.CAData,
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+	}, nil
+}
+
+func k3dAddress(name string) string {
+	return fmt.Sprintf("k3d.%s", name)
+}
@@ -0,0 +1,169 @@
+package native
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+const k3dKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: k3d-hydro
+  cluster:
+    server: https://0.0.0.0:6443
+    certificate-authority-data: Y2VydGlmaWNhdGU=
+contexts:
+- name: k3d-hydro
+  context:
+    cluster: k3d-hydro
+    user: admin@k3d-hydro
+current-context: k3d-hydro
+users:
+- name: admin@k3d-hydro
+  user:
+    token: secret-token
+`
+
+// fakeK3d records the k3d commands and answers them like k3d would with the given clusters.
+type fakeK3d struct {
+	clusters string
+	// version is the output of k3d version, k3d v4 by default
+	version  string
+	commands []string
+}
+
+func (f *fakeK3d) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := strings.Join(args, " ")
+	f.commands = append(f.commands, cmd)
+	switch {
+	case cmd == "version":
+		if f.version == "" {
+			return []byte("k3d version v4.4.8\nk3s version v1.21.3-k3s1 (default)\n"), nil
+		}
+		return []byte(f.version), nil
+	case strings.HasPrefix(cmd, "cluster list"):
+		return []byte(f.clusters), nil
+	case strings.HasPrefix(cmd, "kubeconfig get hydro"):
+		return []byte(k3dKubeconfig), nil
+	case strings.HasPrefix(cmd, "cluster create"), strings.HasPrefix(cmd, "cluster delete"):
+		return nil, nil
+	}
+	return nil, errors.New("unexpected command")
+}
+
+func newTestK3d(f *fakeK3d, ops *types.Options) *K3d {
+	k := NewK3d(ops)
+	k.run = f.run
+	return k
+}
+
+func k3dConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"cluster_name":  "hydro",
+		"servers":       1,
+		"agents":        2,
+		"image":         "rancher/k3s:v1.20.4-k3s1",
+		"port_mappings": []string{"80:80@loadbalancer", "443:443@loadbalancer"},
+	}
+}
+
+func TestK3dCreate(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeK3d{}
+	info, err := newTestK3d(f, &types.Options{Timeouts: &types.Timeouts{Create: 5 * time.Minute}}).Create(context.Background(), types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.Equal(t, "https://0.0.0.0:6443", info.Endpoint)
+	require.Equal(t, []byte("certificate"), info.CertificateAuthorityData)
+	require.Equal(t, types.Provisioned, info.Status.Phase)
+
+	require.Equal(t, "cluster create hydro --servers 1 --agents 2 --registry-create --wait --timeout 5m0s "+
+		"--kubeconfig-update-default=false --kubeconfig-switch-context=false --image rancher/k3s:v1.20.4-k3s1 "+
+		"--port 80:80@loadbalancer --port 443:443@loadbalancer", f.commands[1])
+
+	f = &fakeK3d{}
+	cfg := k3dConfig()
+	cfg["labels"] = map[string]string{types.ExpiresAtLabel: "1614600000"}
+	_, err = newTestK3d(f, nil).Create(context.Background(), types.K3d, cfg)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(f.commands[1], "--label hydroform-expires-at=1614600000@server[0]"), "The labels should be recorded on the first server")
+
+	// the flags differ in other major versions of k3d
+	f = &fakeK3d{version: "k3d version v5.4.6\nk3s version v1.24.4-k3s1 (default)\n"}
+	_, err = newTestK3d(f, nil).Create(context.Background(), types.K3d, k3dConfig())
+	require.EqualError(t, err, "k3d v5 is not supported, install k3d v4")
+	require.Equal(t, []string{"version"}, f.commands, "No cluster should be created")
+}
+
+func TestK3dStatus(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeK3d{clusters: `[{"name":"hydro","nodes":[
+		{"name":"k3d-hydro-server-0","role":"server","State":{"Running":true,"Status":"running"}},
+		{"name":"k3d-hydro-agent-0","role":"agent","State":{"Running":true,"Status":"running"}}]}]`}
+	cs, err := newTestK3d(f, nil).Status(context.Background(), nil, types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.Equal(t, types.Provisioned, cs.Phase)
+
+	f.clusters = `[{"name":"hydro","nodes":[{"name":"k3d-hydro-server-0","role":"server","State":{"Running":false,"Status":"exited"}}]}]`
+	cs, err = newTestK3d(f, nil).Status(context.Background(), nil, types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.Equal(t, types.Errored, cs.Phase, "A stopped cluster is not usable")
+
+	f.clusters = `[{"name":"other"}]`
+	cs, err = newTestK3d(f, nil).Status(context.Background(), nil, types.K3d, k3dConfig())
+	require.Error(t, err, "A missing cluster should fail")
+	require.Equal(t, types.Unknown, cs.Phase)
+}
+
+func TestK3dPlan(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeK3d{clusters: `[]`}
+	plan, err := newTestK3d(f, nil).Plan(context.Background(), nil, types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.Equal(t, []types.ResourceChange{{Address: "k3d.hydro", Type: "k3d_cluster", Action: types.CreateAction}}, plan.Changes)
+
+	f.clusters = `[{"name":"hydro"}]`
+	plan, err = newTestK3d(f, nil).Plan(context.Background(), nil, types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.False(t, plan.HasChanges(), "An existing cluster should not change")
+
+	plan, err = newTestK3d(f, &types.Options{PlanDestroy: true}).Plan(context.Background(), nil, types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Count(types.DeleteAction))
+}
+
+func TestK3dImport(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeK3d{clusters: `[{"name":"hydro"}]`}
+	info, err := newTestK3d(f, nil).Import(context.Background(), types.K3d, k3dConfig())
+	require.NoError(t, err)
+	require.Equal(t, "https://0.0.0.0:6443", info.Endpoint)
+
+	f.clusters = `[]`
+	_, err = newTestK3d(f, nil).Import(context.Background(), types.K3d, k3dConfig())
+	require.Error(t, err, "Importing a missing cluster should fail")
+}
+
+func TestK3dDelete(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeK3d{clusters: `[{"name":"hydro"}]`}
+	require.NoError(t, newTestK3d(f, nil).Delete(context.Background(), nil, types.K3d, k3dConfig()))
+	require.Equal(t, []string{"cluster list --output json", "cluster delete hydro"}, f.commands)
+
+	f = &fakeK3d{clusters: `[]`}
+	require.NoError(t, newTestK3d(f, nil).Delete(context.Background(), nil, types.K3d, k3dConfig()), "Deleting a missing cluster should succeed")
+	require.Equal(t, []string{"cluster list --output json"}, f.commands)
+
+	_, err := newTestK3d(f, nil).Update(context.Background(), nil, types.K3d, k3dConfig())
+	require.Error(t, err, "k3d clusters cannot be updated")
+}
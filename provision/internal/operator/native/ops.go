@@ -0,0 +1,46 @@
+package native
+
+import (
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+const (
+	defaultCreateTimeout = 30 * time.Minute
+	defaultUpdateTimeout = 30 * time.Minute
+	defaultDeleteTimeout = 20 * time.Minute
+)
+
+// timeouts returns the timeouts of the given options, with the defaults for the ones not set.
+func timeouts(ops *types.Options) types.Timeouts {
+	var t types.Timeouts
+	if ops.Timeouts != nil {
+		t = *ops.Timeouts
+	}
+	if t.Create == 0 {
+		t.Create = defaultCreateTimeout
+	}
+	if t.Update == 0 {
+		t.Update = defaultUpdateTimeout
+	}
+	if t.Delete == 0 {
+		t.Delete = defaultDeleteTimeout
+	}
+	return t
+}
+
+// report sends a progress event for the given resource if the options ask for progress events.
+func report(ops *types.Options, t types.ProgressEventType, resource string, action types.ChangeAction, elapsed time.Duration, msg string) {
+	if ops.Progress == nil {
+		return
+	}
+	ops.Progress(types.ProgressEvent{
+		Type:     t,
+		Time:     time.Now(),
+		Resource: resource,
+		Action:   action,
+		Elapsed:  elapsed,
+		Message:  msg,
+	})
+}
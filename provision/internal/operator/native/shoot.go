@@ -0,0 +1,279 @@
+package native
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/network"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// states and types of the last operation of a Shoot
+const (
+	stateSucceeded  = "Succeeded"
+	stateProcessing = "Processing"
+	statePending    = "Pending"
+	stateError      = "Error"
+	stateFailed     = "Failed"
+	stateAborted    = "Aborted"
+
+	operationDelete = "Delete"
+)
+
+// shootFromConfig builds the Shoot described by the configuration of the Gardener provisioner, the same one the terraform template describes.
+func shootFromConfig(cfg map[string]interface{}) (*unstructured.Unstructured, error) {
+	spec, err := shootSpec(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	shoot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "core.gardener.cloud/v1beta1",
+		"kind":       "Shoot",
+		"metadata": map[string]interface{}{
+			"name":      str(cfg["cluster_name"]),
+			"namespace": str(cfg["namespace"]),
+		},
+		"spec": spec,
+	}}
+	if labels, ok := cfg["labels"].(map[string]string); ok && len(labels) > 0 {
+		shoot.SetLabels(labels)
+	}
+	return shoot, nil
+}
+
+func shootSpec(cfg map[string]interface{}) (map[string]interface{}, error) {
+	targetProvider := str(cfg["target_provider"])
+
+	networking := map[string]interface{}{"type": str(cfg["networking_type"])}
+	for key, field := range map[string]string{"networking_nodes": "nodes", "networking_pods": "pods", "networking_services": "services"} {
+		if v := str(cfg[key]); v != "" {
+			networking[field] = v
+		}
+	}
+
+	worker := map[string]interface{}{
+		"name":           "cpu-worker",
+		"zones":          list(cfg["zones"]),
+		"maxSurge":       intOrString(cfg["worker_max_surge"]),
+		"maxUnavailable": intOrString(cfg["worker_max_unavailable"]),
+		"maximum":        integer(cfg["worker_maximum"]),
+		"minimum":        integer(cfg["worker_minimum"]),
+		"volume": map[string]interface{}{
+			"size": fmt.Sprintf("%dGi", integer(cfg["disk_size"])),
+			"type": str(cfg["disk_type"]),
+		},
+		"machine": map[string]interface{}{
+			"type": str(cfg["machine_type"]),
+		},
+	}
+	// Gardener defaults the image, or its version, only if it is omitted
+	if name := str(cfg["machine_image_name"]); name != "" {
+		image := map[string]interface{}{"name": name}
+		if version := str(cfg["machine_image_version"]); version != "" {
+			image["version"] = version
+		}
+		worker["machine"].(map[string]interface{})["image"] = image
+	}
+
+	provider := map[string]interface{}{
+		"type":    targetProvider,
+		"workers": []interface{}{worker},
+	}
+
+	switch targetProvider {
+	case string(types.GCP):
+		provider["controlPlaneConfig"] = map[string]interface{}{
+			"apiVersion": "gcp.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "ControlPlaneConfig",
+			"zone":       str(cfg["gcp_control_plane_zone"]),
+		}
+		provider["infrastructureConfig"] = map[string]interface{}{
+			"apiVersion": "gcp.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "InfrastructureConfig",
+			"networks": map[string]interface{}{
+				"workers": str(cfg["workercidr"]),
+			},
+		}
+	case string(types.Azure):
+		provider["infrastructureConfig"] = map[string]interface{}{
+			"apiVersion": "azure.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "InfrastructureConfig",
+			"zoned":      str(cfg["zoned"]) == "true",
+			"networks": map[string]interface{}{
+				"vnet":             map[string]interface{}{"cidr": str(cfg["vnetcidr"])},
+				"workers":          str(cfg["workercidr"]),
+				"serviceEndpoints": list(cfg["service_endpoints"]),
+			},
+		}
+	case string(types.AWS):
+		zones := list(cfg["zones"])
+		workerNets, publicNets, internalNets, err := network.GardenerAWSSubnets(str(cfg["vnetcidr"]), len(zones))
+		if err != nil {
+			return nil, errors.Wrap(err, "Error generating subnets for AWS zones")
+		}
+		var awsZones []interface{}
+		for i, z := range zones {
+			awsZones = append(awsZones, map[string]interface{}{
+				"name":     z,
+				"workers":  workerNets[i],
+				"public":   publicNets[i],
+				"internal": internalNets[i],
+			})
+		}
+		provider["infrastructureConfig"] = map[string]interface{}{
+			"apiVersion": "aws.provider.extensions.gardener.cloud/v1alpha1",
+			"kind":       "InfrastructureConfig",
+			"networks": map[string]interface{}{
+				"vpc":   map[string]interface{}{"cidr": str(cfg["vnetcidr"])},
+				"zones": awsZones,
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"cloudProfileName":  str(cfg["target_profile"]),
+		"region":            str(cfg["location"]),
+		"secretBindingName": str(cfg["target_secret"]),
+		"networking":        networking,
+		"kubernetes": map[string]interface{}{
+			"version":                   str(cfg["kubernetes_version"]),
+			"allowPrivilegedContainers": str(cfg["privileged_containers"]) == "true",
+		},
+		"maintenance": map[string]interface{}{
+			"autoUpdate": map[string]interface{}{
+				"kubernetesVersion":   true,
+				"machineImageVersion": true,
+			},
+			"timeWindow": map[string]interface{}{
+				"begin": "030000+0000",
+				"end":   "040000+0000",
+			},
+		},
+		"provider": provider,
+	}, nil
+}
+
+// mergeSpec sets the fields of the desired spec on the current spec of a Shoot, keeping the fields Gardener defaulted.
+// Lists and values replace the current ones, maps are merged.
+func mergeSpec(current, desired map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(current))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range desired {
+		dm, desiredIsMap := v.(map[string]interface{})
+		cm, currentIsMap := merged[k].(map[string]interface{})
+		if desiredIsMap && currentIsMap {
+			merged[k] = mergeSpec(cm, dm)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// phase maps the last operation of a Shoot to the phase of the cluster.
+func phase(shoot *unstructured.Unstructured) types.Phase {
+	if shoot.GetDeletionTimestamp() != nil {
+		return types.Deleting
+	}
+
+	state, found, _ := unstructured.NestedString(shoot.Object, "status", "lastOperation", "state")
+	if !found {
+		// Gardener did not pick up the Shoot yet
+		return types.Provisioning
+	}
+	opType, _, _ := unstructured.NestedString(shoot.Object, "status", "lastOperation", "type")
+
+	switch state {
+	case stateSucceeded:
+		if opType == operationDelete {
+			return types.Deleting
+		}
+		return types.Provisioned
+	case stateProcessing, statePending:
+		if opType == operationDelete {
+			return types.Deleting
+		}
+		return types.Provisioning
+	case stateError, stateFailed, stateAborted:
+		return types.Errored
+	}
+	return types.Unknown
+}
+
+// lastOperation describes the last operation of a Shoot and its errors.
+func lastOperation(shoot *unstructured.Unstructured) string {
+	opType, _, _ := unstructured.NestedString(shoot.Object, "status", "lastOperation", "type")
+	state, _, _ := unstructured.NestedString(shoot.Object, "status", "lastOperation", "state")
+	progress, _, _ := unstructured.NestedInt64(shoot.Object, "status", "lastOperation", "progress")
+	description, _, _ := unstructured.NestedString(shoot.Object, "status", "lastOperation", "description")
+
+	msg := fmt.Sprintf("%s %s (%d%%): %s", opType, state, progress, description)
+	lastErrors, _, _ := unstructured.NestedSlice(shoot.Object, "status", "lastErrors")
+	for _, e := range lastErrors {
+		if m, ok := e.(map[string]interface{}); ok {
+			msg += fmt.Sprintf("\n - %v", m["description"])
+		}
+	}
+	return msg
+}
+
+func str(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func integer(v interface{}) int64 {
+	switch t := v.(type) {
+	case int:
+		return int64(t)
+	case int64:
+		return t
+	case float64:
+		return int64(t)
+	case string:
+		i, _ := strconv.ParseInt(t, 10, 64)
+		return i
+	}
+	return 0
+}
+
+// intOrString keeps percentages such as "25%" as strings, and turns everything else into a number.
+func intOrString(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+		return s
+	}
+	return integer(v)
+}
+
+func list(v interface{}) []interface{} {
+	var l []interface{}
+	switch t := v.(type) {
+	case []string:
+		for _, s := range t {
+			l = append(l, s)
+		}
+	case []interface{}:
+		l = t
+	case string:
+		if t != "" {
+			l = append(l, t)
+		}
+	}
+	if l == nil {
+		return []interface{}{}
+	}
+	return l
+}
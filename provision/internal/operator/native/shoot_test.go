@@ -0,0 +1,82 @@
+package native
+
+import (
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestShootFromConfig(t *testing.T) {
+	t.Parallel()
+
+	shoot, err := shootFromConfig(testConfig())
+	require.NoError(t, err)
+	require.Equal(t, "hydro", shoot.GetName())
+	require.Equal(t, "garden-my-project", shoot.GetNamespace())
+	require.Empty(t, shoot.GetLabels(), "There should be no labels without expiry")
+
+	zones, _, _ := unstructured.NestedSlice(shoot.Object, "spec", "provider", "infrastructureConfig", "networks", "zones")
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "eu-west-1a", "workers": "10.250.0.0/19", "public": "10.250.32.0/20", "internal": "10.250.48.0/20"},
+		map[string]interface{}{"name": "eu-west-1b", "workers": "10.250.64.0/19", "public": "10.250.96.0/20", "internal": "10.250.112.0/20"},
+	}, zones, "The VPC should be split among the zones like the terraform template does")
+
+	workers, _, _ := unstructured.NestedSlice(shoot.Object, "spec", "provider", "workers")
+	worker := workers[0].(map[string]interface{})
+	require.Equal(t, int64(4), worker["maximum"])
+	require.Equal(t, "30Gi", worker["volume"].(map[string]interface{})["size"])
+	require.NotContains(t, worker["machine"], "image", "Gardener should default the image")
+
+	privileged, _, _ := unstructured.NestedBool(shoot.Object, "spec", "kubernetes", "allowPrivilegedContainers")
+	require.True(t, privileged)
+
+	// the object has to be valid JSON for the API
+	require.NotPanics(t, func() { shoot.DeepCopy() })
+
+	cfg := testConfig()
+	cfg["labels"] = map[string]string{types.ExpiresAtLabel: "1614600000"}
+	shoot, err = shootFromConfig(cfg)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{types.ExpiresAtLabel: "1614600000"}, shoot.GetLabels(), "The labels should be recorded on the shoot")
+
+	cfg["machine_image_name"] = "gardenlinux"
+	shoot, err = shootFromConfig(cfg)
+	require.NoError(t, err)
+	workers, _, _ = unstructured.NestedSlice(shoot.Object, "spec", "provider", "workers")
+	image, _, _ := unstructured.NestedMap(workers[0].(map[string]interface{}), "machine", "image")
+	require.Equal(t, map[string]interface{}{"name": "gardenlinux"}, image, "Gardener should default the version of the image")
+
+	cfg["machine_image_version"] = "318.8.0"
+	shoot, err = shootFromConfig(cfg)
+	require.NoError(t, err)
+	workers, _, _ = unstructured.NestedSlice(shoot.Object, "spec", "provider", "workers")
+	image, _, _ = unstructured.NestedMap(workers[0].(map[string]interface{}), "machine", "image")
+	require.Equal(t, map[string]interface{}{"name": "gardenlinux", "version": "318.8.0"}, image)
+
+	cfg["vnetcidr"] = "10.250.0.0"
+	_, err = shootFromConfig(cfg)
+	require.Error(t, err, "An invalid VPC range should fail")
+}
+
+func TestMergeSpec(t *testing.T) {
+	t.Parallel()
+
+	current := map[string]interface{}{
+		"region":     "eu-west-1",
+		"networking": map[string]interface{}{"type": "calico", "pods": "100.96.0.0/11"},
+		"provider":   map[string]interface{}{"workers": []interface{}{"a", "b"}},
+	}
+	desired := map[string]interface{}{
+		"networking": map[string]interface{}{"type": "cilium"},
+		"provider":   map[string]interface{}{"workers": []interface{}{"c"}},
+	}
+
+	require.Equal(t, map[string]interface{}{
+		"region":     "eu-west-1",
+		"networking": map[string]interface{}{"type": "cilium", "pods": "100.96.0.0/11"},
+		"provider":   map[string]interface{}{"workers": []interface{}{"c"}},
+	}, mergeSpec(current, desired))
+	require.Equal(t, "calico", current["networking"].(map[string]interface{})["type"], "The current spec should not be changed")
+}
@@ -1,6 +1,8 @@
 package operator
 
 import (
+	"context"
+
 	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/kyma-incubator/hydroform/provision/types"
 )
@@ -8,15 +10,28 @@ import (
 //go:generate mockery -name=Operator -case=snake
 
 // Operator allows switching easily between different types of provisioning operators.
+// All operations stop as soon as possible when the given context is canceled or its deadline is exceeded.
 type Operator interface {
 	// Create creates a new cluster on the given provider based on the configuration and returns the same cluster enriched with its current state.
-	Create(p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error)
+	Create(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error)
 	// Status checks the cluster status based on the given state.
 	// If the state is empty or nil, Status will attempt to load the state from the file system.
-	Status(state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error)
+	Status(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error)
+	// Update applies configuration changes to an existing cluster and returns the cluster enriched with its new state.
+	// If the state is empty or nil, Update will attempt to load the state from the file system.
+	Update(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error)
+	// Plan describes the changes Create, Update, or Delete would make to a cluster without applying any of them.
+	// If the state is empty or nil, Plan will attempt to load the state from the file system.
+	Plan(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.Plan, error)
+	// Import brings an existing cluster created outside of the operator under its management and returns the cluster enriched with its new state.
+	// It fails if there already is a state for the cluster.
+	Import(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error)
+	// ClusterInfo rebuilds the information about an existing cluster, such as its endpoint and certificate authority, from the state the operator keeps.
+	// It lets callers who lost the ClusterInfo returned by Create recover access to the cluster.
+	ClusterInfo(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error)
 	// Delete removes a cluster. For this operation a valid state is necessary.
 	// If the state is empty or nil, Delete will attempt to load the state from the file system.
-	Delete(state *statefile.File, p types.ProviderType, cfg map[string]interface{}) error
+	Delete(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) error
 }
 
 // Type points out the type of the operator.
@@ -25,4 +40,6 @@ type Type string
 const (
 	// TerraformOperator indicates the type of the operator is Terraform.
 	TerraformOperator Type = "terraform"
+	// NativeOperator indicates the operator manages clusters directly through the API of their provider.
+	NativeOperator Type = "native"
 )
@@ -0,0 +1,12 @@
+package terraform
+
+import "sync"
+
+// Terraform keeps some of its state process-wide, such as the registered backends and the plugin directory.
+// The following locks keep concurrent operations on different clusters from interfering with each other.
+var (
+	// backendsMu serializes the registration of the terraform backends, which fills a global map.
+	backendsMu sync.Mutex
+	// gardenerProviderMu serializes the download of the gardener provider into the shared plugin directory.
+	gardenerProviderMu sync.Mutex
+)
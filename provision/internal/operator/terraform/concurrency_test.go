@@ -0,0 +1,97 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+// localTemplate is a cluster template without any provider, so that terraform applies it without downloading plugins.
+const localTemplate = `
+variable "project" {}
+variable "cluster_name" {}
+variable "create_timeout" {}
+variable "update_timeout" {}
+variable "delete_timeout" {}
+
+output "endpoint" {
+  value = "https://${var.cluster_name}.example.com"
+}
+
+output "cluster_ca_certificate" {
+  value = base64encode("ca of ${var.cluster_name}")
+}
+`
+
+// Run with the race detector to check that concurrent operations do not share any state: go test -race
+func TestConcurrentCreate(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-concurrency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	local := types.ProviderType("local")
+	stderr := os.Stderr
+	logOutput := log.Writer()
+
+	const clusters = 4
+	outputs := make([]redirectionWriter, clusters)
+	infos := make([]*types.ClusterInfo, clusters)
+	errs := make([]error, clusters)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < clusters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tf := New(WithDataDir(dataDir), WithModule(local, types.ClusterModule{Template: localTemplate}), WithOutput(&outputs[i]))
+			outputs[i].stderr, outputs[i].log = stderr, logOutput
+			infos[i], errs[i] = tf.Create(context.Background(), local, map[string]interface{}{
+				"project":      "project",
+				"cluster_name": fmt.Sprintf("cluster-%d", i),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < clusters; i++ {
+		require.NoError(t, errs[i])
+		require.Equal(t, fmt.Sprintf("https://cluster-%d.example.com", i), infos[i].Endpoint)
+		require.Equal(t, fmt.Sprintf("ca of cluster-%d", i), string(infos[i].CertificateAuthorityData))
+
+		require.Contains(t, outputs[i].String(), "Apply complete!", "The output of terraform should be written to the writer of the operation")
+		require.False(t, outputs[i].redirected, "Stderr and the standard logger should not be redirected while the operation runs")
+		for j := 0; j < clusters; j++ {
+			if j != i {
+				require.NotContains(t, outputs[i].String(), fmt.Sprintf("cluster-%d.example.com", j), "The output of other operations should not be mixed in")
+			}
+		}
+	}
+
+	require.Equal(t, stderr, os.Stderr, "Stderr should not be redirected")
+	require.Equal(t, logOutput, log.Writer(), "The standard logger should not be redirected")
+}
+
+// redirectionWriter records the output of an operation, and whether stderr or the output of the standard logger differed from the given ones while it was written.
+type redirectionWriter struct {
+	bytes.Buffer
+	stderr     *os.File
+	log        io.Writer
+	redirected bool
+}
+
+func (w *redirectionWriter) Write(p []byte) (int, error) {
+	if os.Stderr != w.stderr || log.Writer() != w.log {
+		w.redirected = true
+	}
+	return w.Buffer.Write(p)
+}
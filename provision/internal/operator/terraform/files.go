@@ -1,19 +1,24 @@
 package terraform
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
-	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/hashicorp/terraform/command/cliconfig"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/plans"
+	"github.com/hashicorp/terraform/plans/planfile"
 	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/internal/network"
 	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/pkg/errors"
 )
@@ -23,11 +28,210 @@ const (
 	tfStateFile  = "terraform.tfstate"
 	tfModuleFile = "terraform.tf"
 	tfVarsFile   = "terraform.tfvars"
+	tfPlanFile   = "terraform.tfplan"
+
+	// gcpNodePoolResource holds the nodes of the GKE clusters, unless they were provisioned with the legacy layout
+	gcpNodePoolResource = "google_container_node_pool.gke_node_pool"
 	// TODO release modules and do not use master as ref when stable
 	azureMod = "git::https://github.com/kyma-incubator/terraform-modules//azurerm_kubernetes_cluster?ref=v0.0.3"
 
 	// TODO remove hardcoded TF templates once modules work
-	awsClusterTemplate = ``
+	awsClusterTemplate = `
+variable "node_count"    		{}
+variable "cluster_name"  		{}
+variable "credentials_file_path" 	{}
+variable "profile"       		{}
+variable "project"       		{}
+variable "location"      		{}
+variable "machine_type"  		{}
+variable "kubernetes_version"   	{}
+variable "disk_size" 			{}
+variable "vpc_cidr" 			{}
+variable "create_timeout" 		{}
+variable "update_timeout" 		{}
+variable "delete_timeout" 		{}
+variable "labels" {
+	type    = map(string)
+	default = {}
+}
+
+provider "aws" {
+	region                  = var.location
+	shared_credentials_file = var.credentials_file_path
+	profile                 = var.profile
+}
+
+data "aws_availability_zones" "available" {
+	state = "available"
+}
+
+resource "aws_vpc" "eks_vpc" {
+	cidr_block           = var.vpc_cidr
+	enable_dns_hostnames = true
+	enable_dns_support   = true
+
+	tags = merge({
+		Name                                        = var.cluster_name
+		project                                     = var.project
+		"kubernetes.io/cluster/${var.cluster_name}" = "shared"
+	}, var.labels)
+}
+
+resource "aws_internet_gateway" "eks_gateway" {
+	vpc_id = aws_vpc.eks_vpc.id
+
+	tags = {
+		Name = var.cluster_name
+	}
+}
+
+resource "aws_subnet" "eks_subnet" {
+	count                   = 2
+	vpc_id                  = aws_vpc.eks_vpc.id
+	availability_zone       = data.aws_availability_zones.available.names[count.index]
+	cidr_block              = cidrsubnet(var.vpc_cidr, 4, count.index)
+	map_public_ip_on_launch = true
+
+	tags = {
+		Name                                        = "${var.cluster_name}-${count.index}"
+		"kubernetes.io/cluster/${var.cluster_name}" = "shared"
+		"kubernetes.io/role/elb"                    = "1"
+	}
+}
+
+resource "aws_route_table" "eks_route_table" {
+	vpc_id = aws_vpc.eks_vpc.id
+
+	route {
+		cidr_block = "0.0.0.0/0"
+		gateway_id = aws_internet_gateway.eks_gateway.id
+	}
+}
+
+resource "aws_route_table_association" "eks_route_table_association" {
+	count          = 2
+	subnet_id      = aws_subnet.eks_subnet[count.index].id
+	route_table_id = aws_route_table.eks_route_table.id
+}
+
+resource "aws_iam_role" "eks_cluster_role" {
+	name = "${var.cluster_name}-cluster"
+
+	assume_role_policy = <<POLICY
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": {
+        "Service": "eks.amazonaws.com"
+      },
+      "Action": "sts:AssumeRole"
+    }
+  ]
+}
+POLICY
+}
+
+resource "aws_iam_role_policy_attachment" "eks_cluster_policy" {
+	policy_arn = "arn:aws:iam::aws:policy/AmazonEKSClusterPolicy"
+	role       = aws_iam_role.eks_cluster_role.name
+}
+
+resource "aws_iam_role" "eks_node_role" {
+	name = "${var.cluster_name}-node"
+
+	assume_role_policy = <<POLICY
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": {
+        "Service": "ec2.amazonaws.com"
+      },
+      "Action": "sts:AssumeRole"
+    }
+  ]
+}
+POLICY
+}
+
+resource "aws_iam_role_policy_attachment" "eks_worker_node_policy" {
+	policy_arn = "arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy"
+	role       = aws_iam_role.eks_node_role.name
+}
+
+resource "aws_iam_role_policy_attachment" "eks_cni_policy" {
+	policy_arn = "arn:aws:iam::aws:policy/AmazonEKS_CNI_Policy"
+	role       = aws_iam_role.eks_node_role.name
+}
+
+resource "aws_iam_role_policy_attachment" "eks_registry_policy" {
+	policy_arn = "arn:aws:iam::aws:policy/AmazonEC2ContainerRegistryReadOnly"
+	role       = aws_iam_role.eks_node_role.name
+}
+
+resource "aws_eks_cluster" "eks_cluster" {
+	name     = var.cluster_name
+	role_arn = aws_iam_role.eks_cluster_role.arn
+	version  = var.kubernetes_version
+
+	vpc_config {
+		subnet_ids = aws_subnet.eks_subnet[*].id
+	}
+
+	timeouts {
+		create = var.create_timeout
+		update = var.update_timeout
+		delete = var.delete_timeout
+	}
+
+	tags = merge({
+		project = var.project
+	}, var.labels)
+
+	depends_on = [aws_iam_role_policy_attachment.eks_cluster_policy]
+}
+
+resource "aws_eks_node_group" "eks_node_group" {
+	cluster_name    = aws_eks_cluster.eks_cluster.name
+	node_group_name = "${var.cluster_name}-workers"
+	node_role_arn   = aws_iam_role.eks_node_role.arn
+	subnet_ids      = aws_subnet.eks_subnet[*].id
+	instance_types  = [var.machine_type]
+	disk_size       = var.disk_size > 0 ? var.disk_size : null
+	version         = var.kubernetes_version
+
+	scaling_config {
+		desired_size = var.node_count
+		min_size     = var.node_count
+		max_size     = var.node_count
+	}
+
+	tags = var.labels
+
+	timeouts {
+		create = var.create_timeout
+		update = var.update_timeout
+		delete = var.delete_timeout
+	}
+
+	depends_on = [
+		aws_iam_role_policy_attachment.eks_worker_node_policy,
+		aws_iam_role_policy_attachment.eks_cni_policy,
+		aws_iam_role_policy_attachment.eks_registry_policy,
+	]
+}
+
+output "endpoint" {
+	value = aws_eks_cluster.eks_cluster.endpoint
+}
+
+output "cluster_ca_certificate" {
+	value = aws_eks_cluster.eks_cluster.certificate_authority.0.data
+}
+`
 	gcpClusterTemplate = `
   variable "node_count"    		{}
   variable "cluster_name"  		{}
@@ -40,6 +244,85 @@ const (
   variable "create_timeout" 	{}
   variable "update_timeout" 	{}
   variable "delete_timeout" 	{}
+  variable "labels" {
+	type    = map(string)
+	default = {}
+  }
+
+  provider "google" {
+    	credentials   = file("${var.credentials_file_path}")
+		project       = var.project
+  }
+
+  resource "google_container_cluster" "gke_cluster" {
+    	name               = var.cluster_name
+    	location 	       = var.location
+    	min_master_version = var.kubernetes_version
+	resource_labels    = var.labels
+
+	# nodes are managed by a separate node pool so that they can be updated without replacing the cluster
+	remove_default_node_pool = true
+	initial_node_count       = 1
+
+	timeouts {
+		create = var.create_timeout
+		update = var.update_timeout
+		delete = var.delete_timeout
+	}
+
+    maintenance_policy {
+      	daily_maintenance_window {
+        	start_time = "03:00"
+      		}
+    	}
+  }
+
+  resource "google_container_node_pool" "gke_node_pool" {
+	name       = "${var.cluster_name}-pool"
+	location   = var.location
+	cluster    = google_container_cluster.gke_cluster.name
+	node_count = var.node_count
+	version    = google_container_cluster.gke_cluster.master_version
+
+    node_config {
+      	machine_type = var.machine_type
+		disk_size_gb = var.disk_size
+    }
+
+	timeouts {
+		create = var.create_timeout
+		update = var.update_timeout
+		delete = var.delete_timeout
+	}
+  }
+
+  output "endpoint" {
+    value = google_container_cluster.gke_cluster.endpoint
+  }
+
+  output "cluster_ca_certificate" {
+    value = google_container_cluster.gke_cluster.master_auth.0.cluster_ca_certificate
+  }
+`
+
+	// gcpLegacyClusterTemplate is the layout of the GKE clusters provisioned before their nodes moved into a separate node pool.
+	// Moving such a cluster to the current layout would replace it, so it keeps this layout, see useLegacyLayout.
+	gcpLegacyClusterTemplate = `
+  variable "node_count"    		{}
+  variable "cluster_name"  		{}
+  variable "credentials_file_path" 	{}
+  variable "project"       		{}
+  variable "location"      		{}
+  variable "machine_type"  		{}
+  variable "kubernetes_version"   	{}
+  variable "disk_size" 			{}
+  variable "create_timeout" 	{}
+  variable "update_timeout" 	{}
+  variable "delete_timeout" 	{}
+  variable "labels" {
+	type    = map(string)
+	default = {}
+  }
 
   provider "google" {
     	credentials   = file("${var.credentials_file_path}")
@@ -52,7 +335,8 @@ const (
     	initial_node_count = var.node_count
     	min_master_version = var.kubernetes_version
     	node_version       = var.kubernetes_version
-    
+	resource_labels    = var.labels
+
     node_config {
       	machine_type = var.machine_type
 		disk_size_gb = var.disk_size
@@ -130,6 +414,10 @@ variable "machine_image_version"	{}
 variable "privileged_containers"	{
 	default = "false"
 }
+variable "labels" {
+	type    = map(string)
+	default = {}
+}
 
 
 provider "gardener" {
@@ -140,7 +428,7 @@ resource "gardener_shoot" "gardener_cluster" {
 	metadata {
 	  name      = var.cluster_name
 	  namespace = var.namespace
-  
+	  labels    = var.labels
 	}
 
 	timeouts {
@@ -249,6 +537,7 @@ resource "gardener_shoot" "gardener_cluster" {
 variable "project"				{}
 variable "cluster_name"			{}
 variable "node_image"				{}
+variable "kind_config"				{}
 variable "create_timeout" 			{}
 variable "update_timeout" 			{}
 variable "delete_timeout" 			{}
@@ -258,6 +547,7 @@ provider "kind" {
 resource "kind" "kind-cluster" {
 	name       = "${var.cluster_name}"
 	node_image = "${var.node_image}"
+	kind_config = base64decode(var.kind_config)
 
 	timeouts {
 		create = "${var.create_timeout}"
@@ -268,8 +558,9 @@ resource "kind" "kind-cluster" {
 `
 )
 
-// initClusterFiles initializes all necessary files for a cluster in the given data directory
-func initClusterFiles(dataDir string, p types.ProviderType, cfg map[string]interface{}) error {
+// initClusterFiles initializes all necessary files for a cluster in the given data directory.
+// If a custom module is given, its template replaces the built-in one, or its source has been downloaded by terraform init already, and the result is validated.
+func initClusterFiles(dataDir string, p types.ProviderType, cfg map[string]interface{}, mod *types.ClusterModule) error {
 	dir, err := clusterDir(dataDir, cfg["project"].(string), cfg["cluster_name"].(string), p)
 	if err != nil {
 		return err
@@ -278,21 +569,16 @@ func initClusterFiles(dataDir string, p types.ProviderType, cfg map[string]inter
 	// create module file for providers that are not using modules
 	// TODO delete this when all providers have downloadable modules
 	var data []byte
-	switch p {
-	case types.GCP:
-		data = []byte(gcpClusterTemplate)
-	case types.Gardener:
-		t, err := expandGardenerClusterTemplate(cfg)
+	switch {
+	case mod != nil && mod.Source != "" && mod.Template != "":
+		return errors.Errorf("the custom %s module can have either a source or a template, not both", p)
+	case mod != nil:
+		data = []byte(mod.Template)
+	default:
+		data, err = clusterTemplate(p, cfg)
 		if err != nil {
 			return err
 		}
-		data = []byte(t)
-	case types.Azure:
-		break
-	case types.AWS:
-		data = []byte(awsClusterTemplate)
-	case types.Kind:
-		data = []byte(kindClusterTemplate)
 	}
 
 	if len(data) > 0 {
@@ -300,6 +586,11 @@ func initClusterFiles(dataDir string, p types.ProviderType, cfg map[string]inter
 			return err
 		}
 	}
+	if mod != nil {
+		if err := validateModule(dir, p); err != nil {
+			return err
+		}
+	}
 
 	// create vars file
 	var vars strings.Builder
@@ -314,10 +605,27 @@ func initClusterFiles(dataDir string, p types.ProviderType, cfg map[string]inter
 			if _, err := vars.WriteString(fmt.Sprintf("%s = \"%s\"\n", k, t)); err != nil {
 				return err
 			}
+		case bool:
+			if _, err := vars.WriteString(fmt.Sprintf("%s = \"%t\"\n", k, t)); err != nil {
+				return err
+			}
 		case time.Duration:
 			if _, err := vars.WriteString(fmt.Sprintf("%s = \"%s\"\n", k, t.String())); err != nil {
 				return err
 			}
+		case map[string]string:
+			keys := make([]string, 0, len(t))
+			for key := range t {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			var a []string
+			for _, key := range keys {
+				a = append(a, fmt.Sprintf("\"%s\" = \"%s\"", key, t[key]))
+			}
+			if _, err := vars.WriteString(fmt.Sprintf("%s = {%s}\n", k, strings.Join(a, ", "))); err != nil {
+				return err
+			}
 		case []string:
 			var a []string
 			for _, v := range t {
@@ -338,6 +646,118 @@ func initClusterFiles(dataDir string, p types.ProviderType, cfg map[string]inter
 	return nil
 }
 
+// clusterTemplate returns the built-in terraform configuration of the clusters of the given provider, or nil if the provider uses a downloadable module.
+func clusterTemplate(p types.ProviderType, cfg map[string]interface{}) ([]byte, error) {
+	switch p {
+	case types.GCP:
+		return []byte(gcpClusterTemplate), nil
+	case types.Gardener:
+		t, err := expandGardenerClusterTemplate(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(t), nil
+	case types.AWS:
+		return []byte(awsClusterTemplate), nil
+	case types.Kind:
+		return []byte(kindClusterTemplate), nil
+	}
+	return nil, nil
+}
+
+// useLegacyLayout writes the legacy GKE template into the given cluster directory if the state in it holds a cluster provisioned with that layout, and returns whether it did.
+// Custom modules are left as they are.
+func useLegacyLayout(dir string, p types.ProviderType, mod *types.ClusterModule) (bool, error) {
+	if p != types.GCP || mod != nil {
+		return false, nil
+	}
+
+	f, err := os.Open(filepath.Join(dir, tfStateFile))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	sf, err := statefile.Read(f)
+	if err == statefile.ErrNoState {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "could not read the state of the cluster")
+	}
+
+	root := sf.State.RootModule()
+	if root.Resources[clusterResource(p)] == nil || root.Resources[gcpNodePoolResource] != nil {
+		return false, nil
+	}
+	return true, ioutil.WriteFile(filepath.Join(dir, tfModuleFile), []byte(gcpLegacyClusterTemplate), 0700)
+}
+
+// requiredOutputs returns the outputs Hydroform reads from the state of the clusters of the given provider, which custom modules have to declare.
+func requiredOutputs(p types.ProviderType) []string {
+	switch p {
+	case types.GCP, types.AWS:
+		// read by clusterInfoFromFile
+		return []string{"endpoint", "cluster_ca_certificate"}
+	case types.Azure:
+		// read by Credentials
+		return []string{"kube_config"}
+	}
+	return nil
+}
+
+// validateModule checks that the custom module in the given cluster directory declares what Hydroform relies on:
+// the outputs the cluster info is read from, and the cluster resource Update and Import work with.
+func validateModule(dir string, p types.ProviderType) error {
+	mod, diags := configs.NewParser(nil).LoadConfigDir(dir)
+	if diags.HasErrors() {
+		return errors.Wrapf(diags, "could not parse the custom %s module", p)
+	}
+
+	var missing []string
+	for _, o := range requiredOutputs(p) {
+		if _, ok := mod.Outputs[o]; !ok {
+			missing = append(missing, fmt.Sprintf("the output %q", o))
+		}
+	}
+	if r := clusterResource(p); r != "" {
+		if _, ok := mod.ManagedResources[r]; !ok {
+			missing = append(missing, fmt.Sprintf("the resource %q", r))
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("the custom %s module does not declare %s", p, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkImportable returns an error if the module in the given cluster directory manages resources that Import cannot bring into the state.
+// Importing only some of them would make the next Update create the others again next to the existing ones.
+func checkImportable(dir string, p types.ProviderType, cfg map[string]interface{}) error {
+	mod, diags := configs.NewParser(nil).LoadConfigDir(dir)
+	if diags.HasErrors() {
+		return errors.Wrapf(diags, "could not parse the %s module", p)
+	}
+
+	imported := importResources(p, cfg)
+	var rest []string
+	for addr := range mod.ManagedResources {
+		if _, ok := imported[addr]; !ok {
+			rest = append(rest, addr)
+		}
+	}
+	for name := range mod.ModuleCalls {
+		rest = append(rest, "module."+name)
+	}
+	if len(rest) > 0 {
+		sort.Strings(rest)
+		return errors.Errorf("the %s module manages resources besides the cluster that cannot be imported: %s", p, strings.Join(rest, ", "))
+	}
+	return nil
+}
+
 // stateFromFile loads the terraform state file for the given cluster
 func stateFromFile(dataDir, project, cluster string, p types.ProviderType) (*statefile.File, error) {
 	dir, err := clusterDir(dataDir, project, cluster, p)
@@ -350,6 +770,7 @@ func stateFromFile(dataDir, project, cluster string, p types.ProviderType) (*sta
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	st, err := statefile.Read(f)
 	if err != nil {
@@ -370,19 +791,36 @@ func stateToFile(state *statefile.File, dataDir, project, cluster string, p type
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 	return statefile.Write(state, f)
 }
 
+// planFromFile loads the terraform plan saved in the given cluster directory
+func planFromFile(dir string) (*plans.Plan, error) {
+	r, err := planfile.Open(filepath.Join(dir, tfPlanFile))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return r.ReadPlan()
+}
+
 func clusterInfoFromFile(dataDir, project, cluster string, p types.ProviderType) (*types.ClusterInfo, error) {
 	sf, err := stateFromFile(dataDir, project, cluster, p)
 	if err != nil {
 		return nil, err
 	}
+	return clusterInfoFromState(sf)
+}
 
+// clusterInfoFromState reads the endpoint and the certificate authority of the cluster from the outputs in its terraform state.
+func clusterInfoFromState(sf *statefile.File) (*types.ClusterInfo, error) {
 	var certificateData []byte
 	var endpoint string
+	var err error
 
-	if len(sf.State.Modules) > 0 {
+	if sf.State != nil && len(sf.State.Modules) > 0 {
 		if val, ok := sf.State.Modules[""].OutputValues["cluster_ca_certificate"]; ok {
 			certificateData, err = base64.StdEncoding.DecodeString(val.Value.AsString())
 			if err != nil {
@@ -465,7 +903,7 @@ func expandGardenerClusterTemplate(cfg map[string]interface{}) (string, error) {
 	if cfg["target_provider"] == string(types.AWS) {
 		// subnets for zones
 		var err error
-		tmpCfg.WorkerNets, tmpCfg.PublicNets, tmpCfg.InternalNets, err = generateGardenerAWSSubnets(cfg["vnetcidr"].(string), len(cfg["zones"].([]string)))
+		tmpCfg.WorkerNets, tmpCfg.PublicNets, tmpCfg.InternalNets, err = network.GardenerAWSSubnets(cfg["vnetcidr"].(string), len(cfg["zones"].([]string)))
 		if err != nil {
 			return "", errors.Wrap(err, "Error generating subnets for AWS zones")
 		}
@@ -489,38 +927,31 @@ func cleanup(dataDir, project, cluster string, p types.ProviderType) error {
 	return os.RemoveAll(d)
 }
 
+// cleanupUnlessInterrupted removes all terraform generated files for a given cluster unless ctx is done.
+// Files of interrupted operations are kept so that the operation can be resumed.
+func cleanupUnlessInterrupted(ctx context.Context, dataDir, project, cluster string, p types.ProviderType) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return cleanup(dataDir, project, cluster, p)
+}
+
+// interruptedError explains that an operation was stopped because ctx is done and where its state was left.
+func interruptedError(ctx context.Context, clusterDir string) error {
+	return errors.Wrapf(ctx.Err(), "operation interrupted, the terraform state is kept in %s to resume it", clusterDir)
+}
+
 // isEmptyDir returns true if the given path contains no files or subdirectories, false otherwise.
+// The lock file of the state and the metadata file are ignored, since they are created before terraform runs.
 func isEmptyDir(path string) (bool, error) {
 	entries, err := ioutil.ReadDir(path)
 	if err != nil {
 		return false, err
 	}
-	return len(entries) == 0, nil
-}
-
-func generateGardenerAWSSubnets(baseNet string, zoneCount int) (workerNets, publicNets, internalNets []string, err error) {
-	_, cidr, err := net.ParseCIDR(baseNet)
-	if err != nil {
-		return
-	}
-	if zoneCount < 1 {
-		err = errors.New("There must be at least 1 zone defined.")
-	}
-
-	// each zone gets its own subnet
-	const subnetSize = 64
-	for i := 0; i < zoneCount; i++ {
-		// workers subnet
-		cidr.IP[2] = byte(i * subnetSize)
-		cidr.Mask = net.CIDRMask(19, 8*net.IPv4len)
-		workerNets = append(workerNets, cidr.String())
-
-		// public and internal share the subnet and divide it further
-		cidr.Mask = net.CIDRMask(20, 8*net.IPv4len)
-		cidr.IP[2] = byte(i*subnetSize + subnetSize/2) // first half of the subnet after worker
-		publicNets = append(publicNets, cidr.String())
-		cidr.IP[2] = byte(int(cidr.IP[2]) + subnetSize/4) // second half of the subnet after worker
-		internalNets = append(internalNets, cidr.String())
+	for _, e := range entries {
+		if e.Name() != lockFile && e.Name() != metadataFile {
+			return false, nil
+		}
 	}
-	return
+	return true, nil
 }
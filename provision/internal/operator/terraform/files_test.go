@@ -0,0 +1,206 @@
+package terraform
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+const customGKETemplate = `
+variable "cluster_name" {}
+
+resource "google_container_cluster" "gke_cluster" {
+  name = var.cluster_name
+
+  network_policy {
+    enabled = true
+  }
+}
+
+output "endpoint" {
+  value = google_container_cluster.gke_cluster.endpoint
+}
+
+output "cluster_ca_certificate" {
+  value = google_container_cluster.gke_cluster.master_auth.0.cluster_ca_certificate
+}
+`
+
+func TestInitClusterFilesWithModule(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-module")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	cfg := map[string]interface{}{"project": "my-project", "cluster_name": "my-cluster"}
+	dir, err := clusterDir(dataDir, "my-project", "my-cluster", types.GCP)
+	require.NoError(t, err)
+
+	// a template replaces the built-in one
+	require.NoError(t, initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Template: customGKETemplate}))
+	data, err := ioutil.ReadFile(filepath.Join(dir, tfModuleFile))
+	require.NoError(t, err)
+	require.Equal(t, customGKETemplate, string(data))
+
+	// a template without the outputs of the built-in one fails
+	err = initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Template: `resource "google_container_cluster" "gke_cluster" {}`})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `the output "endpoint", the output "cluster_ca_certificate"`)
+
+	err = initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Template: `resource "google_container_cluster" "other" {}`})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `the resource "google_container_cluster.gke_cluster"`, "The cluster resource should be required")
+
+	err = initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Template: `resource {`})
+	require.Error(t, err, "An invalid template should fail")
+
+	err = initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Source: "./modules/gke", Template: customGKETemplate})
+	require.Error(t, err, "A module with both a source and a template should fail")
+
+	// a module source was downloaded by terraform init, so only its files are validated
+	require.NoError(t, os.Remove(filepath.Join(dir, tfModuleFile)))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(customGKETemplate), 0600))
+	require.NoError(t, initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Source: "./modules/gke"}))
+	_, err = os.Stat(filepath.Join(dir, tfModuleFile))
+	require.True(t, os.IsNotExist(err), "The built-in template should not be written next to a module source")
+
+	// without a custom module the built-in template is used
+	require.NoError(t, initClusterFiles(dataDir, types.GCP, cfg, nil))
+	data, err = ioutil.ReadFile(filepath.Join(dir, tfModuleFile))
+	require.NoError(t, err)
+	require.Equal(t, gcpClusterTemplate, string(data))
+}
+
+func TestUseLegacyLayout(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "hydroform-legacy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	readTemplate := func() string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, tfModuleFile))
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, tfModuleFile), []byte(gcpClusterTemplate), 0600))
+
+	legacy, err := useLegacyLayout(dir, types.GCP, nil)
+	require.NoError(t, err)
+	require.False(t, legacy, "A new cluster without a state should use the current layout")
+
+	writeGKEState(t, dir, clusterResource(types.GCP), gcpNodePoolResource)
+	legacy, err = useLegacyLayout(dir, types.GCP, nil)
+	require.NoError(t, err)
+	require.False(t, legacy, "A cluster with a node pool should use the current layout")
+	require.Equal(t, gcpClusterTemplate, readTemplate())
+
+	writeGKEState(t, dir, clusterResource(types.GCP))
+	legacy, err = useLegacyLayout(dir, types.GCP, &types.ClusterModule{Template: customGKETemplate})
+	require.NoError(t, err)
+	require.False(t, legacy, "A custom module should be kept")
+
+	legacy, err = useLegacyLayout(dir, types.GCP, nil)
+	require.NoError(t, err)
+	require.True(t, legacy, "A cluster without a node pool should keep its layout")
+	require.Equal(t, gcpLegacyClusterTemplate, readTemplate())
+}
+
+func TestCreateKeepsLegacyLayout(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-legacy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	// the plugin in the mirror lets terraform initialize the cluster, but not apply it
+	mirror := filepath.Join(dataDir, "mirror")
+	require.NoError(t, os.MkdirAll(mirror, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(mirror, "terraform-provider-google_v3.0.0"), nil, 0700))
+
+	// a persistent data directory holds the state of a cluster provisioned with the legacy layout
+	dir, err := clusterDir(dataDir, "project", "cluster", types.GCP)
+	require.NoError(t, err)
+	writeGKEState(t, dir, clusterResource(types.GCP))
+
+	tf := New(WithDataDir(dataDir), Persistent(), WithProviderMirror(types.ProviderMirror{Dir: mirror}))
+	_, err = tf.Create(context.Background(), types.GCP, map[string]interface{}{
+		"project":               "project",
+		"cluster_name":          "cluster",
+		"credentials_file_path": "/path/to/credentials",
+		"location":              "europe-west3-a",
+		"machine_type":          "n1-standard-4",
+		"kubernetes_version":    "1.17",
+		"node_count":            2,
+		"disk_size":             30,
+	})
+	require.Error(t, err, "The fake plugin should fail to apply")
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, tfModuleFile))
+	require.NoError(t, err)
+	require.Equal(t, gcpLegacyClusterTemplate, string(data), "Resuming the cluster should keep its layout")
+}
+
+// writeGKEState writes a state with the given GKE resources into the cluster directory.
+func writeGKEState(t *testing.T, dir string, resources ...string) {
+	state := states.BuildState(func(s *states.SyncState) {
+		for _, r := range resources {
+			parts := strings.Split(r, ".")
+			s.SetResourceInstanceCurrent(
+				addrs.Resource{Mode: addrs.ManagedResourceMode, Type: parts[0], Name: parts[1]}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+				&states.ResourceInstanceObjectSrc{Status: states.ObjectReady, AttrsJSON: []byte("{}")},
+				addrs.ProviderConfig{Type: addrs.NewLegacyProvider("google")}.Absolute(addrs.RootModuleInstance),
+			)
+		}
+	})
+	f, err := os.Create(filepath.Join(dir, tfStateFile))
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, statefile.Write(statefile.New(state, "lineage", 1), f))
+}
+
+func TestCheckImportable(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	cfg := map[string]interface{}{"project": "my-project", "cluster_name": "my-cluster", "location": "somewhere"}
+	for _, p := range []types.ProviderType{types.GCP, types.AWS, types.Kind} {
+		dir, err := clusterDir(dataDir, "my-project", "my-cluster", p)
+		require.NoError(t, err)
+		require.NoError(t, initClusterFiles(dataDir, p, cfg, nil))
+
+		err = checkImportable(dir, p, cfg)
+		if p != types.AWS {
+			require.NoError(t, err, "All resources of the %s template should be imported", p)
+			continue
+		}
+		require.Error(t, err, "The network of the AWS template cannot be imported")
+		require.Contains(t, err.Error(), "aws_eks_node_group.eks_node_group")
+		require.Contains(t, err.Error(), "aws_vpc.eks_vpc")
+		require.NotContains(t, err.Error(), "aws_eks_cluster.eks_cluster")
+	}
+
+	// custom modules are checked as well
+	dir, err := clusterDir(dataDir, "my-project", "my-cluster", types.GCP)
+	require.NoError(t, err)
+	require.NoError(t, initClusterFiles(dataDir, types.GCP, cfg, &types.ClusterModule{Template: customGKETemplate}))
+	require.NoError(t, checkImportable(dir, types.GCP, cfg))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, tfModuleFile), []byte(customGKETemplate+`
+module "network" {
+  source = "./network"
+}
+`), 0600))
+	err = checkImportable(dir, types.GCP, cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "module.network")
+}
@@ -13,7 +13,8 @@ func gcpFilter(key string, value interface{}) bool {
 }
 
 func azureFilter(key string, value interface{}) bool {
-	excludedKeys := []string{"project", "create_timeout", "update_timeout", "delete_timeout"}
+	// the module has no variable for the labels, so the expiry is only in the metadata of the cluster, see types.Cluster.ExpiresAt
+	excludedKeys := []string{"project", "create_timeout", "update_timeout", "delete_timeout", "labels"}
 
 	for _, e := range excludedKeys {
 		if key == e {
@@ -34,7 +35,14 @@ func gardenerFilter(key string, value interface{}) bool {
 }
 
 func kindFilter(key string, value interface{}) bool {
-	// by default all keys stay in the vars for kind
+	// these keys are part of the kind_config variable already, and kind clusters have no labels, see types.Cluster.ExpiresAt
+	excludedKeys := []string{"control_plane_nodes", "extra_port_mappings", "registry_mirror", "feature_gates", "labels"}
+
+	for _, e := range excludedKeys {
+		if key == e {
+			return false
+		}
+	}
 	return true
 }
 
@@ -51,9 +59,12 @@ func filterVars(cfg map[string]interface{}, p types.ProviderType) map[string]int
 	case types.Azure:
 		f = azureFilter
 	case types.AWS:
-		f = awsFilter // not supported yet
+		f = awsFilter
 	case types.Kind:
 		f = kindFilter
+	default:
+		// providers without a filter keep all keys
+		f = func(string, interface{}) bool { return true }
 	}
 
 	for key, value := range cfg {
@@ -38,5 +38,25 @@ func TestFilterVars(t *testing.T) {
 
 	// filter Kind
 	r = filterVars(cfg, types.Kind)
-	require.Equal(t, cfg, r, "Kind should not filter out any variables")
+	require.Equal(t, cfg, r, "Kind should not filter out any of these variables")
+	kindCfg := map[string]interface{}{
+		"cluster_name":        "fake-cluster",
+		"kind_config":         "a2luZDogQ2x1c3Rlcg==",
+		"control_plane_nodes": 3,
+		"extra_port_mappings": []string{"80:80"},
+	}
+	r = filterVars(kindCfg, types.Kind)
+	require.Equal(t, map[string]interface{}{
+		"cluster_name": "fake-cluster",
+		"kind_config":  "a2luZDogQ2x1c3Rlcg==",
+	}, r, "Kind should filter out the variables merged into kind_config")
+
+	// labels only exist in the templates of Hydroform
+	labeled := map[string]interface{}{
+		"cluster_name": "fake-cluster",
+		"labels":       map[string]string{types.ExpiresAtLabel: "1614600000"},
+	}
+	require.Equal(t, labeled, filterVars(labeled, types.GCP), "GCP should keep the labels")
+	require.Equal(t, map[string]interface{}{"cluster_name": "fake-cluster"}, filterVars(labeled, types.Azure), "Azure should filter out the labels")
+	require.Equal(t, map[string]interface{}{"cluster_name": "fake-cluster"}, filterVars(labeled, types.Kind), "Kind should filter out the labels")
 }
@@ -23,6 +23,9 @@ const (
 // initGardenerProvider will check if the gardener provider is available and download it if not.
 
 func initGardenerProvider() error {
+	gardenerProviderMu.Lock()
+	defer gardenerProviderMu.Unlock()
+
 	pluginDirs, err := globalPluginDirs()
 	if err != nil {
 		return err
@@ -0,0 +1,109 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+const metadataFile = ".hydroform.json"
+
+// Metadata describes a cluster managed by the operator.
+// It keeps the configuration the cluster was created with, so that the cluster can be deleted without it, such as once it expired.
+// Like the state, the configuration can contain credentials, such as the client secret of Azure.
+type Metadata struct {
+	// ExpiresAt is when the cluster expires, or zero if it does not expire.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Config is the configuration of the cluster, without the timeouts of the operations.
+	Config map[string]interface{} `json:"config"`
+}
+
+// newMetadata describes the cluster with the given configuration. The expiry is read from the labels of the cluster.
+func newMetadata(cfg map[string]interface{}) *Metadata {
+	md := &Metadata{Config: map[string]interface{}{}}
+	for k, v := range cfg {
+		switch k {
+		case "create_timeout", "update_timeout", "delete_timeout":
+			// the timeouts are set by each operation
+		default:
+			md.Config[k] = v
+		}
+	}
+
+	if labels, ok := cfg["labels"].(map[string]string); ok {
+		if sec, err := strconv.ParseInt(labels[types.ExpiresAtLabel], 10, 64); err == nil {
+			md.ExpiresAt = time.Unix(sec, 0).UTC()
+		}
+	}
+	return md
+}
+
+// Expired returns true if the cluster expires at or before the given time.
+func (m *Metadata) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// encodeMetadata writes the metadata as JSON.
+func encodeMetadata(md *Metadata) ([]byte, error) {
+	data, err := json.Marshal(md)
+	return data, errors.Wrap(err, "could not encode the metadata")
+}
+
+// decodeMetadata reads the metadata from JSON and restores the Go types of the configuration, which JSON does not keep:
+// whole numbers become ints, lists of strings become []string, and objects of strings become map[string]string.
+func decodeMetadata(data []byte) (*Metadata, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	md := &Metadata{}
+	if err := d.Decode(md); err != nil {
+		return nil, errors.Wrap(err, "could not decode the metadata")
+	}
+	for k, v := range md.Config {
+		md.Config[k] = restoreType(v)
+	}
+	return md, nil
+}
+
+func restoreType(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(t.String()); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case []interface{}:
+		l := make([]string, 0, len(t))
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return v
+			}
+			l = append(l, s)
+		}
+		return l
+	case map[string]interface{}:
+		m := make(map[string]string, len(t))
+		for k, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return v
+			}
+			m[k] = s
+		}
+		return m
+	}
+	return v
+}
+
+// sortKeys sorts the keys by provider, project, and cluster.
+func sortKeys(keys []StateKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+}
@@ -0,0 +1,79 @@
+package terraform
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+	cfg := map[string]interface{}{
+		"cluster_name":   "my-cluster",
+		"project":        "my-project",
+		"node_count":     3,
+		"ratio":          0.5,
+		"zoned":          true,
+		"zones":          []string{"a", "b"},
+		"labels":         map[string]string{types.ExpiresAtLabel: "1614600000"},
+		"create_timeout": 30 * time.Minute,
+	}
+
+	md := newMetadata(cfg)
+	require.Equal(t, time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), md.ExpiresAt, "The expiry should be read from the labels")
+	require.NotContains(t, md.Config, "create_timeout", "The timeouts should not be kept")
+	require.True(t, md.Expired(md.ExpiresAt))
+	require.False(t, md.Expired(md.ExpiresAt.Add(-time.Second)))
+	require.False(t, newMetadata(map[string]interface{}{}).Expired(time.Now()), "Clusters without expiry should never expire")
+
+	data, err := encodeMetadata(md)
+	require.NoError(t, err)
+	decoded, err := decodeMetadata(data)
+	require.NoError(t, err)
+	require.Equal(t, md.Config, decoded.Config, "The Go types of the configuration should be restored")
+}
+
+func TestReap(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-reap")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	b := &fileBackend{dataDir: dataDir}
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	for name, expiry := range map[string]time.Time{"expired": now.Add(-time.Hour), "alive": now.Add(time.Hour), "forever": {}} {
+		md := &Metadata{ExpiresAt: expiry, Config: map[string]interface{}{"cluster_name": name, "project": "my-project"}}
+		require.NoError(t, b.SaveMetadata(context.Background(), StateKey{Provider: types.GCP, Project: "my-project", Cluster: name}, md))
+	}
+
+	tf := &Terraform{ops: options(WithDataDir(dataDir), Persistent())}
+	report, err := tf.Reap(context.Background(), now, true)
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+	require.Len(t, report.Clusters, 3, "All clusters with metadata should be reported")
+	require.Equal(t, []types.ReapedCluster{{
+		Provider:  types.GCP,
+		Project:   "my-project",
+		Name:      "expired",
+		ExpiresAt: now.Add(-time.Hour),
+		Expired:   true,
+	}}, report.Expired(), "Only the expired cluster should be reaped, and a dry run should not deprovision it")
+
+	// the deletion fails right away on a canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err = tf.Reap(ctx, now, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "gcp/my-project/expired")
+	expired := report.Expired()
+	require.Len(t, expired, 1)
+	require.Equal(t, context.Canceled, expired[0].Err)
+	require.False(t, expired[0].Deprovisioned)
+	require.FileExists(t, filepath.Join(dataDir, "clusters", "gcp", "my-project", "expired", metadataFile), "A cluster that was not deleted should keep its metadata")
+}
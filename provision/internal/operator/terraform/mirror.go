@@ -0,0 +1,176 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/configs/configload"
+	"github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statefile"
+	tfcore "github.com/hashicorp/terraform/terraform"
+	"github.com/pkg/errors"
+)
+
+// tfProvidersFile pins the providers of a cluster to the versions of the lock file of the provider mirror
+const tfProvidersFile = "hydroform_providers.tf"
+
+// pluginDirs returns the directories terraform looks for provider plugins in instead of downloading them, or nil if there is no provider mirror.
+func (o Options) pluginDirs() ([]string, error) {
+	if o.ProviderMirror == nil {
+		return nil, nil
+	}
+	dir, err := filepath.Abs(o.ProviderMirror.Dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not resolve the directory of the provider mirror")
+	}
+	machineDir := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
+	return []string{dir, filepath.Join(dir, machineDir)}, nil
+}
+
+// pinProviders makes the cluster configuration in the given directory use the provider versions of the lock file of the mirror, and returns whether it pinned any.
+// It fails if the mirror misses any provider of the configuration or the state, listing all missing providers and their versions.
+func (o Options) pinProviders(dir string) (bool, error) {
+	if o.ProviderMirror == nil {
+		return false, nil
+	}
+
+	// the pins of a previous operation are part of the configuration otherwise
+	pinsFile := filepath.Join(dir, tfProvidersFile)
+	if err := os.Remove(pinsFile); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	reqd, err := providerRequirements(filepath.Join(o.DataDir(), "modules"), dir)
+	if err != nil {
+		return false, err
+	}
+	lock, err := loadProviderLock(o.ProviderMirror.LockFile)
+	if err != nil {
+		return false, err
+	}
+
+	pins := make(map[string]string)
+	for name, c := range reqd {
+		v, ok := lock[name]
+		if !ok {
+			continue
+		}
+		pin, err := discovery.ConstraintStr("= " + v).Parse()
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid version %q of the provider %s in the lock file", v, name)
+		}
+		c.Versions = c.Versions.Append(pin)
+		pins[name] = v
+	}
+
+	dirs, err := o.pluginDirs()
+	if err != nil {
+		return false, err
+	}
+	if err := checkPlugins(dirs, reqd); err != nil {
+		return false, errors.Wrapf(err, "the provider mirror %s is incomplete", o.ProviderMirror.Dir)
+	}
+
+	if len(pins) == 0 {
+		return false, nil
+	}
+	return true, ioutil.WriteFile(pinsFile, providerPins(pins), 0700)
+}
+
+// providerRequirements returns the providers the configuration and the state in the given cluster directory require, by name.
+func providerRequirements(modulesDir, dir string) (discovery.PluginRequirements, error) {
+	loader, err := configload.NewLoader(&configload.Config{ModulesDir: modulesDir})
+	if err != nil {
+		return nil, err
+	}
+	config, diags := loader.LoadConfig(dir)
+	if diags.HasErrors() {
+		return nil, errors.Wrap(diags, "could not load the configuration of the cluster")
+	}
+
+	var state *states.State
+	f, err := os.Open(filepath.Join(dir, tfStateFile))
+	switch {
+	case err == nil:
+		defer f.Close()
+		sf, err := statefile.Read(f)
+		if err != nil && err != statefile.ErrNoState {
+			return nil, errors.Wrap(err, "could not read the state of the cluster")
+		}
+		if sf != nil {
+			state = sf.State
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	reqd := tfcore.ConfigTreeDependencies(config, state).AllPluginRequirements()
+	// the terraform provider is built into terraform
+	delete(reqd, "terraform")
+	return reqd, nil
+}
+
+// loadProviderLock reads the provider versions pinned in the given lock file, by provider name. An empty path pins no version.
+func loadProviderLock(path string) (map[string]string, error) {
+	lock := make(map[string]string)
+	if path == "" {
+		return lock, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the provider lock file")
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, errors.Wrapf(err, "could not parse the provider lock file %s", path)
+	}
+	return lock, nil
+}
+
+// checkPlugins returns an error listing the required providers that have no plugin with a matching version in the given directories.
+func checkPlugins(dirs []string, reqd discovery.PluginRequirements) error {
+	available, _ := discovery.FindPlugins("provider", dirs).ValidateVersions()
+	candidates := available.ConstrainVersions(reqd)
+
+	var missing []string
+	for name, c := range reqd {
+		if candidates[name].Count() > 0 {
+			continue
+		}
+		versions := "any version"
+		if !c.Versions.Unconstrained() {
+			versions = c.Versions.String()
+		}
+		missing = append(missing, fmt.Sprintf("%s (%s)", name, versions))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return errors.Errorf("the following providers are missing:\n - %s", strings.Join(missing, "\n - "))
+}
+
+// providerPins returns a terraform configuration requiring the given version of each provider.
+func providerPins(pins map[string]string) []byte {
+	names := make([]string, 0, len(pins))
+	for name := range pins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Generated by Hydroform from the provider lock file\nterraform {\n  required_providers {\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "    %s = %q\n", name, "= "+pins[name])
+	}
+	b.WriteString("  }\n}\n")
+	return []byte(b.String())
+}
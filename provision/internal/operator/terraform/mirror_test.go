@@ -0,0 +1,114 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+const nullTemplate = `
+variable "project" {}
+variable "cluster_name" {}
+variable "create_timeout" {}
+variable "update_timeout" {}
+variable "delete_timeout" {}
+
+resource "null_resource" "cluster" {}
+
+resource "random_id" "cluster" {
+  byte_length = 4
+}
+
+output "endpoint" {
+  value = "https://${var.cluster_name}.example.com"
+}
+`
+
+func TestPinProviders(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-mirror")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	// plugins are only discovered by their file name
+	mirror := filepath.Join(dataDir, "mirror")
+	machineDir := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
+	require.NoError(t, os.MkdirAll(filepath.Join(mirror, machineDir), 0700))
+	for _, plugin := range []string{"terraform-provider-null_v2.1.0", machineDir + "/terraform-provider-null_v3.0.0", "terraform-provider-random_v3.0.0"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(mirror, plugin), nil, 0700))
+	}
+	lockFile := filepath.Join(dataDir, "providers.json")
+	require.NoError(t, ioutil.WriteFile(lockFile, []byte(`{"null": "2.1.0", "google": "3.49.0"}`), 0600))
+
+	dir := filepath.Join(dataDir, "cluster")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, tfModuleFile), []byte(nullTemplate), 0600))
+
+	// without mirror nothing is pinned
+	ops := options(WithDataDir(dataDir))
+	pinned, err := ops.pinProviders(dir)
+	require.NoError(t, err)
+	require.False(t, pinned)
+
+	// only the providers of the configuration are pinned
+	WithProviderMirror(types.ProviderMirror{Dir: mirror, LockFile: lockFile})(&ops)
+	pinned, err = ops.pinProviders(dir)
+	require.NoError(t, err)
+	require.True(t, pinned)
+	data, err := ioutil.ReadFile(filepath.Join(dir, tfProvidersFile))
+	require.NoError(t, err)
+	require.Equal(t, `# Generated by Hydroform from the provider lock file
+terraform {
+  required_providers {
+    null = "= 2.1.0"
+  }
+}
+`, string(data))
+
+	// pinning again replaces the previous pins
+	require.NoError(t, ioutil.WriteFile(lockFile, []byte(`{"null": "3.0.0"}`), 0600))
+	_, err = ops.pinProviders(dir)
+	require.NoError(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(dir, tfProvidersFile))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `null = "= 3.0.0"`, "The plugins of the platform directory should be found")
+
+	// all missing providers are listed with their versions
+	require.NoError(t, ioutil.WriteFile(lockFile, []byte(`{"null": "2.2.0"}`), 0600))
+	require.NoError(t, os.Remove(filepath.Join(mirror, "terraform-provider-random_v3.0.0")))
+	_, err = ops.pinProviders(dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the following providers are missing:\n - null (= 2.2.0)\n - random (any version)")
+
+	// an invalid lock file fails
+	require.NoError(t, ioutil.WriteFile(lockFile, []byte(`null = "2.1.0"`), 0600))
+	_, err = ops.pinProviders(dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not parse the provider lock file")
+}
+
+func TestCreateWithIncompleteMirror(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-mirror")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	mirror := filepath.Join(dataDir, "mirror")
+	require.NoError(t, os.MkdirAll(mirror, 0700))
+
+	local := types.ProviderType("local")
+	tf := New(WithDataDir(dataDir), WithModule(local, types.ClusterModule{Template: nullTemplate}), WithProviderMirror(types.ProviderMirror{Dir: mirror}))
+	_, err = tf.Create(context.Background(), local, map[string]interface{}{
+		"project":      "project",
+		"cluster_name": "cluster",
+	})
+	require.Error(t, err, "Create should fail before terraform looks for the plugins")
+	require.Contains(t, err.Error(), "the following providers are missing:\n - null (any version)\n - random (any version)")
+}
@@ -1,14 +1,15 @@
 package terraform
 
 import (
-	"io/ioutil"
-	"log"
+	"context"
+	"fmt"
 	"os"
 
 	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/pkg/errors"
 
 	"github.com/hashicorp/terraform/states/statefile"
+	hashiCli "github.com/mitchellh/cli"
 )
 
 // Terraform is an Operator.
@@ -16,34 +17,36 @@ type Terraform struct {
 	ops Options
 }
 
-// New creates a new Terraform operator with the given options
+// New creates a new Terraform operator with the given options.
+// The operator does not change any process-wide state, such as the standard logger or os.Stderr, so several operations can run concurrently.
 func New(ops ...Option) *Terraform {
-	// silence the logs since terraform prints a lot of stuff
-	tfOps := options(ops...)
-	if !tfOps.Verbose {
-		log.SetOutput(ioutil.Discard)
-	}
-
 	return &Terraform{
-		ops: tfOps,
+		ops: options(ops...),
 	}
 }
 
 // Create creates a new cluster for a specific provider based on configuration details. It returns a ClusterInfo object with provider-related information, or an error if cluster provisioning failed.
-func (t *Terraform) Create(p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+// If ctx is done while terraform is running, the operation is stopped gracefully and the cluster files are kept in the data directory, so that running Create again resumes it.
+func (t *Terraform) Create(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	applyTimeouts(cfg, t.ops.Timeouts)
 
-	// silence stdErr during terraform execution, plugins send debug and trace entries there
-	if !t.ops.Verbose {
-		stderr := os.Stderr
-		os.Stderr, _ = os.Open(os.DevNull)
-		defer func() { os.Stderr = stderr }()
+	ops, stop := t.ops.operation(ctx)
+	defer stop()
+
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
+	unlock, err := t.lockState(ctx, ops.Ui, key, "create")
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
 	// init cluster files
 	if !t.ops.Persistent {
 		// remove all files if not persistent after running
-		defer cleanup(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+		defer cleanupUnlessInterrupted(ctx, t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
 	}
 
 	clusterDir, err := clusterDir(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
@@ -51,40 +54,92 @@ func (t *Terraform) Create(p types.ProviderType, cfg map[string]interface{}) (*t
 		return nil, err
 	}
 	// INIT
-	if p == types.Gardener {
+	if p == types.Gardener && t.ops.ProviderMirror == nil {
 		if err := initGardenerProvider(); err != nil {
 			return nil, errors.Wrap(err, "could not initialize the gardener provider")
 		}
 	}
-	if err := tfInit(t.ops, p, cfg, clusterDir); err != nil {
+	if err := tfInit(ops, p, cfg, clusterDir); err != nil {
 		return nil, err
 	}
 
-	if err := initClusterFiles(t.ops.DataDir(), p, cfg); err != nil {
+	if err := initClusterFiles(t.ops.DataDir(), p, cfg, t.ops.module(p)); err != nil {
 		return nil, errors.Wrap(err, "Could not initialize cluster data")
 	}
+	if _, err := ops.pinProviders(clusterDir); err != nil {
+		return nil, err
+	}
+
+	// resume from the state in the backend, if any
+	if _, err := t.pullState(ctx, nil, key); err != nil {
+		return nil, err
+	}
+	// a cluster resumed with the legacy layout would be replaced by the current one
+	if _, err := useLegacyLayout(clusterDir, p, t.ops.module(p)); err != nil {
+		return nil, err
+	}
+	if err := t.saveMetadata(ctx, key, cfg); err != nil {
+		return nil, err
+	}
 
 	// APPLY
-	if err := tfApply(t.ops, p, cfg, clusterDir); err != nil {
+	err = tfApply(ctx, ops, p, cfg, clusterDir)
+	// store whatever terraform managed to create, even if it failed
+	if pushErr := t.pushState(key); pushErr != nil && err == nil {
+		err = pushErr
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			// hand back whatever terraform managed to create so the caller can resume or delete it
+			info, _ := clusterInfoFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+			if info != nil {
+				info.Status = &types.ClusterStatus{Phase: types.Errored}
+			}
+			return info, interruptedError(ctx, clusterDir)
+		}
 		return nil, err
 	}
 	return clusterInfoFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
 }
 
-// Status checks the current state of the cluster from the file
-func (t *Terraform) Status(sf *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+// Status checks the current state of the cluster from its state.
+// The cluster is Provisioning or Deleting while another operation holds the lock on the state, and Provisioned once the state has resources.
+// The health of a provisioned cluster is not checked by the operator, since it has no credentials to connect to the cluster.
+func (t *Terraform) Status(ctx context.Context, sf *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	applyTimeouts(cfg, t.ops.Timeouts)
 
 	cs := &types.ClusterStatus{
 		Phase: types.Unknown,
 	}
-	var err error
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
 
-	// if no state given, try the file system
+	// a running operation tells what is happening to the cluster
+	lock, err := t.stateBackend().LockInfo(ctx, key)
+	if err != nil {
+		return cs, errors.Wrap(err, "could not check the lock of the state")
+	}
+	if lock != nil {
+		switch lock.Operation {
+		case "create", "update", "import":
+			cs.Phase = types.Provisioning
+			return cs, nil
+		case "delete":
+			cs.Phase = types.Deleting
+			return cs, nil
+		}
+	}
+
+	// if no state given, try the state backend
 	if sf == nil {
-		sf, err = stateFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+		sf, err = t.stateBackend().Load(ctx, key)
 		if err != nil {
-			return cs, errors.Wrap(err, "no state provided, attempted to load from file")
+			return cs, errors.Wrap(err, "no state provided, attempted to load from the state backend")
+		}
+		if sf == nil {
+			return cs, fmt.Errorf("no state provided and no state found for %s in the state backend", key)
 		}
 	}
 
@@ -96,18 +151,27 @@ func (t *Terraform) Status(sf *statefile.File, p types.ProviderType, cfg map[str
 }
 
 // Delete removes an existing cluster or returns an error if removing the cluster is not possible.
-func (t *Terraform) Delete(sf *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
+// If ctx is done while terraform is running, the operation is stopped gracefully and the cluster files are kept in the data directory, so that running Delete again resumes it.
+func (t *Terraform) Delete(ctx context.Context, sf *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	applyTimeouts(cfg, t.ops.Timeouts)
 
-	// silence stdErr during terraform execution, plugins send debug and trace entries there
-	stderr := os.Stderr
-	os.Stderr, _ = os.Open(os.DevNull)
-	defer func() { os.Stderr = stderr }()
+	ops, stop := t.ops.operation(ctx)
+	defer stop()
+
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
+	unlock, err := t.lockState(ctx, ops.Ui, key, "delete")
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// init cluster files
 	if !t.ops.Persistent {
 		// remove all files if not persistent after running
-		defer cleanup(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+		defer cleanupUnlessInterrupted(ctx, t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
 	}
 
 	clusterDir, err := clusterDir(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
@@ -116,34 +180,370 @@ func (t *Terraform) Delete(sf *statefile.File, p types.ProviderType, cfg map[str
 	}
 
 	// INIT
-	if p == types.Gardener {
+	if p == types.Gardener && t.ops.ProviderMirror == nil {
 		if err := initGardenerProvider(); err != nil {
 			return errors.Wrap(err, "could not initialize the gardener provider")
 		}
 	}
-	if err := tfInit(t.ops, p, cfg, clusterDir); err != nil {
+	if err := tfInit(ops, p, cfg, clusterDir); err != nil {
 		return err
 	}
-	if err := initClusterFiles(t.ops.DataDir(), p, cfg); err != nil {
+	if err := initClusterFiles(t.ops.DataDir(), p, cfg, t.ops.module(p)); err != nil {
 		return errors.Wrap(err, "Could not initialize cluster data")
 	}
+	if _, err := ops.pinProviders(clusterDir); err != nil {
+		return err
+	}
 
-	// if no state given, check if it is already in the file system
-	if sf == nil {
-		_, err := stateFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
-		if err != nil {
-			return errors.Wrap(err, "no state provided, attempted to load from file")
+	// use the given state, otherwise the one in the state backend
+	found, err := t.pullState(ctx, sf, key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no state provided and no state found for %s in the state backend", key)
+	}
+
+	// APPLY
+	err = tfDestroy(ops, p, cfg, clusterDir)
+	// store what is left of the cluster, even if terraform failed
+	if pushErr := t.pushState(key); pushErr != nil && err == nil {
+		err = pushErr
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return interruptedError(ctx, clusterDir)
+		}
+		return err
+	}
+	// the cluster is gone, so Reap must not find it anymore
+	return errors.Wrap(t.stateBackend().SaveMetadata(context.Background(), key, nil), "could not remove the metadata of the cluster")
+}
+
+// Update applies changes of the configuration, such as the node count, machine type, or Kubernetes version, to an existing cluster.
+// The changes are planned against the given state first, and the update is refused if it requires destroying or replacing the cluster itself.
+// GKE clusters provisioned before their nodes moved into a separate node pool keep their layout, so their node count, machine type, and disk size cannot be updated.
+// If the state is nil, Update will attempt to load the state from the file system.
+// If ctx is done while terraform is running, the operation is stopped gracefully and the cluster files are kept in the data directory, so that running Update again resumes it.
+func (t *Terraform) Update(ctx context.Context, sf *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	applyTimeouts(cfg, t.ops.Timeouts)
+
+	ops, stop := t.ops.operation(ctx)
+	defer stop()
+
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
+	unlock, err := t.lockState(ctx, ops.Ui, key, "update")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// init cluster files
+	if !t.ops.Persistent {
+		// remove all files if not persistent after running
+		defer cleanupUnlessInterrupted(ctx, t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+	}
+
+	clusterDir, err := clusterDir(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+	if err != nil {
+		return nil, err
+	}
+
+	// INIT
+	if p == types.Gardener && t.ops.ProviderMirror == nil {
+		if err := initGardenerProvider(); err != nil {
+			return nil, errors.Wrap(err, "could not initialize the gardener provider")
+		}
+	}
+	if err := tfInit(ops, p, cfg, clusterDir); err != nil {
+		return nil, err
+	}
+	if err := initClusterFiles(t.ops.DataDir(), p, cfg, t.ops.module(p)); err != nil {
+		return nil, errors.Wrap(err, "Could not initialize cluster data")
+	}
+	if _, err := ops.pinProviders(clusterDir); err != nil {
+		return nil, err
+	}
+
+	// use the given state, otherwise the one in the state backend
+	found, err := t.pullState(ctx, sf, key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no state provided and no state found for %s in the state backend", key)
+	}
+	legacy, err := useLegacyLayout(clusterDir, p, t.ops.module(p))
+	if err != nil {
+		return nil, err
+	}
+
+	// PLAN
+	if err := tfPlan(ops, p, cfg, clusterDir, false); err != nil {
+		if ctx.Err() != nil {
+			return nil, interruptedError(ctx, clusterDir)
 		}
-	} else {
-		// otherwise save the state into a file so terraform can use it
-		if err := stateToFile(sf, t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p); err != nil {
-			return errors.Wrap(err, "could not store state into file")
+		return nil, err
+	}
+	plan, err := planFromFile(clusterDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the update plan")
+	}
+	if err := checkClusterKept(plan, p); err != nil {
+		if legacy {
+			return nil, errors.Wrap(err, "the nodes of the cluster are part of its cluster resource, since it was provisioned before they moved into a separate node pool. Changing its node count, machine type, or disk size replaces the cluster")
 		}
+		return nil, err
+	}
+	if err := t.saveMetadata(ctx, key, cfg); err != nil {
+		return nil, err
 	}
 
 	// APPLY
-	if err := tfDestroy(t.ops, p, cfg, clusterDir); err != nil {
-		return err
+	err = tfApplyPlan(ops, clusterDir)
+	// store whatever terraform managed to change, even if it failed
+	if pushErr := t.pushState(key); pushErr != nil && err == nil {
+		err = pushErr
 	}
-	return nil
+	if err != nil {
+		if ctx.Err() != nil {
+			info, _ := clusterInfoFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+			if info != nil {
+				info.Status = &types.ClusterStatus{Phase: types.Errored}
+			}
+			return info, interruptedError(ctx, clusterDir)
+		}
+		return nil, err
+	}
+	return clusterInfoFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+}
+
+// Plan describes the changes Create, Update, or Delete (if the PlanDestroy option is set) would make to the cluster without applying them.
+// If the state is nil, Plan will attempt to load the state from the file system. If there is no state, the plan describes the creation of a new cluster.
+func (t *Terraform) Plan(ctx context.Context, sf *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.Plan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	applyTimeouts(cfg, t.ops.Timeouts)
+
+	ops, stop := t.ops.operation(ctx)
+	defer stop()
+
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
+	unlock, err := t.lockState(ctx, ops.Ui, key, "plan")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// init cluster files
+	if !t.ops.Persistent {
+		// remove all files if not persistent after running
+		defer cleanup(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+	}
+
+	clusterDir, err := clusterDir(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+	if err != nil {
+		return nil, err
+	}
+
+	// INIT
+	if p == types.Gardener && t.ops.ProviderMirror == nil {
+		if err := initGardenerProvider(); err != nil {
+			return nil, errors.Wrap(err, "could not initialize the gardener provider")
+		}
+	}
+	if err := tfInit(ops, p, cfg, clusterDir); err != nil {
+		return nil, err
+	}
+	if err := initClusterFiles(t.ops.DataDir(), p, cfg, t.ops.module(p)); err != nil {
+		return nil, errors.Wrap(err, "Could not initialize cluster data")
+	}
+	if _, err := ops.pinProviders(clusterDir); err != nil {
+		return nil, err
+	}
+
+	// use the given state, otherwise the one in the state backend if any
+	if _, err := t.pullState(ctx, sf, key); err != nil {
+		return nil, err
+	}
+	if _, err := useLegacyLayout(clusterDir, p, t.ops.module(p)); err != nil {
+		return nil, err
+	}
+
+	// PLAN
+	if err := tfPlan(ops, p, cfg, clusterDir, t.ops.PlanDestroy); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	plan, err := planFromFile(clusterDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the plan")
+	}
+	return planChanges(plan), nil
+}
+
+// Import brings an existing cluster, created outside of Hydroform or whose state was lost, under the management of the operator.
+// It imports the resources of the module into a new state, so that Status, Update, and Delete work on the cluster afterwards.
+// This is the cluster resource and, for GCP, the node pool named after the cluster.
+// Import fails if there already is a state for the cluster, or if the module manages other resources, such as the network of the AWS template,
+// since their IDs are unknown and the next Update would create them again.
+func (t *Terraform) Import(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	applyTimeouts(cfg, t.ops.Timeouts)
+
+	ops, stop := t.ops.operation(ctx)
+	defer stop()
+
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
+	unlock, err := t.lockState(ctx, ops.Ui, key, "import")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// a cluster with a state is managed already, importing it again would overwrite its state
+	sf, err := t.stateBackend().Load(ctx, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load the state from the state backend")
+	}
+	if sf != nil && sf.State.HasResources() {
+		return nil, fmt.Errorf("the cluster %s is managed already, there is a state for it in the state backend", key)
+	}
+
+	// init cluster files
+	if !t.ops.Persistent {
+		// remove all files if not persistent after running
+		defer cleanup(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+	}
+
+	clusterDir, err := clusterDir(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+	if err != nil {
+		return nil, err
+	}
+
+	// INIT
+	if p == types.Gardener && t.ops.ProviderMirror == nil {
+		if err := initGardenerProvider(); err != nil {
+			return nil, errors.Wrap(err, "could not initialize the gardener provider")
+		}
+	}
+	if err := tfInit(ops, p, cfg, clusterDir); err != nil {
+		return nil, err
+	}
+	if err := initClusterFiles(t.ops.DataDir(), p, cfg, t.ops.module(p)); err != nil {
+		return nil, errors.Wrap(err, "Could not initialize cluster data")
+	}
+	if err := checkImportable(clusterDir, p, cfg); err != nil {
+		return nil, err
+	}
+	if _, err := ops.pinProviders(clusterDir); err != nil {
+		return nil, err
+	}
+
+	// IMPORT
+	if err := tfImport(ops, p, cfg, clusterDir); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrap(err, "could not import the cluster")
+	}
+	if err := t.pushState(key); err != nil {
+		return nil, err
+	}
+	if err := t.saveMetadata(ctx, key, cfg); err != nil {
+		return nil, err
+	}
+	return clusterInfoFromFile(t.ops.DataDir(), cfg["project"].(string), cfg["cluster_name"].(string), p)
+}
+
+// ClusterInfo rebuilds the ClusterInfo of an existing cluster from its state in the state backend, which includes the endpoint, the certificate authority, and the outputs of the state.
+// With the data directory as state backend, the state is only kept if the data directory is persistent.
+func (t *Terraform) ClusterInfo(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key := StateKey{Provider: p, Project: cfg["project"].(string), Cluster: cfg["cluster_name"].(string)}
+	sf, err := t.stateBackend().Load(ctx, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load the state from the state backend")
+	}
+	if sf == nil {
+		return nil, fmt.Errorf("no state found for %s in the state backend", key)
+	}
+	return clusterInfoFromState(sf)
+}
+
+// stateBackend returns the backend storing the state of the clusters, which is the data directory unless configured otherwise.
+func (t *Terraform) stateBackend() StateBackend {
+	if t.ops.StateBackend != nil {
+		return t.ops.StateBackend
+	}
+	return &fileBackend{dataDir: t.ops.DataDir()}
+}
+
+// lockState locks the state of the cluster in the state backend for the given operation and returns the function that unlocks it.
+// Unlocking errors are reported to the UI of the operation.
+func (t *Terraform) lockState(ctx context.Context, ui hashiCli.Ui, key StateKey, operation string) (func(), error) {
+	unlock, err := t.stateBackend().Lock(ctx, key, newLockInfo(operation))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not lock the state")
+	}
+
+	return func() {
+		if err := unlock(); err != nil && ui != nil {
+			ui.Error(fmt.Sprintf("Error unlocking the state of %s, unlock it manually: %s", key, err))
+		}
+	}, nil
+}
+
+// saveMetadata stores the metadata of the cluster in the state backend, so that Reap finds the cluster.
+func (t *Terraform) saveMetadata(ctx context.Context, key StateKey, cfg map[string]interface{}) error {
+	return errors.Wrap(t.stateBackend().SaveMetadata(ctx, key, newMetadata(cfg)), "could not save the metadata of the cluster")
+}
+
+// pullState writes the state terraform works on into the cluster directory: the given state if any, otherwise the one in the state backend.
+// It returns false if there is no state at all.
+func (t *Terraform) pullState(ctx context.Context, sf *statefile.File, key StateKey) (bool, error) {
+	if sf == nil {
+		var err error
+		if sf, err = t.stateBackend().Load(ctx, key); err != nil {
+			return false, errors.Wrap(err, "could not load the state from the state backend")
+		}
+		if sf == nil {
+			return false, nil
+		}
+	}
+
+	if err := stateToFile(sf, t.ops.DataDir(), key.Project, key.Cluster, key.Provider); err != nil {
+		return false, errors.Wrap(err, "could not store state into file")
+	}
+	return true, nil
+}
+
+// pushState saves the state terraform left in the cluster directory into the state backend.
+func (t *Terraform) pushState(key StateKey) error {
+	// the state file already is the data directory backend
+	if t.ops.StateBackend == nil {
+		return nil
+	}
+
+	sf, err := stateFromFile(t.ops.DataDir(), key.Project, key.Cluster, key.Provider)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not read the state file")
+	}
+
+	// the state has to be saved even if the operation was canceled
+	return errors.Wrap(t.stateBackend().Save(context.Background(), key, sf), "could not save the state into the state backend")
 }
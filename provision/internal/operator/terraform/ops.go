@@ -1,7 +1,9 @@
 package terraform
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -24,13 +26,32 @@ type Options struct {
 	command.Meta
 	// Persistent allows to configure if terraform files should stay in the file system or be cleaned up after each operation.
 	Persistent bool
-	// TODO add module source property here corresponding to flag -from-module
+	// Modules replace the built-in terraform configuration of the clusters of a provider with a module source, downloaded with the -from-module flag, or a template.
+	Modules map[types.ProviderType]types.ClusterModule
+	// ProviderMirror makes terraform use the provider plugins of a local directory, pinned to the versions of a lock file, instead of downloading them.
+	ProviderMirror *types.ProviderMirror
 
 	// Timeouts specifies the timeouts of the operations
 	Timeouts types.Timeouts
 
 	// Print terraform log for debugging
 	Verbose bool
+
+	// PlanDestroy makes Plan describe the destruction of the cluster instead of its creation or update
+	PlanDestroy bool
+
+	// StateBackend stores the state of the clusters. If nil, the state stays in the data directory.
+	StateBackend StateBackend
+
+	// Progress receives the progress events parsed from the terraform output. It only works with the default UI.
+	Progress func(types.ProgressEvent)
+
+	// Output receives the output of terraform, line by line. It only works with the default UI.
+	// If nil, the output is discarded unless Verbose is set, which prints it to the standard output.
+	Output io.Writer
+
+	// defaultUI is set if no custom UI was configured, so that each operation gets its own UI.
+	defaultUI bool
 }
 
 // Option is a function that allows to extensibly configure the terraform operator.
@@ -70,6 +91,51 @@ func Verbose(verbose bool) Option {
 	}
 }
 
+// Make Plan describe the destruction of the cluster
+func PlanDestroy() Option {
+	return func(ops *Options) {
+		ops.PlanDestroy = true
+	}
+}
+
+// Store the state of the clusters in the given backend instead of the data directory
+func WithStateBackend(b StateBackend) Option {
+	return func(ops *Options) {
+		ops.StateBackend = b
+	}
+}
+
+// Report the progress of the resource changes to the given function
+func WithProgress(f func(types.ProgressEvent)) Option {
+	return func(ops *Options) {
+		ops.Progress = f
+	}
+}
+
+// Use the provider plugins of the given mirror instead of downloading them
+func WithProviderMirror(m types.ProviderMirror) Option {
+	return func(ops *Options) {
+		ops.ProviderMirror = &m
+	}
+}
+
+// Write the output of terraform to the given writer
+func WithOutput(w io.Writer) Option {
+	return func(ops *Options) {
+		ops.Output = w
+	}
+}
+
+// Provision the clusters of the given provider with a custom module source or template
+func WithModule(p types.ProviderType, m types.ClusterModule) Option {
+	return func(ops *Options) {
+		if ops.Modules == nil {
+			ops.Modules = make(map[types.ProviderType]types.ClusterModule)
+		}
+		ops.Modules[p] = m
+	}
+}
+
 // ToTerraformOptions turns Hydroform options into terraform operator specific options
 func ToTerraformOptions(ops *types.Options) (tfOps []Option) {
 
@@ -89,6 +155,30 @@ func ToTerraformOptions(ops *types.Options) (tfOps []Option) {
 		tfOps = append(tfOps, Verbose(ops.Verbose))
 	}
 
+	if ops.PlanDestroy {
+		tfOps = append(tfOps, PlanDestroy())
+	}
+
+	if ops.Progress != nil {
+		tfOps = append(tfOps, WithProgress(ops.Progress))
+	}
+
+	if ops.Output != nil {
+		tfOps = append(tfOps, WithOutput(ops.Output))
+	}
+
+	for p, m := range ops.ClusterModules {
+		tfOps = append(tfOps, WithModule(p, m))
+	}
+
+	if ops.ProviderMirror != nil {
+		tfOps = append(tfOps, WithProviderMirror(*ops.ProviderMirror))
+	}
+
+	if b := newStateBackend(ops.StateBackend); b != nil {
+		tfOps = append(tfOps, WithStateBackend(b))
+	}
+
 	return tfOps
 }
 
@@ -152,9 +242,48 @@ func options(ops ...Option) Options {
 		o(&tfOps)
 	}
 
+	if tfOps.Ui == ui {
+		tfOps.defaultUI = true
+		ui.progress = tfOps.Progress
+		ui.out = tfOps.output()
+	}
+
 	return tfOps
 }
 
+// output returns the writer receiving the output of terraform, or nil if the output is discarded.
+func (o Options) output() io.Writer {
+	if o.Output == nil && o.Verbose {
+		return os.Stdout
+	}
+	return o.Output
+}
+
+// operation returns a copy of the options for a single operation, see withContext.
+// With the default UI, the operation gets its own UI, so that concurrent operations do not mix up their output and errors.
+func (o Options) operation(ctx context.Context) (Options, func()) {
+	if o.defaultUI {
+		o.Ui = &HydroUI{progress: o.Progress, out: o.output()}
+	}
+	return o.withContext(ctx)
+}
+
+// module returns the custom module of the given provider, or nil if its clusters use the built-in configuration.
+func (o Options) module(p types.ProviderType) *types.ClusterModule {
+	if m, ok := o.Modules[p]; ok {
+		return &m
+	}
+	return nil
+}
+
+// moduleSource returns the module source terraform init copies into the empty directory of a cluster, or an empty string if Hydroform writes the cluster files.
+func (o Options) moduleSource(p types.ProviderType) string {
+	if m := o.module(p); m != nil {
+		return m.Source
+	}
+	return tfMod(p)
+}
+
 func makeShutdownCh() <-chan struct{} {
 	resultCh := make(chan struct{})
 
@@ -168,3 +297,35 @@ func makeShutdownCh() <-chan struct{} {
 	}()
 	return resultCh
 }
+
+// withContext returns a copy of the options whose ShutdownCh also fires once ctx is done.
+// Terraform stops gracefully on the first shutdown request and keeps the state of what was done so far.
+// The returned function releases the resources used to watch ctx and must be called when the operation is over.
+func (o Options) withContext(ctx context.Context) (Options, func()) {
+	signalCh := o.ShutdownCh
+	resultCh := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		ctxDone := ctx.Done()
+		for {
+			select {
+			case <-ctxDone:
+				// only notify once, a second notification makes terraform abort without waiting for providers
+				ctxDone = nil
+			case <-signalCh:
+			case <-done:
+				return
+			}
+
+			select {
+			case resultCh <- struct{}{}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	o.ShutdownCh = resultCh
+	return o, func() { close(done) }
+}
@@ -1,7 +1,11 @@
 package terraform
 
 import (
+	"bytes"
+	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/command"
 	"github.com/kyma-incubator/hydroform/provision/types"
@@ -42,6 +46,19 @@ func TestPersistent(t *testing.T) {
 	require.True(t, ops.Persistent)
 }
 
+func TestWithProgress(t *testing.T) {
+	t.Parallel()
+	var events []types.ProgressEvent
+	ops := options(WithProgress(func(e types.ProgressEvent) {
+		events = append(events, e)
+	}))
+
+	ops.Ui.Output("kind.kind-cluster: Creating...")
+
+	require.Len(t, events, 1, "The default UI should report progress")
+	require.Equal(t, "kind.kind-cluster", events[0].Resource)
+}
+
 func TestToTerraformOptions(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -87,6 +104,42 @@ func TestToTerraformOptions(t *testing.T) {
 				Persistent: true,
 			},
 		},
+		{
+			Name: "Only plan destroy",
+			Input: types.Options{
+				PlanDestroy: true,
+			},
+			Expected: Options{
+				PlanDestroy: true,
+			},
+		},
+		{
+			Name: "Only cluster modules",
+			Input: types.Options{
+				ClusterModules: map[types.ProviderType]types.ClusterModule{types.GCP: {Source: "./modules/gke"}},
+			},
+			Expected: Options{
+				Modules: map[types.ProviderType]types.ClusterModule{types.GCP: {Source: "./modules/gke"}},
+			},
+		},
+		{
+			Name: "Only provider mirror",
+			Input: types.Options{
+				ProviderMirror: &types.ProviderMirror{Dir: "/opt/terraform/plugins", LockFile: "/opt/terraform/providers.json"},
+			},
+			Expected: Options{
+				ProviderMirror: &types.ProviderMirror{Dir: "/opt/terraform/plugins", LockFile: "/opt/terraform/providers.json"},
+			},
+		},
+		{
+			Name: "Only output",
+			Input: types.Options{
+				Output: os.Stderr,
+			},
+			Expected: Options{
+				Output: os.Stderr,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -100,3 +153,100 @@ func TestToTerraformOptions(t *testing.T) {
 		require.Equal(t, tc.Expected, *ops, tc.Name)
 	}
 }
+
+func TestModuleSource(t *testing.T) {
+	t.Parallel()
+	ops := Options{}
+
+	require.Nil(t, ops.module(types.Azure))
+	require.Equal(t, azureMod, ops.moduleSource(types.Azure), "The built-in module should be downloaded by default")
+	require.Empty(t, ops.moduleSource(types.GCP), "The built-in template should not be downloaded")
+
+	WithModule(types.GCP, types.ClusterModule{Source: "./modules/gke"})(&ops)
+	WithModule(types.Azure, types.ClusterModule{Template: "# aks"})(&ops)
+	require.Equal(t, "./modules/gke", ops.moduleSource(types.GCP))
+	require.Empty(t, ops.moduleSource(types.Azure), "A template should replace the built-in module")
+	require.Equal(t, "# aks", ops.module(types.Azure).Template)
+}
+
+func TestOperation(t *testing.T) {
+	t.Parallel()
+	out := &bytes.Buffer{}
+	ops := options(WithOutput(out))
+
+	first, stopFirst := ops.operation(context.Background())
+	defer stopFirst()
+	second, stopSecond := ops.operation(context.Background())
+	defer stopSecond()
+
+	require.NotSame(t, first.Ui, second.Ui, "Each operation should get its own UI")
+	first.Ui.Error("Error: first operation failed")
+	require.Error(t, checkUIErrors(first.Ui))
+	require.NoError(t, checkUIErrors(second.Ui), "Errors should not be shared among operations")
+	require.NoError(t, checkUIErrors(ops.Ui), "The operations should not modify the UI of the options")
+	require.Equal(t, "Error: first operation failed\n", out.String(), "The output should be written to the output writer")
+
+	// custom UIs are kept
+	ui := &HydroUI{}
+	ops = options(WithUI(ui))
+	op, stop := ops.operation(context.Background())
+	defer stop()
+	require.Equal(t, ui, op.Ui)
+
+	// verbose operations print to stdout without an output writer
+	op, stop = options(Verbose(true)).operation(context.Background())
+	defer stop()
+	require.Equal(t, os.Stdout, op.Ui.(*HydroUI).out)
+}
+
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+	signalCh := make(chan struct{})
+	ops := Options{}
+	ops.ShutdownCh = signalCh
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctxOps, stop := ops.withContext(ctx)
+	defer stop()
+
+	require.Equal(t, (<-chan struct{})(signalCh), ops.ShutdownCh, "The original options should not be modified")
+
+	// signals are still forwarded
+	signalCh <- struct{}{}
+	select {
+	case <-ctxOps.ShutdownCh:
+	case <-time.After(time.Second):
+		require.Fail(t, "A shutdown signal should be forwarded")
+	}
+
+	// canceling the context requests a shutdown exactly once
+	cancel()
+	select {
+	case <-ctxOps.ShutdownCh:
+	case <-time.After(time.Second):
+		require.Fail(t, "Canceling the context should request a shutdown")
+	}
+	select {
+	case <-ctxOps.ShutdownCh:
+		require.Fail(t, "Canceling the context should request a shutdown only once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tf := &Terraform{}
+	cfg := map[string]interface{}{"project": "project", "cluster_name": "cluster"}
+
+	_, err := tf.Create(ctx, types.GCP, cfg)
+	require.Equal(t, context.Canceled, err, "Create should not start with a canceled context")
+
+	_, err = tf.Status(ctx, nil, types.GCP, cfg)
+	require.Equal(t, context.Canceled, err, "Status should not start with a canceled context")
+
+	err = tf.Delete(ctx, nil, types.GCP, cfg)
+	require.Equal(t, context.Canceled, err, "Delete should not start with a canceled context")
+}
@@ -0,0 +1,44 @@
+package terraform
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+// Reap deletes the clusters in the state backend that expired at the given time, and reports all clusters it found.
+// With dryRun, the expired clusters are only reported. Only clusters with metadata are found, which the operator stores when it creates, updates, or imports a cluster.
+// The failure to delete one cluster does not stop Reap, the returned error lists all failures.
+func (t *Terraform) Reap(ctx context.Context, now time.Time, dryRun bool) (*types.ReapReport, error) {
+	keys, err := t.stateBackend().List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list the clusters in the state backend")
+	}
+
+	report := &types.ReapReport{DryRun: dryRun, Clusters: []types.ReapedCluster{}}
+	for _, key := range keys {
+		c := types.ReapedCluster{Provider: key.Provider, Project: key.Project, Name: key.Cluster}
+
+		md, err := t.stateBackend().LoadMetadata(ctx, key)
+		if err != nil {
+			c.Err = errors.Wrap(err, "could not load the metadata of the cluster")
+			report.Clusters = append(report.Clusters, c)
+			continue
+		}
+		if md == nil {
+			// deleted since it was listed
+			continue
+		}
+
+		c.ExpiresAt = md.ExpiresAt
+		c.Expired = md.Expired(now)
+		if c.Expired && !dryRun {
+			c.Err = t.Delete(ctx, nil, key.Provider, md.Config)
+			c.Deprovisioned = c.Err == nil
+		}
+		report.Clusters = append(report.Clusters, c)
+	}
+	return report, report.Err()
+}
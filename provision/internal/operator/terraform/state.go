@@ -0,0 +1,285 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+const lockFile = ".hydroform.lock"
+
+// StateBackend stores the terraform state of clusters.
+// The operator works on a copy of the state in the cluster directory, which it loads from the backend before running terraform and saves back afterwards.
+type StateBackend interface {
+	// Load returns the state stored for the given cluster, or nil if there is none.
+	Load(ctx context.Context, key StateKey) (*statefile.File, error)
+	// Save stores the state of the given cluster, replacing the previous one.
+	Save(ctx context.Context, key StateKey, state *statefile.File) error
+	// Lock acquires an exclusive lock on the state of the given cluster and returns the function that releases it.
+	// If the state is already locked, Lock returns a *LockedError.
+	Lock(ctx context.Context, key StateKey, info *LockInfo) (unlock func() error, err error)
+	// LockInfo describes the current holder of the lock on the state of the given cluster, or returns nil if the state is not locked.
+	LockInfo(ctx context.Context, key StateKey) (*LockInfo, error)
+	// List returns the keys of all clusters with metadata, sorted by provider, project, and cluster.
+	List(ctx context.Context) ([]StateKey, error)
+	// LoadMetadata returns the metadata stored for the given cluster, or nil if there is none.
+	LoadMetadata(ctx context.Context, key StateKey) (*Metadata, error)
+	// SaveMetadata stores the metadata of the given cluster, replacing the previous one. Nil metadata removes it.
+	SaveMetadata(ctx context.Context, key StateKey, md *Metadata) error
+}
+
+// StateKey identifies the state of a cluster in a StateBackend.
+type StateKey struct {
+	Provider types.ProviderType
+	Project  string
+	Cluster  string
+}
+
+// String returns the key as a slash-separated path.
+func (k StateKey) String() string {
+	return path.Join(string(k.Provider), k.Project, k.Cluster)
+}
+
+// LockInfo describes who holds a state lock.
+type LockInfo struct {
+	Operation string    `json:"operation"`
+	Who       string    `json:"who"`
+	Created   time.Time `json:"created"`
+}
+
+// newLockInfo describes a lock taken by the current user and host for the given operation.
+func newLockInfo(operation string) *LockInfo {
+	who := "unknown"
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		who = fmt.Sprintf("%s@%s", who, host)
+	}
+
+	return &LockInfo{
+		Operation: operation,
+		Who:       who,
+		Created:   time.Now().UTC(),
+	}
+}
+
+// LockedError is returned when the state of a cluster is locked by another operation.
+// A process killed during an operation leaves its lock behind, which has to be removed at Location before the cluster can be managed again.
+type LockedError struct {
+	Key StateKey
+	// Info describes the current holder of the lock, if known.
+	Info *LockInfo
+	// Location is where the backend keeps the lock, such as the path of the lock file.
+	Location string
+}
+
+func (e *LockedError) Error() string {
+	msg := fmt.Sprintf("the state of %s is locked by another operation", e.Key)
+	if e.Info != nil {
+		msg = fmt.Sprintf("the state of %s is locked by the %s operation of %s since %s", e.Key, e.Info.Operation, e.Info.Who, e.Info.Created.Format(time.RFC3339))
+	}
+	if e.Location == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s. If no operation runs anymore, delete %s to unlock it", msg, e.Location)
+}
+
+// fileBackend stores the state in the cluster directory inside the data directory.
+// It is the default backend and the same file terraform works on.
+type fileBackend struct {
+	dataDir string
+}
+
+// Load reads the state from the terraform state file of the cluster.
+func (b *fileBackend) Load(ctx context.Context, key StateKey) (*statefile.File, error) {
+	sf, err := stateFromFile(b.dataDir, key.Project, key.Cluster, key.Provider)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return sf, err
+}
+
+// Save writes the state into the terraform state file of the cluster.
+func (b *fileBackend) Save(ctx context.Context, key StateKey, state *statefile.File) error {
+	return stateToFile(state, b.dataDir, key.Project, key.Cluster, key.Provider)
+}
+
+// Lock creates a lock file in the cluster directory, which fails if it already exists.
+func (b *fileBackend) Lock(ctx context.Context, key StateKey, info *LockInfo) (func() error, error) {
+	dir, err := clusterDir(b.dataDir, key.Project, key.Cluster, key.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	lockPath := filepath.Join(dir, lockFile)
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		current, _ := b.LockInfo(ctx, key)
+		return nil, &LockedError{Key: key, Info: current, Location: "the lock file " + lockPath}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the lock file")
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(info); err != nil {
+		os.Remove(lockPath)
+		return nil, errors.Wrap(err, "could not write the lock file")
+	}
+
+	return func() error {
+		// the cluster directory might have been cleaned up already
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}, nil
+}
+
+// LockInfo reads the lock file in the cluster directory.
+func (b *fileBackend) LockInfo(ctx context.Context, key StateKey) (*LockInfo, error) {
+	dir, err := clusterDir(b.dataDir, key.Project, key.Cluster, key.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, lockFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := &LockInfo{}
+	return info, json.Unmarshal(data, info)
+}
+
+// List returns the keys of the clusters with a metadata file in the data directory.
+func (b *fileBackend) List(ctx context.Context) ([]StateKey, error) {
+	files, err := filepath.Glob(filepath.Join(b.dataDir, "clusters", "*", "*", "*", metadataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]StateKey, 0, len(files))
+	for _, f := range files {
+		clDir := filepath.Dir(f)
+		projectDir := filepath.Dir(clDir)
+		keys = append(keys, StateKey{
+			Provider: types.ProviderType(filepath.Base(filepath.Dir(projectDir))),
+			Project:  filepath.Base(projectDir),
+			Cluster:  filepath.Base(clDir),
+		})
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// LoadMetadata reads the metadata file in the cluster directory.
+func (b *fileBackend) LoadMetadata(ctx context.Context, key StateKey) (*Metadata, error) {
+	dir, err := clusterDir(b.dataDir, key.Project, key.Cluster, key.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, metadataFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeMetadata(data)
+}
+
+// SaveMetadata writes the metadata file into the cluster directory, or removes it if the metadata is nil.
+func (b *fileBackend) SaveMetadata(ctx context.Context, key StateKey, md *Metadata) error {
+	dir, err := clusterDir(b.dataDir, key.Project, key.Cluster, key.Provider)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, metadataFile)
+	if md == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := encodeMetadata(md)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// brokenBackend reports the error that prevented creating a state backend each time it is used.
+// It allows options to stay free of errors.
+type brokenBackend struct {
+	err error
+}
+
+func (b *brokenBackend) Load(ctx context.Context, key StateKey) (*statefile.File, error) {
+	return nil, b.err
+}
+
+func (b *brokenBackend) Save(ctx context.Context, key StateKey, state *statefile.File) error {
+	return b.err
+}
+
+func (b *brokenBackend) Lock(ctx context.Context, key StateKey, info *LockInfo) (func() error, error) {
+	return nil, b.err
+}
+
+func (b *brokenBackend) LockInfo(ctx context.Context, key StateKey) (*LockInfo, error) {
+	return nil, b.err
+}
+
+func (b *brokenBackend) List(ctx context.Context) ([]StateKey, error) {
+	return nil, b.err
+}
+
+func (b *brokenBackend) LoadMetadata(ctx context.Context, key StateKey) (*Metadata, error) {
+	return nil, b.err
+}
+
+func (b *brokenBackend) SaveMetadata(ctx context.Context, key StateKey, md *Metadata) error {
+	return b.err
+}
+
+// newStateBackend creates the state backend described by the given configuration.
+// It returns nil if no remote backend is configured, so that the operator uses its data directory.
+func newStateBackend(cfg *types.StateBackend) StateBackend {
+	var b StateBackend
+	var err error
+	switch {
+	case cfg == nil:
+		return nil
+	case cfg.Kubernetes != nil && cfg.S3 != nil:
+		err = errors.New("only one state backend can be configured")
+	case cfg.Kubernetes != nil:
+		b, err = newKubernetesBackendFromConfig(cfg.Kubernetes)
+	case cfg.S3 != nil:
+		b, err = newS3BackendFromConfig(cfg.S3)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return &brokenBackend{err: errors.Wrap(err, "could not create the state backend")}
+	}
+	return b
+}
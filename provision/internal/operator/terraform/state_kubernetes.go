@@ -0,0 +1,256 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	defaultStateNamespace = "default"
+	// keys in the data of the Secrets
+	stateSecretKey    = "tfstate"
+	metadataSecretKey = "metadata"
+	lockSecretKey     = "lockinfo"
+
+	managedByLabel     = "app.kubernetes.io/managed-by"
+	stateKeyAnnotation = "hydroform.kyma-project.io/state-key"
+
+	// maxSecretNamePrefix leaves room for the hash and the lock suffix in the names of the Secrets
+	maxSecretNamePrefix = 253 - len("-0123456789abcdef") - len("-lock")
+)
+
+var invalidSecretNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// kubernetesBackend stores the state of each cluster in a Secret.
+// The lock is a second Secret, which can only be created by one operation at a time.
+type kubernetesBackend struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func newKubernetesBackendFromConfig(cfg *types.KubernetesStateBackend) (*kubernetesBackend, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", cfg.KubeconfigPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load the kubeconfig of the state backend")
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKubernetesBackend(client, cfg.Namespace), nil
+}
+
+func newKubernetesBackend(client kubernetes.Interface, namespace string) *kubernetesBackend {
+	if namespace == "" {
+		namespace = defaultStateNamespace
+	}
+	return &kubernetesBackend{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Load reads the state from the Secret of the cluster.
+func (b *kubernetesBackend) Load(ctx context.Context, key StateKey) (*statefile.File, error) {
+	secret, err := b.client.CoreV1().Secrets(b.namespace).Get(ctx, stateSecretName(key), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the state secret")
+	}
+	// the secret might only hold the metadata of the cluster yet
+	if len(secret.Data[stateSecretKey]) == 0 {
+		return nil, nil
+	}
+
+	return statefile.Read(bytes.NewReader(secret.Data[stateSecretKey]))
+}
+
+// Save writes the state into the Secret of the cluster, creating it if needed.
+func (b *kubernetesBackend) Save(ctx context.Context, key StateKey, state *statefile.File) error {
+	buf := &bytes.Buffer{}
+	if err := statefile.Write(state, buf); err != nil {
+		return err
+	}
+
+	return b.saveData(ctx, key, stateSecretKey, buf.Bytes())
+}
+
+// List returns the keys of the clusters whose state Secret holds metadata.
+func (b *kubernetesBackend) List(ctx context.Context) ([]StateKey, error) {
+	secrets, err := b.client.CoreV1().Secrets(b.namespace).List(ctx, metav1.ListOptions{LabelSelector: managedByLabel + "=hydroform"})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list the state secrets")
+	}
+
+	keys := []StateKey{}
+	for _, secret := range secrets.Items {
+		if len(secret.Data[metadataSecretKey]) == 0 {
+			continue
+		}
+		parts := strings.Split(secret.Annotations[stateKeyAnnotation], "/")
+		switch len(parts) {
+		case 3:
+			keys = append(keys, StateKey{Provider: types.ProviderType(parts[0]), Project: parts[1], Cluster: parts[2]})
+		case 2:
+			// keys without project
+			keys = append(keys, StateKey{Provider: types.ProviderType(parts[0]), Cluster: parts[1]})
+		}
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// LoadMetadata reads the metadata from the state Secret of the cluster.
+func (b *kubernetesBackend) LoadMetadata(ctx context.Context, key StateKey) (*Metadata, error) {
+	secret, err := b.client.CoreV1().Secrets(b.namespace).Get(ctx, stateSecretName(key), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the state secret")
+	}
+	if len(secret.Data[metadataSecretKey]) == 0 {
+		return nil, nil
+	}
+
+	return decodeMetadata(secret.Data[metadataSecretKey])
+}
+
+// SaveMetadata writes the metadata into the state Secret of the cluster, creating it if needed, or removes it if the metadata is nil.
+func (b *kubernetesBackend) SaveMetadata(ctx context.Context, key StateKey, md *Metadata) error {
+	if md == nil {
+		return b.saveData(ctx, key, metadataSecretKey, nil)
+	}
+
+	data, err := encodeMetadata(md)
+	if err != nil {
+		return err
+	}
+	return b.saveData(ctx, key, metadataSecretKey, data)
+}
+
+// saveData sets the given key in the data of the state Secret of the cluster, creating the Secret if needed. Nil data removes the key.
+func (b *kubernetesBackend) saveData(ctx context.Context, key StateKey, dataKey string, data []byte) error {
+	secrets := b.client.CoreV1().Secrets(b.namespace)
+	secret, err := secrets.Get(ctx, stateSecretName(key), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		if data == nil {
+			return nil
+		}
+		secret = b.secret(stateSecretName(key), key)
+		secret.Data[dataKey] = data
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return errors.Wrap(err, "could not create the state secret")
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not get the state secret")
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	if data == nil {
+		delete(secret.Data, dataKey)
+	} else {
+		secret.Data[dataKey] = data
+	}
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return errors.Wrap(err, "could not update the state secret")
+}
+
+// Lock creates the lock Secret of the cluster, which fails if another operation already created it.
+func (b *kubernetesBackend) Lock(ctx context.Context, key StateKey, info *LockInfo) (func() error, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := b.client.CoreV1().Secrets(b.namespace)
+	lock := b.secret(lockSecretName(key), key)
+	lock.Data[lockSecretKey] = data
+
+	_, err = secrets.Create(ctx, lock, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		current, _ := b.LockInfo(ctx, key)
+		return nil, &LockedError{Key: key, Info: current, Location: fmt.Sprintf("the Secret %s/%s", b.namespace, lock.Name)}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the lock secret")
+	}
+
+	return func() error {
+		// the lock has to be released even if the operation was canceled
+		err := secrets.Delete(context.Background(), lock.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrap(err, "could not delete the lock secret")
+		}
+		return nil
+	}, nil
+}
+
+// LockInfo reads the lock Secret of the cluster.
+func (b *kubernetesBackend) LockInfo(ctx context.Context, key StateKey) (*LockInfo, error) {
+	lock, err := b.client.CoreV1().Secrets(b.namespace).Get(ctx, lockSecretName(key), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the lock secret")
+	}
+
+	info := &LockInfo{}
+	return info, json.Unmarshal(lock.Data[lockSecretKey], info)
+}
+
+// secret returns an empty Secret with the given name, labeled with the cluster it belongs to.
+func (b *kubernetesBackend) secret(name string, key StateKey) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.namespace,
+			Labels: map[string]string{
+				managedByLabel: "hydroform",
+			},
+			Annotations: map[string]string{
+				stateKeyAnnotation: key.String(),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{},
+	}
+}
+
+// stateSecretName turns the key into a valid Secret name: a readable prefix from the key, and a hash of the whole key that keeps the names of different keys apart,
+// even if their prefixes are the same after replacing invalid characters or truncating. The key itself is in the annotations of the Secret.
+func stateSecretName(key StateKey) string {
+	prefix := strings.ToLower(strings.Join([]string{"hydroform", string(key.Provider), key.Project, key.Cluster}, "-"))
+	prefix = strings.Trim(invalidSecretNameChars.ReplaceAllString(prefix, "-"), "-")
+	// leave room for the hash and the lock suffix within the 253 characters allowed
+	if len(prefix) > maxSecretNamePrefix {
+		prefix = strings.TrimRight(prefix[:maxSecretNamePrefix], "-")
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{string(key.Provider), key.Project, key.Cluster}, "\x00")))
+	return fmt.Sprintf("%s-%x", prefix, hash[:8])
+}
+
+func lockSecretName(key StateKey) string {
+	return stateSecretName(key) + "-lock"
+}
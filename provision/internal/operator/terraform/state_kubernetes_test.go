@@ -0,0 +1,51 @@
+package terraform
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubernetesBackend(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	b := newKubernetesBackend(client, "")
+
+	require.Equal(t, defaultStateNamespace, b.namespace, "The default namespace should be used if not configured")
+	testStateBackend(t, b)
+
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+	secret, err := client.CoreV1().Secrets(defaultStateNamespace).Get(context.Background(), stateSecretName(key), metav1.GetOptions{})
+	require.NoError(t, err, "The state should be stored in a secret")
+	require.Equal(t, key.String(), secret.Annotations["hydroform.kyma-project.io/state-key"])
+	require.NotEmpty(t, secret.Data[stateSecretKey])
+}
+
+func TestKubernetesBackendMetadata(t *testing.T) {
+	t.Parallel()
+	testMetadata(t, newKubernetesBackend(fake.NewSimpleClientset(), "hydroform"))
+}
+
+func TestStateSecretName(t *testing.T) {
+	t.Parallel()
+	require.Regexp(t, "^hydroform-gcp-my-project-my-cluster-[0-9a-f]{16}$", stateSecretName(StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}))
+	require.Regexp(t, "^hydroform-azure-my-project-my-cluster-[0-9a-f]{16}$", stateSecretName(StateKey{Provider: types.Azure, Project: "My_Project", Cluster: "my.cluster"}))
+	require.Equal(t, stateSecretName(StateKey{Provider: types.GCP, Project: "p", Cluster: "c"}), stateSecretName(StateKey{Provider: types.GCP, Project: "p", Cluster: "c"}), "The name should be stable")
+
+	// keys with the same readable prefix
+	require.NotEqual(t,
+		stateSecretName(StateKey{Provider: types.GCP, Project: "a-b", Cluster: "c"}),
+		stateSecretName(StateKey{Provider: types.GCP, Project: "a", Cluster: "b-c"}))
+	require.NotEqual(t,
+		stateSecretName(StateKey{Provider: types.Azure, Project: "My_Project", Cluster: "c"}),
+		stateSecretName(StateKey{Provider: types.Azure, Project: "my-project", Cluster: "c"}))
+
+	long := stateSecretName(StateKey{Provider: types.GCP, Project: "my-project", Cluster: strings.Repeat("a", 300)})
+	require.LessOrEqual(t, len(long+"-lock"), 253, "Secret names should leave room for the lock suffix")
+	require.NotEqual(t, long, stateSecretName(StateKey{Provider: types.GCP, Project: "my-project", Cluster: strings.Repeat("a", 301)}), "Truncated names should differ")
+}
@@ -0,0 +1,255 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+// attributes of the items in the DynamoDB lock table
+const (
+	lockIDAttribute   = "LockID"
+	lockInfoAttribute = "Info"
+)
+
+// s3Backend stores the state of each cluster as an object in an S3 bucket.
+// The state is locked with an item in a DynamoDB table, the same way the terraform S3 backend does.
+type s3Backend struct {
+	s3        s3iface.S3API
+	dynamoDB  dynamodbiface.DynamoDBAPI
+	bucket    string
+	prefix    string
+	lockTable string
+}
+
+func newS3BackendFromConfig(cfg *types.S3StateBackend) (*s3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("the bucket of the S3 state backend cannot be empty")
+	}
+	if cfg.LockTable == "" && !cfg.Unlocked {
+		return nil, errors.New("the S3 state backend needs a lock table to lock the state, set Unlocked to store the state without locking it")
+	}
+
+	awsCfg := aws.NewConfig().WithRegion(cfg.Region)
+	if cfg.Endpoint != "" {
+		// S3-compatible storages rarely support virtual hosted buckets
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint).WithS3ForcePathStyle(true)
+	}
+	if cfg.CredentialsFilePath != "" {
+		awsCfg = awsCfg.WithCredentials(credentials.NewSharedCredentials(cfg.CredentialsFilePath, cfg.Profile))
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:  *awsCfg,
+		Profile: cfg.Profile,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the AWS session of the state backend")
+	}
+
+	b := &s3Backend{
+		s3:        s3.New(sess),
+		bucket:    cfg.Bucket,
+		prefix:    cfg.Prefix,
+		lockTable: cfg.LockTable,
+	}
+	if cfg.LockTable != "" {
+		b.dynamoDB = dynamodb.New(sess)
+	}
+	return b, nil
+}
+
+// Load reads the state from the object of the cluster.
+func (b *s3Backend) Load(ctx context.Context, key StateKey) (*statefile.File, error) {
+	out, err := b.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the state object")
+	}
+	defer out.Body.Close()
+
+	return statefile.Read(out.Body)
+}
+
+// Save writes the state into the object of the cluster.
+func (b *s3Backend) Save(ctx context.Context, key StateKey, state *statefile.File) error {
+	buf := &bytes.Buffer{}
+	if err := statefile.Write(state, buf); err != nil {
+		return err
+	}
+
+	_, err := b.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(b.objectKey(key)),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/json"),
+	})
+	return errors.Wrap(err, "could not put the state object")
+}
+
+// List returns the keys of the clusters with a metadata object under the prefix.
+func (b *s3Backend) List(ctx context.Context) ([]StateKey, error) {
+	prefix := b.prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	keys := []StateKey{}
+	err := b.s3.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	}, func(out *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range out.Contents {
+			parts := strings.Split(strings.TrimPrefix(aws.StringValue(o.Key), prefix), "/")
+			if len(parts) == 4 && parts[3] == metadataFile {
+				keys = append(keys, StateKey{Provider: types.ProviderType(parts[0]), Project: parts[1], Cluster: parts[2]})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list the metadata objects")
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// LoadMetadata reads the metadata object of the cluster.
+func (b *s3Backend) LoadMetadata(ctx context.Context, key StateKey) (*Metadata, error) {
+	out, err := b.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.metadataKey(key)),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the metadata object")
+	}
+	defer out.Body.Close()
+
+	data, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the metadata object")
+	}
+	return decodeMetadata(data)
+}
+
+// SaveMetadata writes the metadata object of the cluster, or deletes it if the metadata is nil.
+func (b *s3Backend) SaveMetadata(ctx context.Context, key StateKey, md *Metadata) error {
+	if md == nil {
+		_, err := b.s3.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(b.metadataKey(key)),
+		})
+		return errors.Wrap(err, "could not delete the metadata object")
+	}
+
+	data, err := encodeMetadata(md)
+	if err != nil {
+		return err
+	}
+	_, err = b.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(b.metadataKey(key)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return errors.Wrap(err, "could not put the metadata object")
+}
+
+// Lock puts the lock item of the cluster into the lock table, which fails if another operation already put it.
+// Without a lock table, which requires the backend to be configured as unlocked, Lock does nothing.
+func (b *s3Backend) Lock(ctx context.Context, key StateKey, info *LockInfo) (func() error, error) {
+	if b.lockTable == "" {
+		return func() error { return nil }, nil
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+
+	lockID := b.lockID(key)
+	_, err = b.dynamoDB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(b.lockTable),
+		Item: map[string]*dynamodb.AttributeValue{
+			lockIDAttribute:   {S: aws.String(lockID)},
+			lockInfoAttribute: {S: aws.String(string(data))},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(LockID)"),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		current, _ := b.LockInfo(ctx, key)
+		return nil, &LockedError{Key: key, Info: current, Location: fmt.Sprintf("the item %s of the DynamoDB table %s", lockID, b.lockTable)}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not put the lock item")
+	}
+
+	return func() error {
+		// the lock has to be released even if the operation was canceled
+		_, err := b.dynamoDB.DeleteItemWithContext(context.Background(), &dynamodb.DeleteItemInput{
+			TableName: aws.String(b.lockTable),
+			Key:       map[string]*dynamodb.AttributeValue{lockIDAttribute: {S: aws.String(lockID)}},
+		})
+		return errors.Wrap(err, "could not delete the lock item")
+	}, nil
+}
+
+// LockInfo reads the lock item of the cluster from the lock table.
+// Without a lock table, the state is never locked.
+func (b *s3Backend) LockInfo(ctx context.Context, key StateKey) (*LockInfo, error) {
+	if b.lockTable == "" {
+		return nil, nil
+	}
+
+	out, err := b.dynamoDB.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(b.lockTable),
+		Key:            map[string]*dynamodb.AttributeValue{lockIDAttribute: {S: aws.String(b.lockID(key))}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the lock item")
+	}
+	v, ok := out.Item[lockInfoAttribute]
+	if !ok || v.S == nil {
+		return nil, nil
+	}
+
+	info := &LockInfo{}
+	return info, json.Unmarshal([]byte(*v.S), info)
+}
+
+func (b *s3Backend) lockID(key StateKey) string {
+	return path.Join(b.bucket, b.objectKey(key))
+}
+
+func (b *s3Backend) objectKey(key StateKey) string {
+	return path.Join(b.prefix, key.String(), tfStateFile)
+}
+
+func (b *s3Backend) metadataKey(key StateKey) string {
+	return path.Join(b.prefix, key.String(), metadataFile)
+}
@@ -0,0 +1,135 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3Backend(t *testing.T) {
+	t.Parallel()
+	objects := &fakeS3{objects: map[string][]byte{}}
+	b := &s3Backend{
+		s3:        objects,
+		dynamoDB:  &fakeDynamoDB{items: map[string]map[string]*dynamodb.AttributeValue{}},
+		bucket:    "my-bucket",
+		prefix:    "states",
+		lockTable: "locks",
+	}
+
+	testStateBackend(t, b)
+	require.Contains(t, objects.objects, "my-bucket/states/gcp/my-project/my-cluster/terraform.tfstate", "The state should be stored under the prefix")
+}
+
+func TestS3BackendMetadata(t *testing.T) {
+	t.Parallel()
+	objects := &fakeS3{objects: map[string][]byte{"my-bucket/unrelated.json": nil, "other-bucket/states/gcp/p/c/.hydroform.json": nil}}
+	testMetadata(t, &s3Backend{s3: objects, bucket: "my-bucket", prefix: "states"})
+	require.Contains(t, objects.objects, "my-bucket/states/gcp/my-project/my-cluster/.hydroform.json", "The metadata should be stored next to the state")
+}
+
+func TestS3BackendWithoutLockTable(t *testing.T) {
+	t.Parallel()
+	b := &s3Backend{
+		s3:     &fakeS3{objects: map[string][]byte{}},
+		bucket: "my-bucket",
+	}
+
+	unlock, err := b.Lock(context.Background(), StateKey{}, newLockInfo("create"))
+	require.NoError(t, err)
+	_, err = b.Lock(context.Background(), StateKey{}, newLockInfo("create"))
+	require.NoError(t, err, "The state should not be locked without a lock table")
+	require.NoError(t, unlock())
+}
+
+// fakeS3 keeps the objects in memory, indexed by bucket and key.
+type fakeS3 struct {
+	s3iface.S3API
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) GetObjectWithContext(_ aws.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[*in.Bucket+"/"+*in.Key]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) PutObjectWithContext(_ aws.Context, in *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := ioutil.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*in.Bucket+"/"+*in.Key] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObjectWithContext(_ aws.Context, in *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, *in.Bucket+"/"+*in.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// ListObjectsV2PagesWithContext returns all objects of the bucket with the prefix in a single page.
+func (f *fakeS3) ListObjectsV2PagesWithContext(_ aws.Context, in *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+	f.mu.Lock()
+	out := &s3.ListObjectsV2Output{}
+	for k := range f.objects {
+		if strings.HasPrefix(k, *in.Bucket+"/"+aws.StringValue(in.Prefix)) {
+			out.Contents = append(out.Contents, &s3.Object{Key: aws.String(strings.TrimPrefix(k, *in.Bucket+"/"))})
+		}
+	}
+	f.mu.Unlock()
+	fn(out, true)
+	return nil
+}
+
+// fakeDynamoDB keeps the items of a single table in memory, indexed by LockID.
+type fakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	mu    sync.Mutex
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (f *fakeDynamoDB) PutItemWithContext(_ aws.Context, in *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := *in.Item[lockIDAttribute].S
+	if _, ok := f.items[id]; ok && in.ConditionExpression != nil {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "condition failed", nil)
+	}
+	f.items[id] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) GetItemWithContext(_ aws.Context, in *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &dynamodb.GetItemOutput{Item: f.items[*in.Key[lockIDAttribute].S]}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItemWithContext(_ aws.Context, in *dynamodb.DeleteItemInput, _ ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, *in.Key[lockIDAttribute].S)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
@@ -0,0 +1,294 @@
+package terraform
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStateKey(t *testing.T) {
+	t.Parallel()
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+
+	require.Equal(t, "gcp/my-project/my-cluster", key.String())
+}
+
+func TestFileBackend(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	testStateBackend(t, &fileBackend{dataDir: dataDir})
+
+	metadataDir, err := ioutil.TempDir("", "hydroform-metadata")
+	require.NoError(t, err)
+	defer os.RemoveAll(metadataDir)
+	testMetadata(t, &fileBackend{dataDir: metadataDir})
+}
+
+func TestNewStateBackend(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, newStateBackend(nil), "No configuration should keep the state in the data directory")
+	require.Nil(t, newStateBackend(&types.StateBackend{}), "An empty configuration should keep the state in the data directory")
+
+	b := newStateBackend(&types.StateBackend{
+		Kubernetes: &types.KubernetesStateBackend{},
+		S3:         &types.S3StateBackend{},
+	})
+	require.IsType(t, &brokenBackend{}, b, "Configuring several backends should fail")
+	_, err := b.Load(context.Background(), StateKey{})
+	require.Error(t, err, "A broken backend should fail on each use")
+
+	b = newStateBackend(&types.StateBackend{S3: &types.S3StateBackend{}})
+	require.IsType(t, &brokenBackend{}, b, "An S3 backend without bucket should fail")
+
+	b = newStateBackend(&types.StateBackend{S3: &types.S3StateBackend{Bucket: "my-bucket", Region: "eu-central-1"}})
+	require.IsType(t, &brokenBackend{}, b, "An S3 backend without lock table should fail")
+	_, err = b.Lock(context.Background(), StateKey{}, newLockInfo("create"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "needs a lock table")
+
+	b = newStateBackend(&types.StateBackend{S3: &types.S3StateBackend{Bucket: "my-bucket", Region: "eu-central-1", LockTable: "locks"}})
+	require.IsType(t, &s3Backend{}, b)
+
+	b = newStateBackend(&types.StateBackend{S3: &types.S3StateBackend{Bucket: "my-bucket", Region: "eu-central-1", Unlocked: true}})
+	require.IsType(t, &s3Backend{}, b, "An S3 backend configured as unlocked does not need a lock table")
+}
+
+func TestPullAndPushState(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+	remoteDir, err := ioutil.TempDir("", "hydroform-remote-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(remoteDir)
+
+	remote := &fileBackend{dataDir: remoteDir}
+	tf := &Terraform{ops: options(WithDataDir(dataDir), WithStateBackend(remote))}
+	key := StateKey{Provider: types.Kind, Project: "my-project", Cluster: "my-cluster"}
+
+	found, err := tf.pullState(context.Background(), nil, key)
+	require.NoError(t, err)
+	require.False(t, found, "There should be no state yet")
+
+	require.NoError(t, tf.pushState(key), "Pushing without a state file should do nothing")
+	sf, err := remote.Load(context.Background(), key)
+	require.NoError(t, err)
+	require.Nil(t, sf)
+
+	// the given state is written into the cluster directory and pushed to the backend
+	state := statefile.New(states.NewState(), "lineage", 3)
+	found, err = tf.pullState(context.Background(), state, key)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.NoError(t, tf.pushState(key))
+
+	sf, err = remote.Load(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, "lineage", sf.Lineage)
+	require.Equal(t, uint64(3), sf.Serial)
+
+	// without a given state, the one in the backend is used
+	require.NoError(t, os.RemoveAll(dataDir))
+	found, err = tf.pullState(context.Background(), nil, key)
+	require.NoError(t, err)
+	require.True(t, found, "The state should be loaded from the backend")
+	sf, err = stateFromFile(dataDir, key.Project, key.Cluster, key.Provider)
+	require.NoError(t, err)
+	require.Equal(t, "lineage", sf.Lineage)
+}
+
+func TestClusterInfo(t *testing.T) {
+	t.Parallel()
+	remoteDir, err := ioutil.TempDir("", "hydroform-remote-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(remoteDir)
+
+	remote := &fileBackend{dataDir: remoteDir}
+	tf := &Terraform{ops: options(WithStateBackend(remote))}
+	cfg := map[string]interface{}{"project": "my-project", "cluster_name": "my-cluster"}
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+
+	_, err = tf.ClusterInfo(context.Background(), types.GCP, cfg)
+	require.Error(t, err, "There should be no cluster info without a state")
+
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetOutputValue(addrs.OutputValue{Name: "endpoint"}.Absolute(addrs.RootModuleInstance), cty.StringVal("10.0.0.1"), false)
+		s.SetOutputValue(addrs.OutputValue{Name: "cluster_ca_certificate"}.Absolute(addrs.RootModuleInstance), cty.StringVal("Y2E="), false)
+	})
+	require.NoError(t, remote.Save(context.Background(), key, statefile.New(state, "lineage", 1)))
+
+	info, err := tf.ClusterInfo(context.Background(), types.GCP, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", info.Endpoint)
+	require.Equal(t, []byte("ca"), info.CertificateAuthorityData)
+	require.Equal(t, "lineage", info.InternalState.TerraformState.Lineage, "The state should be part of the cluster info")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tf.ClusterInfo(ctx, types.GCP, cfg)
+	require.Equal(t, context.Canceled, err)
+}
+
+// testStateBackend checks the behavior every state backend must have.
+func testStateBackend(t *testing.T, b StateBackend) {
+	ctx := context.Background()
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+
+	sf, err := b.Load(ctx, key)
+	require.NoError(t, err, "Loading a missing state should not fail")
+	require.Nil(t, sf, "There should be no state before saving one")
+
+	require.NoError(t, b.Save(ctx, key, statefile.New(states.NewState(), "lineage", 1)))
+	require.NoError(t, b.Save(ctx, key, statefile.New(states.NewState(), "lineage", 2)), "Saving should replace the state")
+
+	sf, err = b.Load(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "lineage", sf.Lineage)
+	require.Equal(t, uint64(2), sf.Serial)
+
+	other := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "other-cluster"}
+	sf, err = b.Load(ctx, other)
+	require.NoError(t, err)
+	require.Nil(t, sf, "States of different clusters should be separated")
+
+	info, err := b.LockInfo(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, info, "The state should not be locked yet")
+
+	unlock, err := b.Lock(ctx, key, newLockInfo("create"))
+	require.NoError(t, err)
+
+	info, err = b.LockInfo(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, info, "The state should be locked")
+	require.Equal(t, "create", info.Operation)
+
+	_, err = b.Lock(ctx, key, newLockInfo("delete"))
+	require.Error(t, err, "Locking a locked state should fail")
+	lockErr, ok := err.(*LockedError)
+	require.True(t, ok, "The error should tell the state is locked")
+	require.NotNil(t, lockErr.Info, "The error should describe the holder of the lock")
+	require.Equal(t, "create", lockErr.Info.Operation)
+	require.NotEmpty(t, lockErr.Location, "The error should tell where the lock is")
+	require.Contains(t, err.Error(), "delete "+lockErr.Location+" to unlock it", "The error should tell how to remove a stale lock")
+
+	unlockOther, err := b.Lock(ctx, other, newLockInfo("update"))
+	require.NoError(t, err, "Locking the state of another cluster should succeed")
+	require.NoError(t, unlockOther())
+
+	require.NoError(t, unlock())
+	info, err = b.LockInfo(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, info, "The state should be unlocked")
+
+	unlock, err = b.Lock(ctx, key, newLockInfo("delete"))
+	require.NoError(t, err, "Locking an unlocked state should succeed")
+	require.NoError(t, unlock())
+}
+
+// testMetadata checks how every state backend stores the metadata of clusters.
+func testMetadata(t *testing.T, b StateBackend) {
+	ctx := context.Background()
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+	other := StateKey{Provider: types.Kind, Project: "my-project", Cluster: "other-cluster"}
+
+	keys, err := b.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, keys, "There should be no clusters with metadata yet")
+	md, err := b.LoadMetadata(ctx, key)
+	require.NoError(t, err, "Loading missing metadata should not fail")
+	require.Nil(t, md)
+
+	expiry := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, b.SaveMetadata(ctx, other, &Metadata{Config: map[string]interface{}{"cluster_name": "other-cluster"}}))
+	require.NoError(t, b.SaveMetadata(ctx, key, &Metadata{ExpiresAt: expiry, Config: map[string]interface{}{
+		"cluster_name": "my-cluster",
+		"node_count":   3,
+		"zones":        []string{"a", "b"},
+	}}))
+
+	keys, err = b.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []StateKey{key, other}, keys, "The clusters with metadata should be listed in order")
+
+	md, err = b.LoadMetadata(ctx, key)
+	require.NoError(t, err)
+	require.True(t, expiry.Equal(md.ExpiresAt))
+	require.Equal(t, map[string]interface{}{"cluster_name": "my-cluster", "node_count": 3, "zones": []string{"a", "b"}}, md.Config)
+
+	sf, err := b.Load(ctx, other)
+	require.NoError(t, err, "Metadata without a state should not break loading the state")
+	require.Nil(t, sf)
+
+	require.NoError(t, b.SaveMetadata(ctx, other, nil))
+	require.NoError(t, b.SaveMetadata(ctx, other, nil), "Removing missing metadata should not fail")
+	keys, err = b.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []StateKey{key}, keys, "Clusters without metadata should not be listed")
+}
+
+func TestStatusFromLock(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	tf := &Terraform{ops: options(WithDataDir(dataDir))}
+	cfg := map[string]interface{}{"project": "my-project", "cluster_name": "my-cluster"}
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+	sf := statefile.New(states.NewState(), "lineage", 1)
+
+	cs, err := tf.Status(context.Background(), sf, types.GCP, cfg)
+	require.NoError(t, err)
+	require.Equal(t, types.Unknown, cs.Phase, "A state without resources should have an unknown phase")
+
+	for op, phase := range map[string]types.Phase{"create": types.Provisioning, "update": types.Provisioning, "import": types.Provisioning, "delete": types.Deleting} {
+		unlock, err := tf.stateBackend().Lock(context.Background(), key, newLockInfo(op))
+		require.NoError(t, err)
+
+		cs, err = tf.Status(context.Background(), sf, types.GCP, cfg)
+		require.NoError(t, err)
+		require.Equal(t, phase, cs.Phase, "The phase should follow the running %s operation", op)
+		require.NoError(t, unlock())
+	}
+}
+
+func TestImportManagedCluster(t *testing.T) {
+	t.Parallel()
+	dataDir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	tf := &Terraform{ops: options(WithDataDir(dataDir))}
+	cfg := map[string]interface{}{"project": "my-project", "cluster_name": "my-cluster"}
+	key := StateKey{Provider: types.GCP, Project: "my-project", Cluster: "my-cluster"}
+
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetResourceInstanceCurrent(
+			addrs.Resource{Mode: addrs.ManagedResourceMode, Type: "google_container_cluster", Name: "gke_cluster"}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+			&states.ResourceInstanceObjectSrc{Status: states.ObjectReady, AttrsJSON: []byte("{}")},
+			addrs.ProviderConfig{Type: addrs.NewLegacyProvider("google")}.Absolute(addrs.RootModuleInstance),
+		)
+	})
+	require.NoError(t, tf.stateBackend().Save(context.Background(), key, statefile.New(state, "lineage", 1)))
+
+	_, err = tf.Import(context.Background(), types.GCP, cfg)
+	require.Error(t, err, "A cluster with a state should not be imported again")
+	require.Contains(t, err.Error(), "managed already")
+
+	info, err := tf.stateBackend().LockInfo(context.Background(), key)
+	require.NoError(t, err)
+	require.Nil(t, info, "Import should release the lock")
+}
@@ -1,15 +1,18 @@
 package terraform
 
 import (
+	"context"
 	"fmt"
 	be_init "github.com/hashicorp/terraform/backend/init"
 	"github.com/hashicorp/terraform/command"
+	"github.com/hashicorp/terraform/plans"
 	"github.com/kyma-incubator/hydroform/provision/types"
 	hashiCli "github.com/mitchellh/cli"
 	"github.com/pkg/errors"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -18,7 +21,9 @@ import (
 // If the given dir is not empty, no modules will be downloaded and init will assume there is a valid module in dir.
 func tfInit(ops Options, p types.ProviderType, cfg map[string]interface{}, dir string) error {
 	// need to init all backends before we start
+	backendsMu.Lock()
 	be_init.Init(ops.Services)
+	backendsMu.Unlock()
 	i := &command.InitCommand{
 		Meta: ops.Meta,
 	}
@@ -29,29 +34,54 @@ func tfInit(ops Options, p types.ProviderType, cfg map[string]interface{}, dir s
 		os.Setenv(command.ProviderSkipVerifyEnvVar, "1")
 	}
 
-	if e := i.Run(initArgs(p, cfg, dir)); e != 0 {
+	pluginDirs, err := ops.pluginDirs()
+	if err != nil {
+		return err
+	}
+
+	if e := i.Run(initArgs(ops.Ui, ops.moduleSource(p), pluginDirs, dir)); e != 0 {
+		// terraform does not tell which versions of the missing providers the lock file of the mirror pins
+		if _, err := ops.pinProviders(dir); err != nil {
+			return err
+		}
+		return checkUIErrors(ops.Ui)
+	}
+
+	// terraform records the plugins it chose during init, which did not know the pinned versions of the providers of a downloaded module yet
+	pinned, err := ops.pinProviders(dir)
+	if err != nil || !pinned {
+		return err
+	}
+	i = &command.InitCommand{
+		Meta: ops.Meta,
+	}
+	if e := i.Run(initArgs(ops.Ui, "", pluginDirs, dir)); e != 0 {
 		return checkUIErrors(ops.Ui)
 	}
 	return nil
 }
 
-// initArgs generates the flag list for the terraform init command based on the operator configuration
-func initArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir string) []string {
+// initArgs generates the flag list for the terraform init command, downloading the given module source into an empty cluster directory.
+// If plugin directories are given, terraform uses the provider plugins in them instead of downloading any.
+func initArgs(ui hashiCli.Ui, source string, pluginDirs []string, clusterDir string) []string {
 	args := make([]string, 0)
 
 	empty, err := isEmptyDir(clusterDir)
 	if err != nil {
-		fmt.Printf("Could not verify if the cluster directory is empty: %s.\nAttempting initialisation without downloading modules.", err)
+		ui.Warn(fmt.Sprintf("Could not verify if the cluster directory is empty: %s.\nAttempting initialisation without downloading modules.", err))
 	}
 
 	// Only download module if the directory is empty, otherwise we might
 	// already have a valid module config from a previous persistent operation.
 	if empty {
 		// TODO remove this condition when fully migrated to modules
-		if m := tfMod(p); m != "" {
-			args = append(args, fmt.Sprintf("-from-module=%s", m))
+		if source != "" {
+			args = append(args, fmt.Sprintf("-from-module=%s", source))
 		}
 	}
+	for _, d := range pluginDirs {
+		args = append(args, fmt.Sprintf("-plugin-dir=%s", d))
+	}
 	if runtime.GOOS == "windows" { // remove '\\?\' path prefix
 		clusterDir = clusterDir[4:]
 	}
@@ -86,32 +116,24 @@ func tfMod(p types.ProviderType) string {
 //   refresh the local state.
 // - if failed with error "not found" => probably state is corrupt => delete
 //   the state and start over with apply.
-func tfApply(ops Options, p types.ProviderType, cfg map[string]interface{}, dir string) error {
+// None of the above is attempted if ctx is done, terraform was interrupted in that case.
+func tfApply(ctx context.Context, ops Options, p types.ProviderType, cfg map[string]interface{}, dir string) error {
 	a := &command.ApplyCommand{
 		Meta: ops.Meta,
 	}
 	e := a.Run(applyArgs(p, cfg, dir))
 	if e != 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		errList := checkUIErrors(ops.Ui)
+		if errList == nil {
+			return errors.New("terraform apply failed without reporting any error")
+		}
 
 		// if cluster already exists import it and refresh the state
 		if strings.Contains(strings.ToLower(errList.Error()), "already exists") {
-			i := &command.ImportCommand{
-				Meta: ops.Meta,
-			}
-
-			if e := i.Run(importArgs(p, cfg, dir)); e != 0 {
-				return checkUIErrors(ops.Ui)
-			}
-
-			r := &command.RefreshCommand{
-				Meta: ops.Meta,
-			}
-
-			if e := r.Run(refreshArgs(p, cfg, dir)); e != 0 {
-				return checkUIErrors(ops.Ui)
-			}
-			return nil
+			return tfImport(ops, p, cfg, dir)
 		}
 
 		// if cluster was not found, cluster got deeted on the remote or state is wrong, delete state and start over
@@ -123,7 +145,7 @@ func tfApply(ops Options, p types.ProviderType, cfg map[string]interface{}, dir
 			}
 
 			// try applying again
-			if err := tfApply(ops, p, cfg, dir); err != nil {
+			if err := tfApply(ctx, ops, p, cfg, dir); err != nil {
 				return errors.Wrap(err, errList.Error())
 			} else {
 				return nil
@@ -134,6 +156,36 @@ func tfApply(ops Options, p types.ProviderType, cfg map[string]interface{}, dir
 	return nil
 }
 
+// tfImport runs the 'terraform import' command for each resource returned by importResources and refreshes the imported state,
+// with the specified options and config in the given working directory.
+func tfImport(ops Options, p types.ProviderType, cfg map[string]interface{}, dir string) error {
+	resources := importResources(p, cfg)
+	addresses := make([]string, 0, len(resources))
+	for addr := range resources {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+
+	for _, addr := range addresses {
+		i := &command.ImportCommand{
+			Meta: ops.Meta,
+		}
+
+		if e := i.Run(importArgs(dir, addr, resources[addr])); e != 0 {
+			return checkUIErrors(ops.Ui)
+		}
+	}
+
+	r := &command.RefreshCommand{
+		Meta: ops.Meta,
+	}
+
+	if e := r.Run(refreshArgs(p, cfg, dir)); e != 0 {
+		return checkUIErrors(ops.Ui)
+	}
+	return nil
+}
+
 // tfDestroy runs the 'terraform destroy' command with the specified options and config in the given working directory
 func tfDestroy(ops Options, p types.ProviderType, cfg map[string]interface{}, dir string) error {
 	a := &command.ApplyCommand{
@@ -146,6 +198,73 @@ func tfDestroy(ops Options, p types.ProviderType, cfg map[string]interface{}, di
 	return nil
 }
 
+// tfPlan runs the 'terraform plan' command with the specified options and config in the given working directory.
+// If destroy is true, the plan describes the destruction of all resources.
+// The plan is saved into the working directory to be inspected and applied later on.
+func tfPlan(ops Options, p types.ProviderType, cfg map[string]interface{}, dir string, destroy bool) error {
+	pc := &command.PlanCommand{
+		Meta: ops.Meta,
+	}
+	if e := pc.Run(planArgs(p, cfg, dir, destroy)); e != 0 {
+		return checkUIErrors(ops.Ui)
+	}
+	return nil
+}
+
+// tfApplyPlan runs the 'terraform apply' command on the plan previously saved in the given working directory by tfPlan.
+func tfApplyPlan(ops Options, dir string) error {
+	a := &command.ApplyCommand{
+		Meta: ops.Meta,
+	}
+	if e := a.Run(applyPlanArgs(dir)); e != 0 {
+		return checkUIErrors(ops.Ui)
+	}
+	return nil
+}
+
+// checkClusterKept returns an error if the given plan destroys or replaces the cluster resource of the given provider.
+// Resources around the cluster, such as node pools, can still be replaced.
+func checkClusterKept(plan *plans.Plan, p types.ProviderType) error {
+	for _, rc := range plan.Changes.Resources {
+		if !rc.Addr.Module.IsRoot() || rc.Addr.Resource.Resource.String() != clusterResource(p) {
+			continue
+		}
+		switch rc.Action {
+		case plans.Delete, plans.DeleteThenCreate, plans.CreateThenDelete:
+			return errors.Errorf("the requested changes require replacing %s, which would destroy the cluster. Deprovision the cluster and provision it again instead", rc.Addr)
+		}
+	}
+	return nil
+}
+
+// planChanges turns a terraform plan into the list of resource changes it contains.
+// Resources without changes and data sources to be read are left out.
+func planChanges(plan *plans.Plan) *types.Plan {
+	res := &types.Plan{Changes: make([]types.ResourceChange, 0)}
+	for _, rc := range plan.Changes.Resources {
+		var a types.ChangeAction
+		switch rc.Action {
+		case plans.Create:
+			a = types.CreateAction
+		case plans.Update:
+			a = types.UpdateAction
+		case plans.DeleteThenCreate, plans.CreateThenDelete:
+			a = types.ReplaceAction
+		case plans.Delete:
+			a = types.DeleteAction
+		default:
+			continue
+		}
+
+		res.Changes = append(res.Changes, types.ResourceChange{
+			Address: rc.Addr.String(),
+			Type:    rc.Addr.Resource.Resource.Type,
+			Action:  a,
+		})
+	}
+	return res
+}
+
 // applyArgs generates the flag list for the terraform apply command based on the operator configuration
 func applyArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir string) []string {
 	args := make([]string, 0)
@@ -162,8 +281,44 @@ func applyArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir stri
 	return args
 }
 
-// importArgs generates the flag list for the terraform import command based on the operator configuration
-func importArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir string) []string {
+// planArgs generates the flag list for the terraform plan command based on the operator configuration
+func planArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir string, destroy bool) []string {
+	args := make([]string, 0)
+
+	stateFile := filepath.Join(clusterDir, tfStateFile)
+	varsFile := filepath.Join(clusterDir, tfVarsFile)
+	planFile := filepath.Join(clusterDir, tfPlanFile)
+
+	args = append(args,
+		fmt.Sprintf("-state=%s", stateFile),
+		fmt.Sprintf("-var-file=%s", varsFile),
+		fmt.Sprintf("-out=%s", planFile),
+		"-input=false")
+	if destroy {
+		args = append(args, "-destroy")
+	}
+	args = append(args, clusterDir)
+
+	return args
+}
+
+// applyPlanArgs generates the flag list for the terraform apply command of a saved plan
+func applyPlanArgs(clusterDir string) []string {
+	args := make([]string, 0)
+
+	stateFile := filepath.Join(clusterDir, tfStateFile)
+	planFile := filepath.Join(clusterDir, tfPlanFile)
+
+	args = append(args,
+		fmt.Sprintf("-state=%s", stateFile),
+		"-auto-approve",
+		planFile)
+
+	return args
+}
+
+// importArgs generates the flag list for the terraform import command of the resource with the given address and ID
+func importArgs(clusterDir, addr, id string) []string {
 	args := make([]string, 0)
 
 	stateFile := filepath.Join(clusterDir, tfStateFile)
@@ -174,12 +329,23 @@ func importArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir str
 		fmt.Sprintf("-state-out=%s", stateFile),
 		fmt.Sprintf("-var-file=%s", varsFile),
 		fmt.Sprintf("-config=%s", clusterDir),
-		clusterResource(p), // cluster resource
-		clusterID(p, cfg))  // cluster ID
+		addr, // resource address
+		id)   // resource ID
 
 	return args
 }
 
+// importResources returns the addresses of the resources Import brings into the state of a cluster of the given provider, mapped to their IDs.
+// Besides the cluster resource, only the resources whose ID follows from the config can be imported.
+func importResources(p types.ProviderType, cfg map[string]interface{}) map[string]string {
+	res := map[string]string{clusterResource(p): clusterID(p, cfg)}
+	if p == types.GCP {
+		// the GCP template names the node pool after the cluster
+		res[gcpNodePoolResource] = fmt.Sprintf("%s/%s/%s/%s-pool", cfg["project"], cfg["location"], cfg["cluster_name"], cfg["cluster_name"])
+	}
+	return res
+}
+
 // clusterResource returns the cluster resource type defined in the terraform module for the given provider.
 func clusterResource(p types.ProviderType) string {
 	switch p {
@@ -190,7 +356,9 @@ func clusterResource(p types.ProviderType) string {
 	case types.Gardener:
 		return "gardener_shoot.gardener_cluster"
 	case types.AWS:
-		return "not supported"
+		return "aws_eks_cluster.eks_cluster"
+	case types.Kind:
+		return "kind.kind-cluster"
 	}
 	return ""
 }
@@ -204,7 +372,11 @@ func clusterID(p types.ProviderType, cfg map[string]interface{}) string {
 	case types.Gardener:
 		return fmt.Sprintf("%s/%s", cfg["namespace"], cfg["cluster_name"])
 	case types.AWS:
-		return "not supported"
+		return fmt.Sprintf("%s", cfg["cluster_name"])
+	case types.Azure:
+		return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s", cfg["subscription_id"], cfg["resource_group"], cfg["cluster_name"])
+	case types.Kind:
+		return fmt.Sprintf("%s", cfg["cluster_name"])
 	}
 	return ""
 }
@@ -225,17 +397,15 @@ func refreshArgs(p types.ProviderType, cfg map[string]interface{}, clusterDir st
 }
 
 func checkUIErrors(ui hashiCli.Ui) error {
-	var errsum strings.Builder
+	tfErr := &types.TerraformError{}
 	if h, ok := ui.(*HydroUI); ok {
 		for _, e := range h.Errors() {
-			if _, err := errsum.WriteString(e.Error()); err != nil {
-				return errors.Wrap(err, "could not fetch errors from terraform")
-			}
+			tfErr.Errors = append(tfErr.Errors, e.Error())
 		}
 	}
 
-	if errsum.Len() != 0 {
-		return errors.New(errsum.String())
+	if len(tfErr.Errors) != 0 {
+		return tfErr
 	}
 
 	return nil
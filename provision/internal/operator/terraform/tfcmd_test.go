@@ -4,20 +4,23 @@ import (
 	"os"
 	"testing"
 
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/plans"
 	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
 func TestInitArgs(t *testing.T) {
 	t.Parallel()
 	// test provider that has no module support
-	res := initArgs("", nil, "/path/to/cluster")
+	res := initArgs(&HydroUI{}, "", nil, "/path/to/cluster")
 
 	require.Len(t, res, 1)
 	require.Equal(t, "/path/to/cluster", res[0]) // cluster config directory
 
 	// test provider that has module but not an empty cluster dir => no modules will be initialized
-	res = initArgs(types.Azure, nil, ".")
+	res = initArgs(&HydroUI{}, tfMod(types.Azure), nil, ".")
 
 	require.Len(t, res, 1)
 	require.Equal(t, ".", res[0]) // cluster config directory
@@ -27,11 +30,14 @@ func TestInitArgs(t *testing.T) {
 	defer os.RemoveAll(".hf-test")
 	require.NoError(t, err)
 
-	res = initArgs(types.Azure, nil, dir)
+	res = initArgs(&HydroUI{}, tfMod(types.Azure), nil, dir)
 	require.Len(t, res, 2)
 	require.Contains(t, res[0], "-from-module")
 	require.Equal(t, res[1], dir) // cluster config directory
 
+	// test provider mirror => plugins are only looked up in the mirror
+	res = initArgs(&HydroUI{}, "", []string{"/mirror", "/mirror/linux_amd64"}, dir)
+	require.Equal(t, []string{"-plugin-dir=/mirror", "-plugin-dir=/mirror/linux_amd64", dir}, res)
 }
 
 func TestApplyArgs(t *testing.T) {
@@ -48,25 +54,144 @@ func TestApplyArgs(t *testing.T) {
 
 func TestImportArgs(t *testing.T) {
 	t.Parallel()
-	cfg := map[string]interface{}{"project": "my-project", "namespace": "my-namespace", "location": "somewhere", "cluster_name": "my-cluster"}
-
-	// test GCP
-	res := importArgs(types.GCP, cfg, "/path/to/cluster")
+	res := importArgs("/path/to/cluster", "google_container_cluster.gke_cluster", "my-project/somewhere/my-cluster")
 	require.Len(t, res, 6)
 	require.Equal(t, "-state=/path/to/cluster/terraform.tfstate", res[0])     // state file
 	require.Equal(t, "-state-out=/path/to/cluster/terraform.tfstate", res[1]) // state output file
 	require.Equal(t, "-var-file=/path/to/cluster/terraform.tfvars", res[2])   // vars file
 	require.Equal(t, "-config=/path/to/cluster", res[3])                      // config folder for import to know where the tf files are (if any)
-	require.Equal(t, "google_container_cluster.gke_cluster", res[4])          // resource type for a GCP cluster
-	require.Equal(t, "my-project/somewhere/my-cluster", res[5])               // cluster ID
+	require.Equal(t, "google_container_cluster.gke_cluster", res[4])          // resource address
+	require.Equal(t, "my-project/somewhere/my-cluster", res[5])               // resource ID
+}
+
+func TestImportResources(t *testing.T) {
+	t.Parallel()
+	cfg := map[string]interface{}{"project": "my-project", "namespace": "my-namespace", "location": "somewhere", "cluster_name": "my-cluster"}
+
+	// test GCP
+	require.Equal(t, map[string]string{
+		"google_container_cluster.gke_cluster":     "my-project/somewhere/my-cluster",
+		"google_container_node_pool.gke_node_pool": "my-project/somewhere/my-cluster/my-cluster-pool",
+	}, importResources(types.GCP, cfg))
 
 	// test Gardener
-	res = importArgs(types.Gardener, cfg, "/path/to/cluster")
+	require.Equal(t, map[string]string{"gardener_shoot.gardener_cluster": "my-namespace/my-cluster"}, importResources(types.Gardener, cfg))
+
+	// test AWS
+	require.Equal(t, map[string]string{"aws_eks_cluster.eks_cluster": "my-cluster"}, importResources(types.AWS, cfg))
+
+	// test Azure
+	cfg["subscription_id"] = "my-subscription"
+	cfg["resource_group"] = "my-group"
+	require.Equal(t, map[string]string{
+		"azurerm_kubernetes_cluster.azure_cluster": "/subscriptions/my-subscription/resourceGroups/my-group/providers/Microsoft.ContainerService/managedClusters/my-cluster",
+	}, importResources(types.Azure, cfg))
+
+	// test kind
+	require.Equal(t, map[string]string{"kind.kind-cluster": "my-cluster"}, importResources(types.Kind, cfg))
+}
+
+func TestPlanArgs(t *testing.T) {
+	t.Parallel()
+	res := planArgs("", nil, "/path/to/cluster", false)
+
+	require.Len(t, res, 5)
+	require.Equal(t, "-state=/path/to/cluster/terraform.tfstate", res[0])   // state file
+	require.Equal(t, "-var-file=/path/to/cluster/terraform.tfvars", res[1]) // vars file
+	require.Equal(t, "-out=/path/to/cluster/terraform.tfplan", res[2])      // plan file to apply later on
+	require.Equal(t, "-input=false", res[3])                                // never wait for user input
+	require.Equal(t, "/path/to/cluster", res[4])                            // cluster config directory
+
+	// destroy plan
+	res = planArgs("", nil, "/path/to/cluster", true)
+
 	require.Len(t, res, 6)
-	require.Equal(t, "-state=/path/to/cluster/terraform.tfstate", res[0])     // state file
-	require.Equal(t, "-state-out=/path/to/cluster/terraform.tfstate", res[1]) // state output file
-	require.Equal(t, "-var-file=/path/to/cluster/terraform.tfvars", res[2])   // vars file
-	require.Equal(t, "-config=/path/to/cluster", res[3])                      // config folder for import to know where the tf files are (if any)
-	require.Equal(t, "gardener_shoot.gardener_cluster", res[4])               // resource type for a GCP cluster
-	require.Equal(t, "my-namespace/my-cluster", res[5])                       // cluster ID
+	require.Equal(t, "-destroy", res[4])         // plan the destruction of all resources
+	require.Equal(t, "/path/to/cluster", res[5]) // cluster config directory
+}
+
+func TestApplyPlanArgs(t *testing.T) {
+	t.Parallel()
+	res := applyPlanArgs("/path/to/cluster")
+
+	require.Len(t, res, 3)
+	require.Equal(t, "-state=/path/to/cluster/terraform.tfstate", res[0]) // state file
+	require.Equal(t, "-auto-approve", res[1])                             // auto approve is important so that hydroform does not wait for user confirmation
+	require.Equal(t, "/path/to/cluster/terraform.tfplan", res[2])         // saved plan
+}
+
+func TestCheckClusterKept(t *testing.T) {
+	t.Parallel()
+	plan := &plans.Plan{Changes: plans.NewChanges()}
+	require.NoError(t, checkClusterKept(plan, types.GCP), "An empty plan keeps the cluster")
+
+	plan.Changes.Resources = append(plan.Changes.Resources,
+		resourceChange("google_container_cluster", "gke_cluster", plans.Update),
+		resourceChange("google_container_node_pool", "gke_node_pool", plans.DeleteThenCreate),
+	)
+	require.NoError(t, checkClusterKept(plan, types.GCP), "Updating the cluster in place and replacing other resources keeps the cluster")
+
+	plan.Changes.Resources = append(plan.Changes.Resources, resourceChange("google_container_cluster", "gke_cluster", plans.DeleteThenCreate))
+	require.Error(t, checkClusterKept(plan, types.GCP), "Replacing the cluster should be refused")
+
+	plan.Changes.Resources = []*plans.ResourceInstanceChangeSrc{resourceChange("gardener_shoot", "gardener_cluster", plans.Delete)}
+	require.Error(t, checkClusterKept(plan, types.Gardener), "Deleting the cluster should be refused")
+}
+
+func TestPlanChanges(t *testing.T) {
+	t.Parallel()
+	plan := &plans.Plan{Changes: plans.NewChanges()}
+	plan.Changes.Resources = append(plan.Changes.Resources,
+		resourceChange("google_container_cluster", "gke_cluster", plans.NoOp),
+		resourceChange("google_container_node_pool", "gke_node_pool", plans.Update),
+		resourceChange("aws_eks_node_group", "eks_node_group", plans.DeleteThenCreate),
+		resourceChange("aws_subnet", "eks_subnet", plans.CreateThenDelete),
+		resourceChange("kind", "kind-cluster", plans.Create),
+		resourceChange("gardener_shoot", "gardener_cluster", plans.Delete),
+	)
+
+	res := planChanges(plan)
+
+	require.Len(t, res.Changes, 5, "Resources without changes should be left out")
+	require.Equal(t, types.ResourceChange{
+		Address: "google_container_node_pool.gke_node_pool",
+		Type:    "google_container_node_pool",
+		Action:  types.UpdateAction,
+	}, res.Changes[0])
+	require.Equal(t, types.ReplaceAction, res.Changes[1].Action)
+	require.Equal(t, types.ReplaceAction, res.Changes[2].Action)
+	require.Equal(t, types.CreateAction, res.Changes[3].Action)
+	require.Equal(t, types.DeleteAction, res.Changes[4].Action)
+	require.Equal(t, 2, res.Count(types.ReplaceAction))
+	require.True(t, res.HasChanges())
+}
+
+// resourceChange returns the planned change of a root module resource with the given type, name and action.
+func resourceChange(resourceType, name string, action plans.Action) *plans.ResourceInstanceChangeSrc {
+	addr := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: resourceType,
+		Name: name,
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+
+	return &plans.ResourceInstanceChangeSrc{
+		Addr:      addr,
+		ChangeSrc: plans.ChangeSrc{Action: action},
+	}
+}
+
+func TestCheckUIErrors(t *testing.T) {
+	t.Parallel()
+	ui := &HydroUI{}
+	require.NoError(t, checkUIErrors(ui), "No errors should be reported without errors in the UI")
+
+	ui.Error("Error: first")
+	ui.Error("Error: second")
+	err := checkUIErrors(ui)
+	require.Error(t, err)
+
+	tfErr := &types.TerraformError{}
+	require.True(t, errors.As(errors.Wrap(err, "unable to provision gcp cluster"), &tfErr), "The terraform errors should be available in wrapped errors")
+	require.Equal(t, []string{"Error: first", "Error: second"}, tfErr.Errors)
+	require.Equal(t, "Error: first\nError: second", err.Error())
 }
@@ -1,11 +1,45 @@
 package terraform
 
 import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/pkg/errors"
 )
 
+var (
+	// ansiCodes matches the color codes terraform might add to its output
+	ansiCodes = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	// output lines of terraform while it applies changes to resources
+	resourceStartedLine    = regexp.MustCompile(`^(.+?): (Creating|Modifying|Destroying)\.\.\.`)
+	resourceInProgressLine = regexp.MustCompile(`^(.+?): Still (creating|modifying|destroying)\.\.\. \[(?:.*, )?(\S+) elapsed\]`)
+	resourceCompletedLine  = regexp.MustCompile(`^(.+?): (Creation|Modifications|Destruction) complete after (\S+)`)
+
+	progressActions = map[string]types.ChangeAction{
+		"creating":      types.CreateAction,
+		"creation":      types.CreateAction,
+		"modifying":     types.UpdateAction,
+		"modifications": types.UpdateAction,
+		"destroying":    types.DeleteAction,
+		"destruction":   types.DeleteAction,
+	}
+)
+
 type HydroUI struct {
+	mu   sync.Mutex
 	errs []error
+	// progress receives the progress events parsed from the terraform output, if set.
+	// progressMu keeps the calls in order without holding mu, so that a slow progress function does not hold up the output and the errors.
+	progress   func(types.ProgressEvent)
+	progressMu sync.Mutex
+	// out receives every line terraform outputs, if set
+	out io.Writer
 }
 
 // Ask asks the user for input using the given query. For Hydroform,
@@ -21,26 +55,102 @@ func (h *HydroUI) AskSecret(string) (string, error) {
 }
 
 // Output is called for normal standard output.
-// Terraform output is ignored in Hydroform
-func (h *HydroUI) Output(string) {}
+// Terraform output is written to the output writer and used to report the progress of the resource changes.
+func (h *HydroUI) Output(s string) {
+	h.write(s)
+	if e, ok := parseProgress(s); ok {
+		h.report(e)
+	}
+}
 
 // Info is called for information related to the previous output.
 // In general this may be the exact same as Output, but this gives
 // Ui implementors some flexibility with output formats.
-// Terraform info is ignored in Hydroform.
-func (h *HydroUI) Info(string) {}
+// Terraform info is handled like its output.
+func (h *HydroUI) Info(s string) {
+	h.Output(s)
+}
 
-// Error saves error messages from terraform as an error slice to be retrieved later by Hydroform.
+// Error saves error messages from terraform as an error slice to be retrieved later by Hydroform, and reports them as progress.
 func (h *HydroUI) Error(s string) {
+	h.write(s)
+	s = strings.TrimSpace(ansiCodes.ReplaceAllString(s, ""))
+
+	h.mu.Lock()
 	h.errs = append(h.errs, errors.New(s))
+	h.mu.Unlock()
+
+	h.report(types.ProgressEvent{
+		Type:    types.ProgressError,
+		Time:    time.Now(),
+		Message: s,
+	})
 }
 
 // Warn saves warning messages from terraform as an error slice to be retrieved later by Hydroform.
 func (h *HydroUI) Warn(s string) {
+	h.write(s)
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.errs = append(h.errs, errors.New(s))
 }
 
 // Errors returns any errors or warnings that happened during a terraform command execution
 func (h *HydroUI) Errors() []error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	return h.errs
 }
+
+// write writes the given terraform output to the output writer without color codes, terraform calls the UI from several goroutines.
+func (h *HydroUI) write(s string) {
+	if h.out == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// the output is best effort, a failing writer must not fail the operation
+	fmt.Fprintln(h.out, strings.TrimRight(ansiCodes.ReplaceAllString(s, ""), "\n"))
+}
+
+// report passes the event to the progress function, terraform calls the UI from several goroutines.
+func (h *HydroUI) report(e types.ProgressEvent) {
+	h.progressMu.Lock()
+	defer h.progressMu.Unlock()
+	if h.progress != nil {
+		h.progress(e)
+	}
+}
+
+// parseProgress turns an output line of terraform into a progress event.
+// It returns false if the line does not report the progress of a resource change.
+func parseProgress(line string) (types.ProgressEvent, bool) {
+	line = strings.TrimSpace(ansiCodes.ReplaceAllString(line, ""))
+	e := types.ProgressEvent{
+		Time:    time.Now(),
+		Message: line,
+	}
+
+	if m := resourceStartedLine.FindStringSubmatch(line); m != nil {
+		e.Type = types.ResourceStarted
+		e.Resource = m[1]
+		e.Action = progressActions[strings.ToLower(m[2])]
+		return e, true
+	}
+	if m := resourceInProgressLine.FindStringSubmatch(line); m != nil {
+		e.Type = types.ResourceInProgress
+		e.Resource = m[1]
+		e.Action = progressActions[m[2]]
+		e.Elapsed, _ = time.ParseDuration(m[3])
+		return e, true
+	}
+	if m := resourceCompletedLine.FindStringSubmatch(line); m != nil {
+		e.Type = types.ResourceCompleted
+		e.Resource = m[1]
+		e.Action = progressActions[strings.ToLower(m[2])]
+		e.Elapsed, _ = time.ParseDuration(m[3])
+		return e, true
+	}
+
+	return e, false
+}
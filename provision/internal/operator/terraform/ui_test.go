@@ -1,8 +1,11 @@
 package terraform
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	"github.com/kyma-incubator/hydroform/provision/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,3 +36,123 @@ func TestWarnAnError(t *testing.T) {
 
 	require.Len(t, ui.Errors(), 2, "There should be 2 errors in total (1 errror and 1 warning)")
 }
+
+func TestParseProgress(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Line     string
+		Expected types.ProgressEvent
+	}{
+		{
+			Line:     "google_container_cluster.gke_cluster: Creating...",
+			Expected: types.ProgressEvent{Type: types.ResourceStarted, Resource: "google_container_cluster.gke_cluster", Action: types.CreateAction},
+		},
+		{
+			Line:     "\x1b[0m\x1b[1maws_subnet.eks_subnet[1]: Destroying... [id=subnet-123]\x1b[0m",
+			Expected: types.ProgressEvent{Type: types.ResourceStarted, Resource: "aws_subnet.eks_subnet[1]", Action: types.DeleteAction},
+		},
+		{
+			Line:     "google_container_node_pool.gke_node_pool: Still modifying... [id=projects/p/pool, 1m10s elapsed]",
+			Expected: types.ProgressEvent{Type: types.ResourceInProgress, Resource: "google_container_node_pool.gke_node_pool", Action: types.UpdateAction, Elapsed: 70 * time.Second},
+		},
+		{
+			Line:     "kind.kind-cluster: Still creating... [20s elapsed]",
+			Expected: types.ProgressEvent{Type: types.ResourceInProgress, Resource: "kind.kind-cluster", Action: types.CreateAction, Elapsed: 20 * time.Second},
+		},
+		{
+			Line:     "aws_eks_cluster.eks_cluster: Creation complete after 9m52s [id=hydro-cluster]",
+			Expected: types.ProgressEvent{Type: types.ResourceCompleted, Resource: "aws_eks_cluster.eks_cluster", Action: types.CreateAction, Elapsed: 9*time.Minute + 52*time.Second},
+		},
+		{
+			Line:     "aws_vpc.eks_vpc: Destruction complete after 1s",
+			Expected: types.ProgressEvent{Type: types.ResourceCompleted, Resource: "aws_vpc.eks_vpc", Action: types.DeleteAction, Elapsed: time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		e, ok := parseProgress(tc.Line)
+		require.True(t, ok, "Line %q should report progress", tc.Line)
+		require.Equal(t, tc.Expected.Type, e.Type, tc.Line)
+		require.Equal(t, tc.Expected.Resource, e.Resource, tc.Line)
+		require.Equal(t, tc.Expected.Action, e.Action, tc.Line)
+		require.Equal(t, tc.Expected.Elapsed, e.Elapsed, tc.Line)
+		require.NotContains(t, e.Message, "\x1b", "Color codes should be removed")
+	}
+
+	_, ok := parseProgress("Apply complete! Resources: 3 added, 0 changed, 0 destroyed.")
+	require.False(t, ok, "Other output should not report progress")
+}
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	var events []types.ProgressEvent
+	ui := &HydroUI{progress: func(e types.ProgressEvent) {
+		events = append(events, e)
+	}}
+
+	ui.Output("kind.kind-cluster: Creating...")
+	ui.Output("Apply complete! Resources: 1 added, 0 changed, 0 destroyed.")
+	ui.Info("kind.kind-cluster: Creation complete after 1m0s [id=kind]")
+	ui.Error("\x1b[31mError: could not pull the node image\x1b[0m\n")
+
+	require.Len(t, events, 3)
+	require.Equal(t, types.ResourceStarted, events[0].Type)
+	require.Equal(t, types.ResourceCompleted, events[1].Type)
+	require.Equal(t, types.ProgressError, events[2].Type)
+	require.Equal(t, "Error: could not pull the node image", events[2].Message)
+	require.Len(t, ui.Errors(), 1, "Errors should still be collected")
+}
+
+func TestBlockedProgress(t *testing.T) {
+	t.Parallel()
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	defer close(unblock)
+	var out bytes.Buffer
+	ui := &HydroUI{out: &out, progress: func(types.ProgressEvent) {
+		close(blocked)
+		<-unblock
+	}}
+
+	go ui.Output("kind.kind-cluster: Creating...")
+	<-blocked
+	done := make(chan struct{})
+	go func() {
+		ui.Output("Terraform will perform the following actions:")
+		ui.Warn("Warning: deprecated attribute")
+		ui.Errors()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "A blocked progress function should not hold up the output and the errors")
+	}
+
+	// a full progress channel drops the events instead of blocking the operation
+	ops := &types.Options{}
+	types.WithProgressChannel(make(chan types.ProgressEvent, 1))(ops)
+	ops.Progress(types.ProgressEvent{Type: types.ResourceStarted})
+	ops.Progress(types.ProgressEvent{Type: types.ResourceCompleted})
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+	out := &bytes.Buffer{}
+	ui := &HydroUI{out: out}
+
+	ui.Output("\x1b[0m\x1b[1mkind.kind-cluster: Creating...\x1b[0m")
+	ui.Info("Apply complete! Resources: 1 added, 0 changed, 0 destroyed.\n")
+	ui.Warn("Warning: deprecated attribute")
+	ui.Error("\x1b[31mError: could not pull the node image\x1b[0m\n")
+
+	require.Equal(t, `kind.kind-cluster: Creating...
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.
+Warning: deprecated attribute
+Error: could not pull the node image
+`, out.String(), "All output should be written without color codes")
+
+	// without an output writer, nothing is written
+	ui = &HydroUI{}
+	ui.Output("kind.kind-cluster: Creating...")
+	require.Len(t, ui.Errors(), 0)
+}
@@ -1,6 +1,7 @@
 package operator
 
 import (
+	"context"
 	"errors"
 
 	"github.com/hashicorp/terraform/states/statefile"
@@ -12,15 +13,35 @@ type Unknown struct {
 }
 
 // Create returns an error if the operator is unknown.
-func (u *Unknown) Create(p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+func (u *Unknown) Create(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
 	return nil, errors.New("unknown operator")
 }
 
-func (u *Unknown) Status(state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+func (u *Unknown) Status(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterStatus, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// Update returns an error if the operator is unknown.
+func (u *Unknown) Update(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// Plan returns an error if the operator is unknown.
+func (u *Unknown) Plan(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) (*types.Plan, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// Import returns an error if the operator is unknown.
+func (u *Unknown) Import(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// ClusterInfo returns an error if the operator is unknown.
+func (u *Unknown) ClusterInfo(ctx context.Context, p types.ProviderType, cfg map[string]interface{}) (*types.ClusterInfo, error) {
 	return nil, errors.New("unknown operator")
 }
 
 // Delete returns an error if the operator is unknown.
-func (u *Unknown) Delete(state *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
+func (u *Unknown) Delete(ctx context.Context, state *statefile.File, p types.ProviderType, cfg map[string]interface{}) error {
 	return errors.New("unknown operator")
 }
@@ -0,0 +1,174 @@
+// Package providerconfig converts the typed provider configurations of the types package into custom configurations,
+// and validates custom configurations against the tags of the typed configurations, so that both ways to configure a provider follow the same rules.
+package providerconfig
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/errs"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+// field describes a field of a typed configuration.
+type field struct {
+	index []int
+	key   string
+	def   string
+	rules []string
+}
+
+// ToMap returns the custom configurations set by the given typed configuration.
+// Empty fields get the value of their default tag. Empty strings without a default are left out.
+func ToMap(cfg types.ProviderConfig) (map[string]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	if v.Kind() != reflect.Struct {
+		return nil, errors.Errorf("unsupported provider configuration %T", cfg)
+	}
+	// work on a copy to set the defaults
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	m := map[string]interface{}{}
+	if t, ok := cfg.(interface{ TargetProvider() types.ProviderType }); ok {
+		m["target_provider"] = string(t.TargetProvider())
+	}
+
+	for _, f := range fields(c.Type()) {
+		fv := c.FieldByIndex(f.index)
+		if fv.IsZero() && f.def != "" {
+			if err := setDefault(fv, f.def); err != nil {
+				return nil, errors.Wrapf(err, "invalid default of %s", f.key)
+			}
+		}
+
+		switch fv.Kind() {
+		case reflect.String:
+			if s := fv.String(); s != "" {
+				m[f.key] = s
+			}
+		case reflect.Int:
+			m[f.key] = int(fv.Int())
+		case reflect.Bool:
+			m[f.key] = fv.Bool()
+		case reflect.Slice:
+			// the provisioners expect a list even if it is empty
+			m[f.key] = append([]string{}, fv.Interface().([]string)...)
+		default:
+			return nil, errors.Errorf("unsupported type %s of %s", fv.Type(), f.key)
+		}
+	}
+	return m, nil
+}
+
+// Validate checks the custom configurations against the validate tags of the given typed configuration, whose values are ignored.
+// It returns the validation messages in the format of the errs package, or an empty string if the custom configurations are valid.
+// Keys the typed configuration does not know about are not checked.
+func Validate(custom map[string]interface{}, cfg types.ProviderConfig) string {
+	var errMessage string
+	for _, f := range fields(reflect.Indirect(reflect.ValueOf(cfg)).Type()) {
+		name := fmt.Sprintf("Provider.CustomConfigurations['%s']", f.key)
+		v, ok := custom[f.key]
+		for _, rule := range f.rules {
+			switch {
+			case rule == "required":
+				if !ok || isEmpty(v) {
+					errMessage += fmt.Sprintf(errs.CannotBeEmpty, name)
+				}
+			case strings.HasPrefix(rule, "min="):
+				min, _ := strconv.Atoi(strings.TrimPrefix(rule, "min="))
+				if i, isInt := v.(int); isInt && i < min {
+					errMessage += fmt.Sprintf(errs.CannotBeLess, name, min)
+				}
+			case rule == "cidr":
+				if s, isString := v.(string); isString && s != "" {
+					if _, _, err := net.ParseCIDR(s); err != nil {
+						errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("%s has to be a network range in CIDR notation, such as 10.250.0.0/16", name))
+					}
+				}
+			}
+		}
+	}
+	return errMessage
+}
+
+// Schema returns the custom configuration keys of the given typed configurations, mapped to the kind of their values:
+// reflect.String, reflect.Int, reflect.Bool, or reflect.Slice for lists of strings.
+// The Gardener configurations add the target_provider key.
+func Schema(cfgs ...types.ProviderConfig) map[string]reflect.Kind {
+	schema := map[string]reflect.Kind{}
+	for _, cfg := range cfgs {
+		if _, ok := cfg.(interface{ TargetProvider() types.ProviderType }); ok {
+			schema["target_provider"] = reflect.String
+		}
+		t := reflect.Indirect(reflect.ValueOf(cfg)).Type()
+		for _, f := range fields(t) {
+			schema[f.key] = t.FieldByIndex(f.index).Type.Kind()
+		}
+	}
+	return schema
+}
+
+// fields lists the configuration fields of the given struct type, including the ones of embedded structs.
+func fields(t reflect.Type) []field {
+	var list []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			for _, f := range fields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				list = append(list, f)
+			}
+			continue
+		}
+
+		key := sf.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		f := field{index: []int{i}, key: key, def: sf.Tag.Get("default")}
+		if rules := sf.Tag.Get("validate"); rules != "" {
+			f.rules = strings.Split(rules, ",")
+		}
+		list = append(list, f)
+	}
+	return list
+}
+
+func setDefault(v reflect.Value, def string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(def)
+	case reflect.Int:
+		i, err := strconv.Atoi(def)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(i))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(strings.Split(def, ",")))
+	}
+	return nil
+}
+
+func isEmpty(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []string:
+		return len(t) == 0
+	}
+	return false
+}
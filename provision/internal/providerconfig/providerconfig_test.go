@@ -0,0 +1,127 @@
+package providerconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMap(t *testing.T) {
+	t.Parallel()
+
+	cfg := types.GardenerAWSConfig{
+		GardenerWorkerConfig: types.GardenerWorkerConfig{
+			TargetSecret:  "aws-secret",
+			DiskType:      "gp2",
+			WorkerMaximum: 5,
+		},
+		Zones: []string{"eu-west-1a", "eu-west-1b"},
+	}
+
+	m, err := ToMap(cfg)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"target_provider":        "aws",
+		"target_secret":          "aws-secret",
+		"disk_type":              "gp2",
+		"worker_minimum":         1,
+		"worker_maximum":         5,
+		"worker_max_surge":       1,
+		"worker_max_unavailable": 0,
+		"networking_type":        "calico",
+		"privileged_containers":  false,
+		"vnetcidr":               "10.250.0.0/16",
+		"zones":                  []string{"eu-west-1a", "eu-west-1b"},
+	}, m, "Empty fields should get their defaults, and empty strings without default should be left out")
+	require.Empty(t, cfg.VnetCIDR, "The given configuration should not be changed")
+
+	m, err = ToMap(&types.GardenerAzureConfig{})
+	require.NoError(t, err)
+	require.Equal(t, []string{}, m["zones"], "Lists should be set even if empty")
+
+	m, err = ToMap(types.KindConfig{NodeImage: "kindest/node:v1.17.0"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"node_image":          "kindest/node:v1.17.0",
+		"control_plane_nodes": 1,
+		"extra_port_mappings": []string{},
+		"feature_gates":       []string{},
+	}, m)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	custom := map[string]interface{}{
+		"target_provider":        "gcp",
+		"target_secret":          "secret-name",
+		"disk_type":              "pd-standard",
+		"workercidr":             "10.250.0.0/19",
+		"worker_max_surge":       4,
+		"worker_max_unavailable": 1,
+		"worker_maximum":         4,
+		"worker_minimum":         2,
+		"zones":                  []string{"europe-west3-b"},
+		"gcp_control_plane_zone": "europe-west3-b",
+		"networking_type":        "calico",
+		"unknown_key":            "ignored",
+	}
+	require.Empty(t, Validate(custom, types.GardenerGCPConfig{}))
+
+	custom["zones"] = []string{}
+	delete(custom, "target_secret")
+	custom["worker_minimum"] = 0
+	custom["workercidr"] = "10.250.0.0"
+	msg := Validate(custom, types.GardenerGCPConfig{})
+	require.Contains(t, msg, "Provider.CustomConfigurations['zones'] cannot be empty")
+	require.Contains(t, msg, "Provider.CustomConfigurations['target_secret'] cannot be empty")
+	require.Contains(t, msg, "Provider.CustomConfigurations['worker_minimum'] cannot be less than 1")
+	require.Contains(t, msg, "Provider.CustomConfigurations['workercidr'] has to be a network range in CIDR notation")
+
+	require.Contains(t, Validate(map[string]interface{}{}, types.KindConfig{}), "Provider.CustomConfigurations['node_image'] cannot be empty")
+	require.Empty(t, Validate(map[string]interface{}{}, types.AzureConfig{}))
+}
+
+func TestTypedConfigurationsAreValid(t *testing.T) {
+	t.Parallel()
+
+	worker := types.GardenerWorkerConfig{TargetSecret: "secret", DiskType: "standard"}
+	configs := []types.ProviderConfig{
+		types.GardenerGCPConfig{GardenerWorkerConfig: worker, Zones: []string{"europe-west3-b"}, ControlPlaneZone: "europe-west3-b"},
+		types.GardenerAzureConfig{GardenerWorkerConfig: worker, MachineImageName: "gardenlinux", MachineImageVersion: "184.0.0"},
+		types.GardenerAWSConfig{GardenerWorkerConfig: worker, Zones: []string{"eu-west-1a"}},
+		types.KindConfig{NodeImage: "kindest/node:v1.17.0"},
+		types.AzureConfig{},
+		types.AWSConfig{},
+		types.K3dConfig{},
+	}
+
+	for _, cfg := range configs {
+		m, err := ToMap(cfg)
+		require.NoError(t, err)
+		require.Empty(t, Validate(m, cfg), "The defaults of %T should be valid", cfg)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, map[string]reflect.Kind{
+		"node_image":          reflect.String,
+		"control_plane_nodes": reflect.Int,
+		"extra_port_mappings": reflect.Slice,
+		"registry_mirror":     reflect.String,
+		"feature_gates":       reflect.Slice,
+	}, Schema(types.KindConfig{}))
+
+	schema := Schema(types.GardenerGCPConfig{}, types.GardenerAzureConfig{})
+	require.Equal(t, reflect.String, schema["target_provider"])
+	require.Equal(t, reflect.Int, schema["worker_minimum"], "The keys of embedded configurations should be included")
+	require.Equal(t, reflect.Bool, schema["privileged_containers"])
+	require.Equal(t, reflect.String, schema["gcp_control_plane_zone"], "The keys of all configurations should be included")
+	require.Equal(t, reflect.Slice, schema["service_endpoints"])
+
+	require.Empty(t, Schema())
+}
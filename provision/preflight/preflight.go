@@ -0,0 +1,88 @@
+// Package preflight checks whether a provider can run a cluster before any of its resources are created,
+// so that a missing quota or an unavailable machine type fails the provisioning right away instead of after minutes of Terraform.
+//
+// The checks query a types.PreflightChecker, which Hydroform does not implement for any provider, since it depends on the APIs and the pricing of the provider.
+// Pass your implementation with types.WithPreflightChecker, or use Static.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+// Run checks the cluster with the given checker and returns all problems found, along with the estimated cost of the cluster.
+// The Kubernetes version and the machine type are only checked if the cluster sets them. A check that fails to query the provider is a problem as well.
+// If maxMonthlyCost is not zero, a higher estimated monthly cost is a problem. Without a checker, there is nothing to check.
+func Run(ctx context.Context, c types.PreflightChecker, cluster *types.Cluster, provider *types.Provider, maxMonthlyCost float64) (*types.PreflightReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	report := &types.PreflightReport{}
+	if c == nil {
+		return report, nil
+	}
+
+	if cluster.KubernetesVersion != "" {
+		versions, err := c.KubernetesVersions(ctx, cluster, provider)
+		switch {
+		case err != nil:
+			report.Problems = append(report.Problems, fmt.Sprintf("could not check the Kubernetes version: %s", err))
+		case !supportsVersion(versions, cluster.KubernetesVersion):
+			report.Problems = append(report.Problems, fmt.Sprintf("Kubernetes %s is not supported by %s in %s, supported versions are: %s",
+				cluster.KubernetesVersion, provider.Type, cluster.Location, strings.Join(versions, ", ")))
+		}
+	}
+
+	if cluster.MachineType != "" {
+		machineTypes, err := c.MachineTypes(ctx, cluster, provider)
+		switch {
+		case err != nil:
+			report.Problems = append(report.Problems, fmt.Sprintf("could not check the machine type: %s", err))
+		case !contains(machineTypes, cluster.MachineType):
+			report.Problems = append(report.Problems, fmt.Sprintf("the machine type %s is not available in %s", cluster.MachineType, cluster.Location))
+		}
+	}
+
+	shortages, err := c.QuotaShortages(ctx, cluster, provider)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("could not check the quotas: %s", err))
+	}
+	for _, s := range shortages {
+		report.Problems = append(report.Problems, fmt.Sprintf("the %s quota is too low: %g required, %g available", s.Metric, s.Required, s.Available))
+	}
+
+	cost, err := c.EstimateCost(ctx, cluster, provider)
+	switch {
+	case err != nil:
+		report.Problems = append(report.Problems, fmt.Sprintf("could not estimate the cost: %s", err))
+	case cost != nil:
+		report.Cost = cost
+		if maxMonthlyCost != 0 && cost.Monthly() > maxMonthlyCost {
+			report.Problems = append(report.Problems, fmt.Sprintf("the estimated cost of %.2f %s per month exceeds the limit of %.2f", cost.Monthly(), cost.Currency, maxMonthlyCost))
+		}
+	}
+
+	return report, nil
+}
+
+// supportsVersion tells whether the version is one of the supported ones. Versions match their patch and provider-specific releases, so 1.17 matches 1.17.9-gke.1504.
+func supportsVersion(supported []string, version string) bool {
+	for _, s := range supported {
+		if s == version || strings.HasPrefix(s, version+".") || strings.HasPrefix(s, version+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	cluster := &types.Cluster{Name: "hydro", Location: "eu-west-1", KubernetesVersion: "1.16", MachineType: "m5.xlarge"}
+	provider := &types.Provider{Type: types.AWS}
+	checker := &Static{
+		Versions:  []string{"1.17", "1.18"},
+		Machines:  map[string][]string{"eu-west-1": {"m5.large"}},
+		Shortages: []types.QuotaShortage{{Metric: "vCPU", Required: 8, Available: 4}},
+		Cost:      &types.CostEstimate{Currency: "USD", Hourly: 1},
+	}
+
+	report, err := Run(context.Background(), checker, cluster, provider, 500)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Kubernetes 1.16 is not supported by aws in eu-west-1, supported versions are: 1.17, 1.18",
+		"the machine type m5.xlarge is not available in eu-west-1",
+		"the vCPU quota is too low: 8 required, 4 available",
+		"the estimated cost of 730.00 USD per month exceeds the limit of 500.00",
+	}, report.Problems, "All problems should be reported at once")
+	require.Equal(t, 730.0, report.Cost.Monthly())
+	require.Error(t, report.Err())
+
+	cluster.KubernetesVersion = "1.17.12"
+	checker.Versions = []string{"1.17.12-eks.1"}
+	cluster.MachineType = "m5.large"
+	checker.Shortages = nil
+	report, err = Run(context.Background(), checker, cluster, provider, 0)
+	require.NoError(t, err)
+	require.NoError(t, report.Err(), "Without a limit the cost should only be reported")
+
+	// a check that cannot query the provider is a problem as well
+	checker.Err = errors.New("access denied")
+	report, err = Run(context.Background(), checker, cluster, provider, 0)
+	require.NoError(t, err)
+	require.Len(t, report.Problems, 4)
+	require.Nil(t, report.Cost)
+
+	// without a checker there is nothing to check
+	report, err = Run(context.Background(), nil, cluster, provider, 0)
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, checker, cluster, provider, 0)
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestSupportsVersion(t *testing.T) {
+	t.Parallel()
+	supported := []string{"1.17.9-gke.1504", "1.18"}
+
+	require.True(t, supportsVersion(supported, "1.17"))
+	require.True(t, supportsVersion(supported, "1.17.9"))
+	require.True(t, supportsVersion(supported, "1.18"))
+	require.False(t, supportsVersion(supported, "1.1"))
+	require.False(t, supportsVersion(supported, "1.18.1"), "A patch version should not match its minor version")
+	require.False(t, supportsVersion(nil, "1.17"))
+}
@@ -0,0 +1,42 @@
+package preflight
+
+import (
+	"context"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+// Static is a types.PreflightChecker answering from fixed values, such as a local fake in tests,
+// or a list of the versions and machine types approved for the clusters of a company.
+type Static struct {
+	// Versions are the supported Kubernetes versions in all locations.
+	Versions []string
+	// Machines are the available machine types by location.
+	Machines map[string][]string
+	// Shortages are returned for every cluster.
+	Shortages []types.QuotaShortage
+	// Cost is the estimated cost of every cluster, or nil if it is not estimated.
+	Cost *types.CostEstimate
+	// Err is returned by all checks if set.
+	Err error
+}
+
+// KubernetesVersions returns the supported versions.
+func (s *Static) KubernetesVersions(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]string, error) {
+	return s.Versions, s.Err
+}
+
+// MachineTypes returns the machine types of the location of the cluster.
+func (s *Static) MachineTypes(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]string, error) {
+	return s.Machines[cluster.Location], s.Err
+}
+
+// QuotaShortages returns the shortages.
+func (s *Static) QuotaShortages(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]types.QuotaShortage, error) {
+	return s.Shortages, s.Err
+}
+
+// EstimateCost returns the cost.
+func (s *Static) EstimateCost(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.CostEstimate, error) {
+	return s.Cost, s.Err
+}
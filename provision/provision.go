@@ -1,164 +1,383 @@
 package provision
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/kyma-incubator/hydroform/provision/action"
 
+	"github.com/kyma-incubator/hydroform/provision/internal/aws"
 	"github.com/kyma-incubator/hydroform/provision/internal/azure"
 	"github.com/kyma-incubator/hydroform/provision/internal/gardener"
+	"github.com/kyma-incubator/hydroform/provision/internal/health"
+	"github.com/kyma-incubator/hydroform/provision/internal/k3d"
 	"github.com/kyma-incubator/hydroform/provision/internal/kind"
+	"github.com/kyma-incubator/hydroform/provision/internal/kubeconfig"
 
 	"github.com/kyma-incubator/hydroform/provision/internal/gcp"
 	"github.com/kyma-incubator/hydroform/provision/internal/operator"
+	"github.com/kyma-incubator/hydroform/provision/internal/providerconfig"
+	"github.com/kyma-incubator/hydroform/provision/preflight"
 	"github.com/kyma-incubator/hydroform/provision/types"
 )
 
 const provisioningOperator = operator.TerraformOperator
 
-// Provisioner is the Hydroform interface that groups Provision, Status, Credentials, and Deprovision functions used to create and manage a cluster.
+// Provisioner is the Hydroform interface that groups Provision, Status, Credentials, Update, Plan, Import, and Deprovision functions used to create and manage a cluster.
+// All functions stop as soon as possible when the given context is canceled or its deadline is exceeded.
 type Provisioner interface {
-	Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
-	Status(cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error)
-	Credentials(cluster *types.Cluster, provider *types.Provider) ([]byte, error)
-	Deprovision(cluster *types.Cluster, provider *types.Provider) error
+	Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
+	Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error)
+	Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error)
+	Update(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
+	Plan(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error)
+	Import(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
+	Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error
+	// ValidateInputs checks the cluster and provider parameters without contacting the provider.
+	ValidateInputs(cluster *types.Cluster, provider *types.Provider) error
+}
+
+// updateRejecter is implemented by the provisioners that cannot change existing clusters.
+// RejectUpdate returns the error Update fails with, so that the cluster is not checked in vain.
+type updateRejecter interface {
+	RejectUpdate() error
 }
 
 // Provision creates a new cluster for a given provider based on specific cluster and provider parameters. It returns a cluster object enriched with information from the provider, such as the IP address or the connection endpoint. This object is necessary for the other operations, such as retrieving the cluster status or deprovisioning the cluster. If the cluster cannot be created, the function returns an error.
 func Provision(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Cluster, error) {
-	var err error
-	var cl *types.Cluster
+	return ProvisionWithContext(context.Background(), cluster, provider, ops...)
+}
 
-	if err = action.Before(); err != nil {
-		return cl, err
-	}
+// ProvisionWithContext works like Provision but stops as soon as ctx is canceled or its deadline is exceeded.
+// An interrupted provisioning returns the cluster enriched with the partial state, and keeps the cluster files in the data directory so that calling Provision again resumes it.
+// If the cluster is provisioned but the audit sinks cannot record it, the cluster is returned along with a types.AuditError.
+func ProvisionWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Cluster, error) {
+	res, err := run(ctx, types.ProvisionOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		if err := checkPreflight(ctx, p, cluster, provider, ops); err != nil {
+			return nil, err
+		}
+		return p.Provision(ctx, cluster, provider)
+	})
+	cl, _ := res.(*types.Cluster)
+	return cl, err
+}
 
-	if runtime.GOOS == "windows" {
-		provider.CredentialsFilePath = updateWindowsPath(provider.CredentialsFilePath)
+// Status returns the cluster status for a given provider, or an error if providing the status is not possible. The possible status values are defined in the ClusterStatus type.
+// The health of a provisioned cluster is checked with its admin credentials: the API server must be reachable, and all nodes and system pods must be ready. The result of each check is added to the conditions of the status.
+// If the credentials do not work, the checks are unknown and the cluster stays provisioned.
+func Status(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.ClusterStatus, error) {
+	return StatusWithContext(context.Background(), cluster, provider, ops...)
+}
+
+// StatusWithContext works like Status but stops as soon as ctx is canceled or its deadline is exceeded.
+func StatusWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.ClusterStatus, error) {
+	res, err := run(ctx, types.StatusOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		cs, err := p.Status(ctx, cluster, provider)
+		if err != nil {
+			return cs, err
+		}
+		if cs.Phase == types.Provisioned {
+			checkHealth(ctx, p, cluster, provider, cs)
+		}
+		return cs, nil
+	})
+	cs, _ := res.(*types.ClusterStatus)
+	return cs, err
+}
+
+// adminCredentialer is implemented by the provisioners whose Credentials return a kubeconfig that needs the command line tools of the provider.
+// AdminCredentials returns a kubeconfig with the admin identity of the cluster that works without them.
+type adminCredentialer interface {
+	AdminCredentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error)
+}
+
+// Credentials returns the kubeconfig for a specific cluster as a byte array.
+// Pass the WithServiceAccount option to get a self-contained kubeconfig with the token of a ServiceAccount instead, or the WithExecAuth option to authenticate with a credential plugin.
+func Credentials(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) ([]byte, error) {
+	return CredentialsWithContext(context.Background(), cluster, provider, ops...)
+}
+
+// CredentialsWithContext works like Credentials but stops as soon as ctx is canceled or its deadline is exceeded.
+func CredentialsWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) ([]byte, error) {
+	options := &types.Options{}
+	for _, o := range ops {
+		o(options)
 	}
 
-	switch provider.Type {
-	case types.GCP:
-		cl, err = newGCPProvisioner(provisioningOperator, ops...).Provision(cluster, provider)
-	case types.Gardener:
-		cl, err = newGardenerProvisioner(provisioningOperator, ops...).Provision(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		cl, err = newAzureProvisioner(provisioningOperator, ops...).Provision(cluster, provider)
-	case types.Kind:
-		cl, err = newKindProvisioner(provisioningOperator, ops...).Provision(cluster, provider)
+	res, err := run(ctx, types.CredentialsOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		return credentials(ctx, p, cluster, provider, options)
+	})
+	cr, _ := res.([]byte)
+	return cr, err
+}
+
+// credentials returns the kubeconfig of the cluster, derived from the admin kubeconfig according to the options.
+func credentials(ctx context.Context, p Provisioner, cluster *types.Cluster, provider *types.Provider, options *types.Options) ([]byte, error) {
+	switch {
+	case options.ServiceAccount != nil && options.ExecAuth != nil:
+		return nil, errors.New("the service account and exec auth options cannot be used together")
+	case options.ServiceAccount != nil:
+		var admin []byte
+		var err error
+		if ac, ok := p.(adminCredentialer); ok {
+			admin, err = ac.AdminCredentials(ctx, cluster, provider)
+		} else {
+			admin, err = p.Credentials(ctx, cluster, provider)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return kubeconfig.NewGenerator().ForServiceAccount(ctx, admin, *options.ServiceAccount)
+	case options.ExecAuth != nil:
+		kc, err := p.Credentials(ctx, cluster, provider)
+		if err != nil {
+			return nil, err
+		}
+		return kubeconfig.WithExecAuth(kc, *options.ExecAuth)
 	default:
-		err = errors.New("unknown provider")
+		return p.Credentials(ctx, cluster, provider)
 	}
+}
 
-	if err != nil {
-		return cl, err
-	}
-	return cl, action.After()
+// Update applies changes of the node count, machine type, or Kubernetes version to an existing cluster. Pass the cluster object returned by Provision with the updated values. It returns the cluster enriched with its new state. If the changes cannot be applied without replacing the cluster, the function returns an error and leaves the cluster untouched.
+func Update(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Cluster, error) {
+	return UpdateWithContext(context.Background(), cluster, provider, ops...)
 }
 
-// Status returns the cluster status for a given provider, or an error if providing the status is not possible. The possible status values are defined in the ClusterStatus type.
-func Status(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.ClusterStatus, error) {
-	var err error
-	var cs *types.ClusterStatus
+// UpdateWithContext works like Update but stops as soon as ctx is canceled or its deadline is exceeded.
+func UpdateWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Cluster, error) {
+	res, err := run(ctx, types.UpdateOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		if ur, ok := p.(updateRejecter); ok {
+			return cluster, ur.RejectUpdate()
+		}
+		if err := checkPreflight(ctx, p, cluster, provider, ops); err != nil {
+			return cluster, err
+		}
+		return p.Update(ctx, cluster, provider)
+	})
+	cl, _ := res.(*types.Cluster)
+	return cl, err
+}
 
-	if err = action.Before(); err != nil {
-		return cs, err
-	}
+// Plan returns the changes that Provision or Update would make to the infrastructure of a cluster without applying any of them. Pass the cluster object returned by Provision to plan an update of an existing cluster. Use the PlanDestroy option to plan the changes of Deprovision instead.
+func Plan(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Plan, error) {
+	return PlanWithContext(context.Background(), cluster, provider, ops...)
+}
 
-	if runtime.GOOS == "windows" {
-		provider.CredentialsFilePath = updateWindowsPath(provider.CredentialsFilePath)
+// PlanWithContext works like Plan but stops as soon as ctx is canceled or its deadline is exceeded.
+func PlanWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Plan, error) {
+	res, err := run(ctx, types.PlanOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		return p.Plan(ctx, cluster, provider)
+	})
+	pl, _ := res.(*types.Plan)
+	return pl, err
+}
+
+// Import brings an existing cluster, created outside of Hydroform or whose data directory was lost, under the management of Hydroform. Describe the cluster the same way as for Provision.
+// It returns a cluster object enriched with its internal state, which Status, Update, and Deprovision need afterwards. Import brings in the cluster and, on GCP, its node pool.
+// Import fails if Hydroform already has a state for the cluster, or if the Terraform configuration of the provider manages other resources whose IDs are unknown, such as the network of an EKS cluster.
+func Import(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Cluster, error) {
+	return ImportWithContext(context.Background(), cluster, provider, ops...)
+}
+
+// ImportWithContext works like Import but stops as soon as ctx is canceled or its deadline is exceeded.
+func ImportWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.Cluster, error) {
+	res, err := run(ctx, types.ImportOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		return p.Import(ctx, cluster, provider)
+	})
+	cl, _ := res.(*types.Cluster)
+	return cl, err
+}
+
+// Preflight checks whether the provider can run the cluster with the checker set by the WithPreflightChecker option, without changing anything.
+// It returns all problems found, such as an unsupported Kubernetes version, an unavailable machine type, or a missing quota, along with the estimated cost of the cluster.
+// Provision and Update run the same checks and fail if there are problems. Without a checker for the provider, there is nothing to check.
+func Preflight(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.PreflightReport, error) {
+	return PreflightWithContext(context.Background(), cluster, provider, ops...)
+}
+
+// PreflightWithContext works like Preflight but stops as soon as ctx is canceled or its deadline is exceeded.
+func PreflightWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) (*types.PreflightReport, error) {
+	res, err := run(ctx, types.PreflightOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		return runPreflight(ctx, p, cluster, provider, ops)
+	})
+	report, _ := res.(*types.PreflightReport)
+	return report, err
+}
+
+// runPreflight validates the inputs with the provisioner and checks the cluster with the preflight checker of its provider set in the options.
+func runPreflight(ctx context.Context, p Provisioner, cluster *types.Cluster, provider *types.Provider, ops []types.Option) (*types.PreflightReport, error) {
+	if err := p.ValidateInputs(cluster, provider); err != nil {
+		return nil, err
 	}
 
-	switch provider.Type {
-	case types.GCP:
-		cs, err = newGCPProvisioner(provisioningOperator, ops...).Status(cluster, provider)
-	case types.Gardener:
-		cs, err = newGardenerProvisioner(provisioningOperator, ops...).Status(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		cs, err = newAzureProvisioner(provisioningOperator, ops...).Status(cluster, provider)
-	case types.Kind:
-		cs, err = newKindProvisioner(provisioningOperator, ops...).Status(cluster, provider)
-	default:
-		err = errors.New("unknown provider")
+	options := &types.Options{}
+	for _, o := range ops {
+		o(options)
 	}
+	return preflight.Run(ctx, options.PreflightCheckers[provider.Type], cluster, provider, options.MaxMonthlyCost)
+}
 
+// checkPreflight works like runPreflight but returns an error listing all problems found.
+func checkPreflight(ctx context.Context, p Provisioner, cluster *types.Cluster, provider *types.Provider, ops []types.Option) error {
+	report, err := runPreflight(ctx, p, cluster, provider, ops)
 	if err != nil {
-		return cs, err
+		return err
 	}
-	return cs, action.After()
+	return report.Err()
 }
 
-// Credentials returns the kubeconfig for a specific cluster as a byte array.
-func Credentials(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) ([]byte, error) {
-	var err error
-	var cr []byte
+// Deprovision removes an existing cluster along or returns an error if removing the cluster is not possible.
+func Deprovision(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) error {
+	return DeprovisionWithContext(context.Background(), cluster, provider, ops...)
+}
 
-	if err = action.Before(); err != nil {
-		return cr, err
+// DeprovisionWithContext works like Deprovision but stops as soon as ctx is canceled or its deadline is exceeded.
+// An interrupted deprovisioning keeps the cluster files in the data directory so that calling Deprovision again resumes it.
+// If the cluster is deprovisioned but the audit sinks cannot record it, a types.AuditError is returned, and the cluster is gone nonetheless.
+func DeprovisionWithContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, ops ...types.Option) error {
+	_, err := run(ctx, types.DeprovisionOperation, cluster, provider, ops, func(p Provisioner) (interface{}, error) {
+		return nil, p.Deprovision(ctx, cluster, provider)
+	})
+	return err
+}
+
+// run runs an operation with the provisioner of the given provider, along with the actions set in the action package and the actions passed as options.
+// The actions passed as options receive an *types.Event describing the operation.
+func run(ctx context.Context, op types.Operation, cluster *types.Cluster, provider *types.Provider, ops []types.Option, f func(p Provisioner) (interface{}, error)) (interface{}, error) {
+	options := &types.Options{}
+	for _, o := range ops {
+		o(options)
 	}
+	event := &types.Event{
+		Operation: op,
+		Cluster:   cluster,
+		Provider:  provider,
+	}
+	started := time.Now()
 
-	if runtime.GOOS == "windows" {
-		provider.CredentialsFilePath = updateWindowsPath(provider.CredentialsFilePath)
+	res, err := func() (interface{}, error) {
+		if err := action.Before(); err != nil {
+			return nil, err
+		}
+		if err := runActions(options.BeforeActions, event); err != nil {
+			return nil, err
+		}
+
+		p, err := newProvisioner(provider, ops...)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := f(p)
+		if err != nil {
+			return res, err
+		}
+
+		event.Result = res
+		if err := runActions(options.AfterActions, event); err != nil {
+			return res, err
+		}
+		return res, action.After()
+	}()
+
+	if err != nil {
+		event.Result = res
+		event.Err = err
+		if actionErr := runActions(options.ErrorActions, event); actionErr != nil {
+			err = fmt.Errorf("%w\nthe error actions failed as well: %v", err, actionErr)
+		}
 	}
 
-	switch provider.Type {
-	case types.GCP:
-		cr, err = newGCPProvisioner(provisioningOperator, ops...).Credentials(cluster, provider)
-	case types.Gardener:
-		cr, err = newGardenerProvisioner(provisioningOperator, ops...).Credentials(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		cr, err = newAzureProvisioner(provisioningOperator, ops...).Credentials(cluster, provider)
-	case types.Kind:
-		cr, err = newKindProvisioner(provisioningOperator, ops...).Credentials(cluster, provider)
-	default:
-		err = errors.New("unknown provider")
+	if auditErr := audit(options, event, started); auditErr != nil {
+		if err != nil {
+			return res, fmt.Errorf("%w\nrecording the operation in the audit sinks failed as well: %v", err, auditErr)
+		}
+		return res, &types.AuditError{Operation: op, Err: auditErr}
 	}
+	return res, err
+}
 
-	if err != nil {
-		return cr, err
+// runActions runs the given actions in order with the event as their only argument, and stops at the first error.
+func runActions(actions []action.Action, event *types.Event) error {
+	for _, a := range actions {
+		if _, err := a.Run(event); err != nil {
+			return err
+		}
 	}
-	return cr, action.After()
+	return nil
 }
 
-// Deprovision removes an existing cluster along or returns an error if removing the cluster is not possible.
-func Deprovision(cluster *types.Cluster, provider *types.Provider, ops ...types.Option) error {
+// checkHealth connects to the provisioned cluster to run the health checks and sets the status phase according to their results.
+// It prefers the admin credentials, so that the checks do not depend on the command line tools of the provider.
+// If there are no working credentials for the cluster, the phase is kept and the API server reachability is unknown.
+func checkHealth(ctx context.Context, p Provisioner, cluster *types.Cluster, provider *types.Provider, cs *types.ClusterStatus) {
+	var kubeconfig []byte
 	var err error
-
-	if err = action.Before(); err != nil {
-		return err
+	if ac, ok := p.(adminCredentialer); ok {
+		kubeconfig, err = ac.AdminCredentials(ctx, cluster, provider)
+	} else {
+		kubeconfig, err = p.Credentials(ctx, cluster, provider)
 	}
+	if err != nil {
+		cs.Conditions = []types.Condition{{
+			Type:    types.APIServerReachable,
+			Status:  types.ConditionUnknown,
+			Reason:  "CredentialsUnavailable",
+			Message: err.Error(),
+		}}
+		return
+	}
+
+	cs.Conditions = health.NewChecker().Check(ctx, kubeconfig)
+	cs.Phase = health.Phase(cs.Conditions)
+}
 
+// newProvisioner returns the Provisioner for the type of the given provider.
+func newProvisioner(provider *types.Provider, ops ...types.Option) (Provisioner, error) {
 	if runtime.GOOS == "windows" {
 		provider.CredentialsFilePath = updateWindowsPath(provider.CredentialsFilePath)
 	}
 
+	if provider.Config != nil {
+		if err := applyConfig(provider); err != nil {
+			return nil, err
+		}
+	}
+
+	options := &types.Options{}
+	for _, o := range ops {
+		o(options)
+	}
+	if options.NativeOperator && provider.Type != types.Gardener && provider.Type != types.K3d {
+		return nil, fmt.Errorf("the native operator does not support the %s provider", provider.Type)
+	}
+
 	switch provider.Type {
 	case types.GCP:
-		err = newGCPProvisioner(provisioningOperator, ops...).Deprovision(cluster, provider)
+		return newGCPProvisioner(provisioningOperator, ops...), nil
 	case types.Gardener:
-		err = newGardenerProvisioner(provisioningOperator, ops...).Deprovision(cluster, provider)
+		if options.NativeOperator {
+			return newGardenerProvisioner(operator.NativeOperator, ops...), nil
+		}
+		return newGardenerProvisioner(provisioningOperator, ops...), nil
 	case types.AWS:
-		err = errors.New("aws not supported yet")
+		return newAWSProvisioner(provisioningOperator, ops...), nil
 	case types.Azure:
-		err = newAzureProvisioner(provisioningOperator, ops...).Deprovision(cluster, provider)
+		return newAzureProvisioner(provisioningOperator, ops...), nil
 	case types.Kind:
-		err = newKindProvisioner(provisioningOperator, ops...).Deprovision(cluster, provider)
+		return newKindProvisioner(provisioningOperator, ops...), nil
+	case types.K3d:
+		// k3d has no terraform provider, its clusters are always managed natively
+		return newK3dProvisioner(operator.NativeOperator, ops...), nil
 	default:
-		err = errors.New("unknown provider")
-	}
-	if err != nil {
-		return err
+		return nil, errors.New("unknown provider")
 	}
-	return action.After()
 }
 
 func newGCPProvisioner(operatorType operator.Type, ops ...types.Option) Provisioner {
@@ -170,7 +389,7 @@ func newGardenerProvisioner(operatorType operator.Type, ops ...types.Option) Pro
 }
 
 func newAWSProvisioner(operatorType operator.Type, ops ...types.Option) Provisioner {
-	return nil
+	return aws.New(operatorType, ops...)
 }
 
 func newAzureProvisioner(operatorType operator.Type, ops ...types.Option) Provisioner {
@@ -181,7 +400,32 @@ func newKindProvisioner(operatorType operator.Type, ops ...types.Option) Provisi
 	return kind.New(operatorType, ops...)
 }
 
+func newK3dProvisioner(operatorType operator.Type, ops ...types.Option) Provisioner {
+	return k3d.New(operatorType, ops...)
+}
+
 func updateWindowsPath(windowsPath string) string {
 	cleanWindowsPath := filepath.Clean(windowsPath)
 	return strings.Replace(cleanWindowsPath, `\`, `\\`, -1)
 }
+
+// applyConfig adds the custom configurations of the typed configuration of the provider to its custom configurations.
+func applyConfig(provider *types.Provider) error {
+	if t := provider.Config.ProviderType(); t != provider.Type {
+		return fmt.Errorf("the configuration of type %T is meant for the %s provider, not %s", provider.Config, t, provider.Type)
+	}
+	cfg, err := providerconfig.ToMap(provider.Config)
+	if err != nil {
+		return err
+	}
+
+	custom := make(map[string]interface{}, len(provider.CustomConfigurations)+len(cfg))
+	for k, v := range provider.CustomConfigurations {
+		custom[k] = v
+	}
+	for k, v := range cfg {
+		custom[k] = v
+	}
+	provider.CustomConfigurations = custom
+	return nil
+}
@@ -0,0 +1,312 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/provision/action"
+	"github.com/kyma-incubator/hydroform/provision/preflight"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionOptions(t *testing.T) {
+	t.Parallel()
+	cluster, provider := awsCluster("hydro-cluster")
+
+	var events []types.Event
+	record := action.FuncAction(func(args ...interface{}) (interface{}, error) {
+		events = append(events, *args[0].(*types.Event))
+		return nil, nil
+	})
+
+	kubeconfig, err := Credentials(cluster, provider,
+		types.WithBeforeAction(record),
+		types.WithAfterAction(record),
+		types.OnError(record),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2, "The before and after actions should run, but not the error action")
+	require.Equal(t, types.CredentialsOperation, events[0].Operation)
+	require.Equal(t, cluster, events[0].Cluster)
+	require.Equal(t, provider, events[0].Provider)
+	require.Nil(t, events[0].Result, "The before action should not receive a result")
+	require.Equal(t, kubeconfig, events[1].Result, "The after action should receive the result of the operation")
+
+	// a failing before action stops the operation and triggers the error actions
+	events = nil
+	_, err = Credentials(cluster, provider,
+		types.WithBeforeAction(action.FuncAction(func(args ...interface{}) (interface{}, error) {
+			return nil, errors.New("not allowed")
+		})),
+		types.WithAfterAction(record),
+		types.OnError(record),
+	)
+	require.EqualError(t, err, "not allowed")
+	require.Len(t, events, 1, "Only the error action should run")
+	require.EqualError(t, events[0].Err, "not allowed")
+
+	// combinators work as per-call actions
+	events = nil
+	_, err = Status(cluster, &types.Provider{Type: "unknown"}, types.OnError(action.Sequence{record, record}))
+	require.Error(t, err)
+	require.Len(t, events, 2, "All actions of the sequence should run")
+	require.Equal(t, types.StatusOperation, events[1].Operation)
+}
+
+func TestConcurrentActionOptions(t *testing.T) {
+	t.Parallel()
+	wg := sync.WaitGroup{}
+	for _, name := range []string{"cluster-a", "cluster-b", "cluster-c"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			cluster, provider := awsCluster(name)
+
+			var seen []string
+			record := action.FuncAction(func(args ...interface{}) (interface{}, error) {
+				seen = append(seen, args[0].(*types.Event).Cluster.Name)
+				return nil, nil
+			})
+
+			for i := 0; i < 10; i++ {
+				_, err := Credentials(cluster, provider, types.WithBeforeAction(record), types.WithAfterAction(record))
+				require.NoError(t, err)
+			}
+
+			require.Len(t, seen, 20, "The actions of each call should run exactly once")
+			for _, s := range seen {
+				require.Equal(t, name, s, "Actions should only see their own cluster")
+			}
+		}(name)
+	}
+	wg.Wait()
+}
+
+// auditRecorder is an in-memory types.AuditSink.
+type auditRecorder struct {
+	records []*types.AuditRecord
+	err     error
+}
+
+func (a *auditRecorder) Record(ctx context.Context, r *types.AuditRecord) error {
+	a.records = append(a.records, r)
+	return a.err
+}
+
+func TestAuditSinks(t *testing.T) {
+	t.Parallel()
+	cluster, provider := awsCluster("hydro-cluster")
+	provider.CustomConfigurations = map[string]interface{}{
+		"secret_access_key": "s3cr3t",
+		"zones":             []string{"eu-central-1a"},
+		"tags":              map[string]string{"team": "hydro", "api_token": "t0k3n"},
+	}
+
+	sink := &auditRecorder{}
+	_, err := Credentials(cluster, provider, types.WithAuditSink(sink), types.WithAuditActor("alice"))
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 1)
+	r := sink.records[0]
+	require.Equal(t, types.CredentialsOperation, r.Operation)
+	require.Equal(t, "alice", r.Actor)
+	require.Equal(t, types.AuditSucceeded, r.Outcome)
+	require.Empty(t, r.Error)
+	require.False(t, r.Time.IsZero())
+	require.Equal(t, "hydro-cluster", r.Cluster.Name)
+	require.Nil(t, r.Cluster.ClusterInfo, "The cluster info should not be recorded")
+	require.Equal(t, "/path/to/credentials", r.Provider.CredentialsFilePath)
+	require.Equal(t, map[string]interface{}{
+		"secret_access_key": "REDACTED",
+		"zones":             []string{"eu-central-1a"},
+		"tags":              map[string]string{"team": "hydro", "api_token": "REDACTED"},
+	}, r.Provider.CustomConfigurations, "Secrets should be redacted")
+	require.Equal(t, "s3cr3t", provider.CustomConfigurations["secret_access_key"], "The provider should not be modified")
+	require.NotNil(t, cluster.ClusterInfo, "The cluster should not be modified")
+
+	// failed operations are recorded, along with the errors of the sinks
+	sink = &auditRecorder{err: errors.New("disk full")}
+	_, err = Status(cluster, &types.Provider{Type: "unknown"}, types.WithAuditSink(sink))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown provider")
+	require.Contains(t, err.Error(), "disk full")
+	require.Len(t, sink.records, 1)
+	require.Equal(t, types.AuditFailed, sink.records[0].Outcome)
+	require.Equal(t, "unknown provider", sink.records[0].Error)
+	require.NotEmpty(t, sink.records[0].Actor, "The user running the process should be the default actor")
+
+	var auditErr *types.AuditError
+	require.False(t, errors.As(err, &auditErr), "The error of a failed operation should not be an audit error")
+
+	// a failing sink fails a successful operation, which still returns its result
+	creds, err := Credentials(cluster, provider, types.WithAuditSink(&auditRecorder{err: errors.New("disk full")}))
+	require.EqualError(t, err, "could not record the operation in the audit sinks: disk full")
+	require.True(t, errors.As(err, &auditErr), "The error should be an audit error")
+	require.Equal(t, types.CredentialsOperation, auditErr.Operation)
+	require.NotNil(t, creds, "The result of the operation should not be lost")
+}
+
+// awsCluster returns a provisioned AWS cluster, whose credentials can be created without any request to the provider.
+func awsCluster(name string) (*types.Cluster, *types.Provider) {
+	return &types.Cluster{
+		Name:              name,
+		KubernetesVersion: "1.17",
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+		ClusterInfo: &types.ClusterInfo{
+			Endpoint:                 "https://cluster-url.fake",
+			CertificateAuthorityData: []byte("My cert"),
+		},
+	}, &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	t.Parallel()
+	provider := &types.Provider{
+		Type: types.Kind,
+		CustomConfigurations: map[string]interface{}{
+			"node_image": "kindest/node:v1.16.0",
+			"other":      "value",
+		},
+		Config: types.KindConfig{NodeImage: "kindest/node:v1.17.0"},
+	}
+	custom := provider.CustomConfigurations
+
+	require.NoError(t, applyConfig(provider))
+	require.Equal(t, map[string]interface{}{
+		"node_image":          "kindest/node:v1.17.0",
+		"control_plane_nodes": 1,
+		"extra_port_mappings": []string{},
+		"feature_gates":       []string{},
+		"other":               "value",
+	}, provider.CustomConfigurations, "The typed configuration should take precedence over the custom configurations")
+	require.Equal(t, "kindest/node:v1.16.0", custom["node_image"], "The given custom configurations should not be changed")
+
+	provider.Config = types.GardenerGCPConfig{}
+	err := applyConfig(provider)
+	require.Error(t, err, "A configuration of another provider should fail")
+	require.Contains(t, err.Error(), "meant for the gardener provider, not kind")
+}
+
+func TestNativeOperator(t *testing.T) {
+	t.Parallel()
+
+	_, provider := awsCluster("hydro-cluster")
+	_, err := newProvisioner(provider, types.WithNativeOperator())
+	require.Error(t, err, "Only Gardener supports the native operator")
+	require.Contains(t, err.Error(), "does not support the aws provider")
+
+	p, err := newProvisioner(&types.Provider{Type: types.Gardener}, types.WithNativeOperator())
+	require.NoError(t, err)
+	require.NotNil(t, p)
+}
+
+func TestCredentialsOptions(t *testing.T) {
+	t.Parallel()
+	cluster, provider := awsCluster("hydro-cluster")
+
+	kc, err := Credentials(cluster, provider, types.WithExecAuth(types.ExecAuth{Command: "aws-iam-authenticator", Args: []string{"token"}}))
+	require.NoError(t, err)
+	require.Contains(t, string(kc), "aws-iam-authenticator", "The credential plugin should replace the one of the provider")
+	require.NotContains(t, string(kc), "get-token")
+
+	_, err = Credentials(cluster, provider,
+		types.WithExecAuth(types.ExecAuth{Command: "aws-iam-authenticator"}),
+		types.WithServiceAccount(types.ServiceAccount{Name: "ci", ClusterRole: "view"}),
+	)
+	require.Error(t, err, "The service account and exec auth options should exclude each other")
+}
+
+func TestCheckHealthWithAdminCredentials(t *testing.T) {
+	t.Parallel()
+	cluster, provider := awsCluster("hydro-cluster")
+	p := &adminProvisioner{err: errors.New("no token")}
+	cs := &types.ClusterStatus{Phase: types.Provisioned}
+
+	checkHealth(context.Background(), p, cluster, provider, cs)
+
+	require.True(t, p.called, "The health checks should use the admin credentials")
+	require.Equal(t, types.Provisioned, cs.Phase, "Missing credentials should not change the phase")
+	require.Equal(t, types.ConditionUnknown, cs.Conditions[0].Status)
+	require.Equal(t, "CredentialsUnavailable", cs.Conditions[0].Reason)
+}
+
+// adminProvisioner is a Provisioner whose only working method is AdminCredentials.
+type adminProvisioner struct {
+	Provisioner
+	called bool
+	err    error
+}
+
+func (p *adminProvisioner) AdminCredentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	p.called = true
+	return nil, p.err
+}
+
+func TestPreflight(t *testing.T) {
+	t.Parallel()
+	checker := &preflight.Static{
+		Versions: []string{"1.17.9-gke.1504", "1.18.6-gke.3504"},
+		Machines: map[string][]string{"europe-west3": {"n1-standard-4"}},
+		Cost:     &types.CostEstimate{Currency: "USD", Hourly: 0.5},
+	}
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+	ops := []types.Option{types.WithPreflightChecker(types.GCP, checker)}
+
+	report, err := Preflight(cluster, provider, ops...)
+	require.NoError(t, err)
+	require.Len(t, report.Problems, 2, "The version and the machine type should be reported at once")
+	require.Equal(t, 0.5, report.Cost.Hourly)
+
+	_, err = Provision(cluster, provider, ops...)
+	require.Error(t, err, "Provision should fail before running the operator")
+	require.Contains(t, err.Error(), "Kubernetes 1.12 is not supported")
+	_, err = Update(cluster, provider, ops...)
+	require.Error(t, err, "Update should fail before running the operator")
+	require.Contains(t, err.Error(), "Kubernetes 1.12 is not supported")
+
+	_, err = Preflight(&types.Cluster{}, provider, ops...)
+	require.Error(t, err, "Invalid inputs should fail before the checks")
+	require.Contains(t, err.Error(), "input validation failed")
+
+	cluster.KubernetesVersion = "1.17"
+	cluster.MachineType = "n1-standard-4"
+	report, err = Preflight(cluster, provider, ops...)
+	require.NoError(t, err)
+	require.Empty(t, report.Problems)
+
+	report, err = Preflight(cluster, provider, append(ops, types.WithMaxMonthlyCost(100))...)
+	require.NoError(t, err)
+	require.Len(t, report.Problems, 1, "The estimated cost should exceed the limit")
+}
+
+func TestUpdateRejected(t *testing.T) {
+	t.Parallel()
+	checker := &preflight.Static{Versions: []string{"1.20"}}
+	cluster := &types.Cluster{Name: "hydro-cluster", KubernetesVersion: "1.17"}
+
+	_, err := Update(cluster, &types.Provider{Type: types.K3d}, types.WithPreflightChecker(types.K3d, checker))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "k3d clusters cannot be updated", "k3d should reject the update without checking the cluster")
+}
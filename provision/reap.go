@@ -0,0 +1,28 @@
+package provision
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/operator/terraform"
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+// Reap deprovisions all clusters whose ExpiresAt has passed. It finds the clusters in the state backend, or in the data directory if no state backend is set.
+// Pass the DryRun option to only list the expired clusters. The returned report lists all clusters found, whether they expired or not.
+// Only clusters provisioned, updated, or imported with Terraform are found, and with the data directory only if it is persistent.
+// Reap reads the expiry from that metadata, not from the labels of the resources, which Azure and Kind clusters do not carry.
+// The failure to deprovision one cluster does not stop the others, the returned error lists all failures.
+func Reap(ops ...types.Option) (*types.ReapReport, error) {
+	return ReapWithContext(context.Background(), ops...)
+}
+
+// ReapWithContext works like Reap but stops as soon as ctx is canceled or its deadline is exceeded.
+func ReapWithContext(ctx context.Context, ops ...types.Option) (*types.ReapReport, error) {
+	os := &types.Options{}
+	for _, o := range ops {
+		o(os)
+	}
+
+	return terraform.New(terraform.ToTerraformOptions(os)...).Reap(ctx, time.Now(), os.DryRun)
+}
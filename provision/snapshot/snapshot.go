@@ -0,0 +1,125 @@
+// Package snapshot encodes the cluster returned by Provision, including its Terraform state, into a portable blob, for example to store it in a database.
+// Decode the blob to pass the cluster to Status, Credentials, Update, or Deprovision later on, from any process.
+//
+// The Terraform state holds the credentials of some providers, so encrypt the blob with a key unless it is stored safely.
+package snapshot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	apiVersion = "hydroform.kyma-project.io/v1alpha1"
+	kind       = "ClusterSnapshot"
+	encryption = "AES-GCM"
+)
+
+// envelope is the JSON representation of a snapshot.
+type envelope struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Cluster is only set if the snapshot is not encrypted.
+	Cluster *types.Cluster `json:"cluster,omitempty"`
+	// Encryption, Nonce, and Data are only set if the snapshot is encrypted, Data being the encrypted JSON of the cluster.
+	Encryption string `json:"encryption,omitempty"`
+	Nonce      []byte `json:"nonce,omitempty"`
+	Data       []byte `json:"data,omitempty"`
+}
+
+// Encode returns a JSON blob with the cluster, including its Terraform state.
+// If key is nil, the cluster can be read from the blob. Otherwise, it is encrypted with AES-GCM,
+// and the key has to be 16, 24, or 32 bytes long to use AES-128, AES-192, or AES-256.
+func Encode(cluster *types.Cluster, key []byte) ([]byte, error) {
+	if cluster == nil {
+		return nil, errors.New("there is no cluster to encode")
+	}
+
+	e := envelope{
+		APIVersion: apiVersion,
+		Kind:       kind,
+	}
+	if key == nil {
+		e.Cluster = cluster
+		return json.Marshal(e)
+	}
+
+	data, err := json.Marshal(cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode the cluster")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	e.Encryption = encryption
+	e.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, e.Nonce); err != nil {
+		return nil, errors.Wrap(err, "could not generate a nonce")
+	}
+	e.Data = gcm.Seal(nil, e.Nonce, data, additionalData())
+	return json.Marshal(e)
+}
+
+// Decode returns the cluster of a blob created by Encode. The key has to be the one the blob was encrypted with, or nil if it is not encrypted.
+func Decode(blob []byte, key []byte) (*types.Cluster, error) {
+	var e envelope
+	if err := json.Unmarshal(blob, &e); err != nil {
+		return nil, errors.Wrap(err, "could not decode the snapshot")
+	}
+	if e.APIVersion != apiVersion || e.Kind != kind {
+		return nil, errors.Errorf("the blob is no %s snapshot of version %s", kind, apiVersion)
+	}
+
+	switch {
+	case e.Encryption == "" && key != nil:
+		// an unencrypted snapshot could have been put in place of the expected one
+		return nil, errors.New("the snapshot is not encrypted, but a key was given")
+	case e.Encryption == "":
+		if e.Cluster == nil {
+			return nil, errors.New("the snapshot has no cluster")
+		}
+		return e.Cluster, nil
+	case e.Encryption != encryption:
+		return nil, errors.Errorf("unsupported encryption %q", e.Encryption)
+	case key == nil:
+		return nil, errors.New("the snapshot is encrypted, a key is needed to decode it")
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("the nonce of the snapshot is invalid")
+	}
+	data, err := gcm.Open(nil, e.Nonce, e.Data, additionalData())
+	if err != nil {
+		return nil, errors.New("could not decrypt the snapshot, the key is wrong or the snapshot was modified")
+	}
+
+	cluster := &types.Cluster{}
+	if err := json.Unmarshal(data, cluster); err != nil {
+		return nil, errors.Wrap(err, "could not decode the cluster")
+	}
+	return cluster, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "the key has to be 16, 24, or 32 bytes long")
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the encrypted data to the format of the snapshot.
+func additionalData() []byte {
+	return []byte(apiVersion + "/" + kind)
+}
@@ -0,0 +1,154 @@
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statefile"
+	"github.com/kyma-incubator/hydroform/provision"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testCluster() *types.Cluster {
+	state := states.BuildState(func(s *states.SyncState) {
+		s.SetOutputValue(addrs.RootModuleInstance.OutputValue("endpoint"), cty.StringVal("https://cluster-url.fake"), false)
+		s.SetOutputValue(addrs.RootModuleInstance.OutputValue("password"), cty.StringVal("secret"), true)
+	})
+
+	return &types.Cluster{
+		Name:              "hydro",
+		KubernetesVersion: "1.17",
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+		ClusterInfo: &types.ClusterInfo{
+			Endpoint:                 "https://cluster-url.fake",
+			CertificateAuthorityData: []byte("My cert"),
+			InternalState:            &types.InternalState{TerraformState: statefile.New(state, "lineage", 3)},
+			Status:                   &types.ClusterStatus{Phase: types.Provisioned},
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		key  []byte
+	}{
+		{name: "plain"},
+		{name: "AES-128", key: bytes.Repeat([]byte{1}, 16)},
+		{name: "AES-256", key: bytes.Repeat([]byte{2}, 32)},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			cluster := testCluster()
+			version := cluster.ClusterInfo.InternalState.TerraformState.TerraformVersion
+
+			blob, err := Encode(cluster, c.key)
+			require.NoError(t, err)
+			if c.key != nil {
+				require.NotContains(t, string(blob), "cluster-url.fake", "The cluster should be encrypted")
+			}
+			require.Same(t, version, cluster.ClusterInfo.InternalState.TerraformState.TerraformVersion, "Encoding should not change the state of the cluster")
+
+			decoded, err := Decode(blob, c.key)
+			require.NoError(t, err)
+			require.Equal(t, cluster.Name, decoded.Name)
+			require.Equal(t, cluster.MachineType, decoded.MachineType)
+			require.Equal(t, cluster.ClusterInfo.Endpoint, decoded.ClusterInfo.Endpoint)
+			require.Equal(t, cluster.ClusterInfo.CertificateAuthorityData, decoded.ClusterInfo.CertificateAuthorityData)
+			require.Equal(t, cluster.ClusterInfo.Status, decoded.ClusterInfo.Status)
+
+			sf := decoded.ClusterInfo.InternalState.TerraformState
+			require.Equal(t, "lineage", sf.Lineage)
+			require.Equal(t, uint64(3), sf.Serial)
+			require.True(t, statefile.StatesMarshalEqual(cluster.ClusterInfo.InternalState.TerraformState.State, sf.State), "The state should be decoded as it was")
+			require.True(t, sf.State.RootModule().OutputValues["password"].Sensitive)
+		})
+	}
+}
+
+func TestEncodeWithoutState(t *testing.T) {
+	t.Parallel()
+
+	cluster := testCluster()
+	cluster.ClusterInfo.InternalState = nil
+	blob, err := Encode(cluster, nil)
+	require.NoError(t, err)
+	decoded, err := Decode(blob, nil)
+	require.NoError(t, err)
+	require.Nil(t, decoded.ClusterInfo.InternalState)
+
+	cluster.ClusterInfo = nil
+	blob, err = Encode(cluster, nil)
+	require.NoError(t, err)
+	decoded, err = Decode(blob, nil)
+	require.NoError(t, err)
+	require.Equal(t, cluster, decoded, "A cluster that is not provisioned yet should be decoded as well")
+
+	_, err = Encode(nil, nil)
+	require.Error(t, err)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{1}, 32)
+	encrypted, err := Encode(testCluster(), key)
+	require.NoError(t, err)
+	plain, err := Encode(testCluster(), nil)
+	require.NoError(t, err)
+	modified := bytes.Replace(encrypted, []byte(`"data":"`), []byte(`"data":"AAAA`), 1)
+
+	cases := []struct {
+		name string
+		blob []byte
+		key  []byte
+		err  string
+	}{
+		{name: "wrong key", blob: encrypted, key: bytes.Repeat([]byte{2}, 32), err: "the key is wrong or the snapshot was modified"},
+		{name: "modified", blob: modified, key: key, err: "the key is wrong or the snapshot was modified"},
+		{name: "missing key", blob: encrypted, err: "a key is needed"},
+		{name: "invalid key", blob: encrypted, key: []byte("short"), err: "the key has to be 16, 24, or 32 bytes long"},
+		{name: "not encrypted", blob: plain, key: key, err: "the snapshot is not encrypted"},
+		{name: "no snapshot", blob: []byte(`{"name": "hydro"}`), err: "the blob is no ClusterSnapshot"},
+		{name: "no JSON", blob: []byte("hydro"), err: "could not decode the snapshot"},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Decode(c.blob, c.key)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.err)
+		})
+	}
+}
+
+func TestDecodedClusterCredentials(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{1}, 24)
+	blob, err := Encode(testCluster(), key)
+	require.NoError(t, err)
+	cluster, err := Decode(blob, key)
+	require.NoError(t, err)
+
+	kubeconfig, err := provision.Credentials(cluster, &types.Provider{
+		Type:                types.AWS,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	})
+	require.NoError(t, err)
+	require.Contains(t, string(kubeconfig), "https://cluster-url.fake", "The decoded cluster should be usable by later operations")
+}
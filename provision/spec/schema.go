@@ -0,0 +1,103 @@
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/providerconfig"
+	"github.com/kyma-incubator/hydroform/provision/types"
+)
+
+// valueType is the type a custom configuration value must have.
+type valueType string
+
+const (
+	stringType     valueType = "string"
+	intType        valueType = "integer"
+	boolType       valueType = "boolean"
+	stringListType valueType = "list of strings"
+)
+
+// configs lists the typed configurations of each provider, whose config tags are the custom configurations the provider supports.
+// The other provider variables are taken from the cluster and provider fields.
+var configs = map[types.ProviderType][]types.ProviderConfig{
+	types.GCP:      nil,
+	types.Azure:    {types.AzureConfig{}},
+	types.AWS:      {types.AWSConfig{}},
+	types.Gardener: {types.GardenerGCPConfig{}, types.GardenerAzureConfig{}, types.GardenerAWSConfig{}},
+	types.Kind:     {types.KindConfig{}},
+	types.K3d:      {types.K3dConfig{}},
+}
+
+// schemas lists the custom configurations supported by each provider and their types.
+var schemas = func() map[types.ProviderType]map[string]valueType {
+	valueTypes := map[reflect.Kind]valueType{
+		reflect.String: stringType,
+		reflect.Int:    intType,
+		reflect.Bool:   boolType,
+		reflect.Slice:  stringListType,
+	}
+
+	res := make(map[types.ProviderType]map[string]valueType, len(configs))
+	for p, cfgs := range configs {
+		schema := map[string]valueType{}
+		for key, kind := range providerconfig.Schema(cfgs...) {
+			schema[key] = valueTypes[kind]
+		}
+		res[p] = schema
+	}
+	return res
+}()
+
+// convert checks that the decoded value has the type and returns it as the Go type the provisioners expect.
+func (t valueType) convert(v interface{}) (interface{}, bool) {
+	switch t {
+	case stringType:
+		s, ok := v.(string)
+		return s, ok
+	case intType:
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		i, err := n.Int64()
+		return int(i), err == nil
+	case boolType:
+		b, ok := v.(bool)
+		return b, ok
+	case stringListType:
+		l, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		list := make([]string, 0, len(l))
+		for _, e := range l {
+			s, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, s)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+func providerTypes() []string {
+	var list []string
+	for p := range schemas {
+		list = append(list, string(p))
+	}
+	sort.Strings(list)
+	return list
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
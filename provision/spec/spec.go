@@ -0,0 +1,192 @@
+// Package spec reads clusters from declarative YAML or JSON files, so that they can be kept in version control and reviewed like any other change.
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/internal/errs"
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the version of the spec format supported by this package.
+	APIVersion = "hydroform.kyma-project.io/v1alpha1"
+	// Kind is the kind of document describing a cluster.
+	Kind = "Cluster"
+)
+
+// Spec describes a cluster, the provider it runs on, and the options used to manage it.
+type Spec struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Cluster    *types.Cluster  `json:"cluster"`
+	Provider   *types.Provider `json:"provider"`
+	Options    *Options        `json:"options,omitempty"`
+}
+
+// Options contains the Hydroform options that can be set in a spec.
+type Options struct {
+	DataDir      string              `json:"dataDir,omitempty"`
+	Persistent   bool                `json:"persistent,omitempty"`
+	Verbose      bool                `json:"verbose,omitempty"`
+	Timeouts     *Timeouts           `json:"timeouts,omitempty"`
+	StateBackend *types.StateBackend `json:"stateBackend,omitempty"`
+	// Modules replace the terraform configuration of the clusters of a provider, by provider type.
+	Modules map[types.ProviderType]types.ClusterModule `json:"modules,omitempty"`
+	// ProviderMirror makes terraform use local provider plugins instead of downloading them.
+	ProviderMirror *types.ProviderMirror `json:"providerMirror,omitempty"`
+}
+
+// Timeouts specifies the timeouts of the operations, written as durations such as "30m".
+type Timeouts struct {
+	Create Duration `json:"create,omitempty"`
+	Update Duration `json:"update,omitempty"`
+	Delete Duration `json:"delete,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "1h30m".
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration from a string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Errorf("durations must be strings such as \"30m\", got %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Load reads the spec in the given YAML or JSON file.
+func Load(path string) (*Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the spec file")
+	}
+	s, err := Parse(data)
+	return s, errors.Wrapf(err, "invalid spec file %s", path)
+}
+
+// Parse reads a spec from YAML or JSON and validates it.
+// Unknown fields are rejected, and the custom configurations of the provider are checked against the keys and types the provider supports,
+// then converted to the Go types Hydroform expects, so the returned cluster and provider can be passed to the provision functions as they are.
+func Parse(data []byte) (*Spec, error) {
+	s := &Spec{}
+	// numbers are kept as json.Number so that integers can be told apart from other numbers
+	if err := yaml.UnmarshalStrict(data, s, useNumber); err != nil {
+		return nil, errors.Wrap(err, "could not decode the spec")
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Ops returns the Hydroform options set in the spec. Options not available in specs, such as actions, can be appended to them.
+func (s *Spec) Ops() []types.Option {
+	if s.Options == nil {
+		return nil
+	}
+
+	var ops []types.Option
+	if s.Options.DataDir != "" {
+		ops = append(ops, types.WithDataDir(s.Options.DataDir))
+	}
+	if s.Options.Persistent {
+		ops = append(ops, types.Persistent())
+	}
+	if s.Options.Verbose {
+		ops = append(ops, types.Verbose(true))
+	}
+	if t := s.Options.Timeouts; t != nil {
+		ops = append(ops, types.WithTimeouts(&types.Timeouts{
+			Create: time.Duration(t.Create),
+			Update: time.Duration(t.Update),
+			Delete: time.Duration(t.Delete),
+		}))
+	}
+	if s.Options.StateBackend != nil {
+		ops = append(ops, types.WithStateBackend(s.Options.StateBackend))
+	}
+	for p, m := range s.Options.Modules {
+		ops = append(ops, types.WithClusterModule(p, m))
+	}
+	if s.Options.ProviderMirror != nil {
+		ops = append(ops, types.WithProviderMirror(*s.Options.ProviderMirror))
+	}
+	return ops
+}
+
+// validate checks the spec and converts the custom configurations of the provider to their Go types.
+func (s *Spec) validate() error {
+	var errMessage string
+
+	if s.APIVersion != APIVersion {
+		errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("apiVersion has to be %s, got %q", APIVersion, s.APIVersion))
+	}
+	if s.Kind != Kind {
+		errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("kind has to be %s, got %q", Kind, s.Kind))
+	}
+
+	if s.Cluster == nil {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "cluster")
+	} else if s.Cluster.ClusterInfo != nil {
+		errMessage += fmt.Sprintf(errs.Custom, "cluster.clusterInfo is set by Hydroform and cannot be part of a spec")
+	}
+
+	if s.Provider == nil {
+		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "provider")
+	} else {
+		errMessage += s.validateCustomConfigurations()
+	}
+
+	if errMessage != "" {
+		return errors.New("spec validation failed with the following information: " + errMessage)
+	}
+	return nil
+}
+
+// validateCustomConfigurations checks the custom configurations against the schema of the provider, replacing each value by its converted value.
+// It returns the validation messages, or an empty string if the configurations are valid.
+func (s *Spec) validateCustomConfigurations() string {
+	schema, ok := schemas[s.Provider.Type]
+	if !ok {
+		return fmt.Sprintf(errs.Custom, fmt.Sprintf("provider.type has to be one of: %s, got %q", strings.Join(providerTypes(), ", "), s.Provider.Type))
+	}
+
+	var errMessage string
+	for _, key := range sortedKeys(s.Provider.CustomConfigurations) {
+		t, ok := schema[key]
+		if !ok {
+			errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("provider.customConfigurations.%s is not supported by the %s provider", key, s.Provider.Type))
+			continue
+		}
+		v, ok := t.convert(s.Provider.CustomConfigurations[key])
+		if !ok {
+			errMessage += fmt.Sprintf(errs.Custom, fmt.Sprintf("provider.customConfigurations.%s has to be of type %s", key, t))
+			continue
+		}
+		s.Provider.CustomConfigurations[key] = v
+	}
+	return errMessage
+}
+
+func useNumber(d *json.Decoder) *json.Decoder {
+	d.UseNumber()
+	return d
+}
@@ -0,0 +1,199 @@
+package spec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/types"
+	"github.com/stretchr/testify/require"
+)
+
+const gardenerSpec = `
+apiVersion: hydroform.kyma-project.io/v1alpha1
+kind: Cluster
+cluster:
+  name: hydro
+  kubernetesVersion: "1.17"
+  diskSizeGB: 30
+  nodeCount: 2
+  location: eu-west-1
+  machineType: m5.xlarge
+  expiresAt: 2021-03-01T12:00:00Z
+provider:
+  type: gardener
+  projectName: my-project
+  credentialsFilePath: /path/to/kubeconfig.yaml
+  customConfigurations:
+    target_provider: aws
+    target_secret: aws-secret
+    disk_type: gp2
+    vnetcidr: 10.250.0.0/16
+    zones: [eu-west-1a, eu-west-1b]
+    worker_minimum: 2
+    worker_maximum: 4
+    privileged_containers: true
+options:
+  persistent: true
+  dataDir: /tmp/hydroform
+  timeouts:
+    create: 45m
+    delete: 1h
+  stateBackend:
+    kubernetes:
+      kubeconfigPath: /path/to/state/kubeconfig.yaml
+      namespace: hydroform
+  modules:
+    gardener:
+      source: git::https://example.com/hydroform-modules.git//gardener
+  providerMirror:
+    dir: /opt/terraform/plugins
+    lockFile: /opt/terraform/providers.json
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	s, err := Parse([]byte(gardenerSpec))
+	require.NoError(t, err)
+
+	require.Equal(t, &types.Cluster{
+		Name:              "hydro",
+		KubernetesVersion: "1.17",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-west-1",
+		MachineType:       "m5.xlarge",
+		ExpiresAt:         time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+	}, s.Cluster)
+
+	require.Equal(t, types.Gardener, s.Provider.Type)
+	require.Equal(t, "my-project", s.Provider.ProjectName)
+	require.Equal(t, []string{"eu-west-1a", "eu-west-1b"}, s.Provider.CustomConfigurations["zones"], "Lists should be converted to string slices")
+	require.Equal(t, 2, s.Provider.CustomConfigurations["worker_minimum"], "Integers should be converted to int")
+	require.Equal(t, true, s.Provider.CustomConfigurations["privileged_containers"])
+	require.Equal(t, "gp2", s.Provider.CustomConfigurations["disk_type"])
+
+	ops := &types.Options{}
+	for _, o := range s.Ops() {
+		o(ops)
+	}
+	require.True(t, ops.Persistent)
+	require.Equal(t, "/tmp/hydroform", ops.DataDir)
+	require.Equal(t, &types.Timeouts{Create: 45 * time.Minute, Delete: time.Hour}, ops.Timeouts)
+	require.Equal(t, "hydroform", ops.StateBackend.Kubernetes.Namespace)
+	require.Equal(t, "git::https://example.com/hydroform-modules.git//gardener", ops.ClusterModules[types.Gardener].Source)
+	require.Equal(t, &types.ProviderMirror{Dir: "/opt/terraform/plugins", LockFile: "/opt/terraform/providers.json"}, ops.ProviderMirror)
+
+	// JSON specs are read the same way
+	s, err = Parse([]byte(`{
+		"apiVersion": "hydroform.kyma-project.io/v1alpha1",
+		"kind": "Cluster",
+		"cluster": {"name": "kind-cluster"},
+		"provider": {"type": "kind", "projectName": "local", "customConfigurations": {"node_image": "kindest/node:v1.17.0"}}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "kindest/node:v1.17.0", s.Provider.CustomConfigurations["node_image"])
+	require.Empty(t, s.Ops(), "A spec without options should not set any option")
+
+	// the custom configurations of each provider are the ones of its typed configuration
+	s, err = Parse([]byte(`{
+		"apiVersion": "hydroform.kyma-project.io/v1alpha1",
+		"kind": "Cluster",
+		"cluster": {"name": "aks-cluster"},
+		"provider": {"type": "azure", "projectName": "my-project", "customConfigurations": {"resource_group": "my-group"}}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "my-group", s.Provider.CustomConfigurations["resource_group"])
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		spec string
+		err  string
+	}{
+		{
+			name: "unsupported version",
+			spec: "apiVersion: hydroform.kyma-project.io/v2\nkind: Cluster\ncluster: {name: c}\nprovider: {type: gcp}",
+			err:  "apiVersion has to be hydroform.kyma-project.io/v1alpha1",
+		},
+		{
+			name: "wrong kind",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Pod\ncluster: {name: c}\nprovider: {type: gcp}",
+			err:  "kind has to be Cluster",
+		},
+		{
+			name: "missing cluster and provider",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster",
+			err:  "cluster cannot be empty",
+		},
+		{
+			name: "cluster info",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster\ncluster: {name: c, clusterInfo: {endpoint: x}}\nprovider: {type: gcp}",
+			err:  "cluster.clusterInfo is set by Hydroform",
+		},
+		{
+			name: "unknown field",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster\ncluster: {name: c, nodes: 3}\nprovider: {type: gcp}",
+			err:  "unknown field",
+		},
+		{
+			name: "unknown provider",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster\ncluster: {name: c}\nprovider: {type: nimbus}",
+			err:  "provider.type has to be one of: aws, azure, gardener, gcp, k3d, kind",
+		},
+		{
+			name: "unsupported custom configuration",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster\ncluster: {name: c}\nprovider: {type: kind, customConfigurations: {node_img: x}}",
+			err:  "provider.customConfigurations.node_img is not supported by the kind provider",
+		},
+		{
+			name: "wrong custom configuration type",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster\ncluster: {name: c}\nprovider: {type: gardener, customConfigurations: {worker_minimum: 1.5, zones: eu-west-1a}}",
+			err:  "provider.customConfigurations.worker_minimum has to be of type integer",
+		},
+		{
+			name: "invalid timeout",
+			spec: "apiVersion: hydroform.kyma-project.io/v1alpha1\nkind: Cluster\ncluster: {name: c}\nprovider: {type: gcp}\noptions: {timeouts: {create: soon}}",
+			err:  "invalid duration",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(c.spec))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "hydroform-spec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cluster.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(gardenerSpec), 0600))
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "hydro", s.Cluster.Name)
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err, "A missing file should fail")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("kind: Cluster"), 0600))
+	_, err = Load(path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), path, "The error should point to the spec file")
+}
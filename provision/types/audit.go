@@ -0,0 +1,55 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// AuditSink records the Hydroform operations, such as who provisioned or deprovisioned which cluster, when, and with which outcome.
+type AuditSink interface {
+	// Record stores the record of an operation. It is called once the operation is over, whether it succeeded or not.
+	Record(ctx context.Context, r *AuditRecord) error
+}
+
+// AuditOutcome tells whether an audited operation succeeded.
+type AuditOutcome string
+
+const (
+	AuditSucceeded AuditOutcome = "succeeded"
+	AuditFailed    AuditOutcome = "failed"
+)
+
+// AuditRecord describes an operation for the audit log.
+type AuditRecord struct {
+	// Time is when the operation started.
+	Time time.Time `json:"time"`
+	// Duration is how long the operation took.
+	Duration time.Duration `json:"duration"`
+	// Actor is who ran the operation, as set with WithAuditActor, or the user running the process.
+	Actor     string    `json:"actor"`
+	Operation Operation `json:"operation"`
+	// Cluster holds the parameters of the cluster, without its ClusterInfo.
+	Cluster *Cluster `json:"cluster"`
+	// Provider holds the parameters of the provider, with the values of the secret custom configurations, such as client_secret, redacted.
+	Provider *Provider    `json:"provider"`
+	Outcome  AuditOutcome `json:"outcome"`
+	// Error is the error of a failed operation.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditError is returned by an operation that succeeded, but could not be recorded in the audit sinks.
+// The operation returns its result along with the error, such as the provisioned cluster, which exists and must not be lost.
+// Retrieve it from the error returned by an operation with errors.As.
+type AuditError struct {
+	Operation Operation
+	// Err is the first error of the sinks.
+	Err error
+}
+
+func (e *AuditError) Error() string {
+	return "could not record the operation in the audit sinks: " + e.Err.Error()
+}
+
+func (e *AuditError) Unwrap() error {
+	return e.Err
+}
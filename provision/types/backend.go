@@ -0,0 +1,37 @@
+package types
+
+// StateBackend configures where the state of clusters is stored.
+// Set only one of the backends. If none is set, the state is stored in the data directory.
+// The backends lock the state of a cluster while an operation changes it, except the S3 backend with Unlocked set.
+type StateBackend struct {
+	Kubernetes *KubernetesStateBackend `json:"kubernetes,omitempty"`
+	S3         *S3StateBackend         `json:"s3,omitempty"`
+}
+
+// KubernetesStateBackend stores the state of each cluster in a Secret of another Kubernetes cluster.
+type KubernetesStateBackend struct {
+	// KubeconfigPath is the path to the kubeconfig of the cluster holding the state.
+	KubeconfigPath string `json:"kubeconfigPath"`
+	// Namespace holding the state Secrets. Defaults to "default".
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// S3StateBackend stores the state of each cluster as an object in an S3 bucket or an S3-compatible storage.
+type S3StateBackend struct {
+	// Bucket holding the state objects.
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to the key of each state object.
+	Prefix string `json:"prefix,omitempty"`
+	// Region of the bucket.
+	Region string `json:"region,omitempty"`
+	// Endpoint of an S3-compatible storage. Leave it empty to use AWS S3.
+	Endpoint string `json:"endpoint,omitempty"`
+	// CredentialsFilePath is the path to an AWS shared credentials file. Leave it empty to use the default AWS credentials chain.
+	CredentialsFilePath string `json:"credentialsFilePath,omitempty"`
+	// Profile in the credentials file. Defaults to "default".
+	Profile string `json:"profile,omitempty"`
+	// LockTable is the name of a DynamoDB table with the "LockID" string partition key, used to lock the state. It is required unless Unlocked is set.
+	LockTable string `json:"lockTable,omitempty"`
+	// Unlocked allows the backend to work without a lock table, leaving the state unlocked. This is only safe if a single user manages each cluster.
+	Unlocked bool `json:"unlocked,omitempty"`
+}
@@ -1,6 +1,17 @@
 package types
 
-import "github.com/hashicorp/terraform/states/statefile"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform/states/statefile"
+)
+
+// ExpiresAtLabel is the label, or tag, recording on the resources of a cluster when the cluster expires, in seconds since the Unix epoch.
+const ExpiresAtLabel = "hydroform-expires-at"
 
 // Cluster contains detailed cluster specification and properties.
 type Cluster struct {
@@ -17,10 +28,22 @@ type Cluster struct {
 	// MachineType specifies the hardware cluster is provisioned on.
 	MachineType string `json:"machineType"`
 	// Location specifies the location of the actual cluster.
-	Location    string       `json:"location"`
+	Location string `json:"location"`
+	// ExpiresAt specifies when the cluster can be deleted by Reap. The cluster does not expire if ExpiresAt is zero.
+	// It is recorded in the ExpiresAtLabel of the resources of AWS, GCP, Gardener, and k3d clusters. Azure and Kind clusters carry no labels,
+	// so their expiry is only recorded in the metadata the Terraform operator stores next to their state.
+	ExpiresAt   time.Time    `json:"expiresAt,omitempty"`
 	ClusterInfo *ClusterInfo `json:"clusterInfo"`
 }
 
+// Labels returns the labels Hydroform records on the resources of the cluster, or nil if there are none.
+func (c *Cluster) Labels() map[string]string {
+	if c.ExpiresAt.IsZero() {
+		return nil
+	}
+	return map[string]string{ExpiresAtLabel: strconv.FormatInt(c.ExpiresAt.Unix(), 10)}
+}
+
 // ClusterInfo contains the actual provider-related cluster details retrieved after the cluster was provisioned.
 type ClusterInfo struct {
 	// Endpoint specifies the URL at which you can reach the cluster.
@@ -35,6 +58,8 @@ type ClusterInfo struct {
 // ClusterStatus contains possible values used to indicate the current cluster status.
 type ClusterStatus struct {
 	Phase Phase `json:"phase"`
+	// Conditions contains the results of the health checks run against a provisioned cluster.
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // Phase indicates the current status of the cluster.
@@ -43,13 +68,93 @@ type Phase string
 const (
 	// Provisioned indicates that the cluster has been created and is fully usable.
 	Provisioned Phase = "Provisioned"
+	// Provisioning indicates that the cluster is being created or updated.
+	Provisioning Phase = "Provisioning"
+	// Degraded indicates that the cluster is reachable but some of its nodes or system pods are not ready.
+	Degraded Phase = "Degraded"
+	// Deleting indicates that the cluster is being deleted.
+	Deleting Phase = "Deleting"
 	// Errored indicates that the cluster may be unusable due to errors.
 	Errored Phase = "Errored"
 	// Unknown indicates that the cluster status is not known.
 	Unknown Phase = "Unknown"
 )
 
+// Condition describes the result of a health check of the cluster.
+type Condition struct {
+	Type   ConditionType   `json:"type"`
+	Status ConditionStatus `json:"status"`
+	// Reason is a short CamelCase explanation of the status.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable description of the status.
+	Message string `json:"message,omitempty"`
+}
+
+// ConditionType indicates what a health check verifies.
+type ConditionType string
+
+const (
+	// APIServerReachable indicates whether the API server of the cluster answers requests.
+	APIServerReachable ConditionType = "APIServerReachable"
+	// NodesReady indicates whether all nodes of the cluster are ready.
+	NodesReady ConditionType = "NodesReady"
+	// SystemPodsReady indicates whether all pods in the kube-system namespace are ready.
+	SystemPodsReady ConditionType = "SystemPodsReady"
+)
+
+// ConditionStatus indicates the result of a health check.
+type ConditionStatus string
+
+const (
+	// ConditionTrue indicates that the health check passed.
+	ConditionTrue ConditionStatus = "True"
+	// ConditionFalse indicates that the health check failed.
+	ConditionFalse ConditionStatus = "False"
+	// ConditionUnknown indicates that the health check could not be run.
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
 // InternalState holds the state information of the internal operator which is currently in use. Hydroform uses this information for internal purposes only.
+// It is encoded to JSON with the Terraform state in the format of a state file, so that a cluster can be stored as JSON and decoded again.
 type InternalState struct {
 	TerraformState *statefile.File
 }
+
+// internalStateJSON is the JSON representation of an InternalState.
+type internalStateJSON struct {
+	TerraformState json.RawMessage `json:"terraformState,omitempty"`
+}
+
+// MarshalJSON encodes the Terraform state like a state file.
+func (s InternalState) MarshalJSON() ([]byte, error) {
+	var j internalStateJSON
+	if s.TerraformState != nil && s.TerraformState.State != nil {
+		// writing the state sets its terraform version, which must not change the state of the cluster
+		sf := *s.TerraformState
+		var buf bytes.Buffer
+		if err := statefile.Write(&sf, &buf); err != nil {
+			return nil, fmt.Errorf("could not encode the terraform state: %w", err)
+		}
+		j.TerraformState = buf.Bytes()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes the Terraform state written by MarshalJSON.
+func (s *InternalState) UnmarshalJSON(data []byte) error {
+	var j internalStateJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	s.TerraformState = nil
+	if len(j.TerraformState) == 0 {
+		return nil
+	}
+	sf, err := statefile.Read(bytes.NewReader(j.TerraformState))
+	if err != nil {
+		return fmt.Errorf("could not decode the terraform state: %w", err)
+	}
+	s.TerraformState = sf
+	return nil
+}
@@ -0,0 +1,150 @@
+package types
+
+// ProviderConfig is a typed configuration of a provider. Set it as Provider.Config instead of filling Provider.CustomConfigurations by hand.
+// The fields of a configuration are tagged with:
+//   - config: the custom configuration key the field stands for.
+//   - default: the value used if the field is left empty.
+//   - validate: the comma-separated rules the value has to meet: "required", "min=<n>" for numbers, and "cidr" for network ranges.
+type ProviderConfig interface {
+	// ProviderType returns the type of provider the configuration is meant for.
+	ProviderType() ProviderType
+}
+
+// GardenerWorkerConfig contains the worker and networking configuration shared by all Gardener target providers.
+type GardenerWorkerConfig struct {
+	// TargetSecret is the name of the secret binding with the credentials of the target provider in the Gardener project.
+	TargetSecret string `config:"target_secret" validate:"required"`
+	// DiskType is the type of the disks of the worker nodes.
+	DiskType string `config:"disk_type" validate:"required"`
+	// WorkerMinimum is the minimum number of worker nodes.
+	WorkerMinimum int `config:"worker_minimum" default:"1" validate:"required,min=1"`
+	// WorkerMaximum is the maximum number of worker nodes.
+	WorkerMaximum int `config:"worker_maximum" default:"3" validate:"required,min=1"`
+	// WorkerMaxSurge is the number of worker nodes that can be added above the maximum during an update.
+	WorkerMaxSurge int `config:"worker_max_surge" default:"1" validate:"required,min=0"`
+	// WorkerMaxUnavailable is the number of worker nodes that can be unavailable during an update.
+	WorkerMaxUnavailable int `config:"worker_max_unavailable" validate:"required,min=0"`
+	// NetworkingType is the network plugin of the cluster.
+	NetworkingType string `config:"networking_type" default:"calico" validate:"required"`
+	// NetworkingNodes is the network range of the nodes. It defaults to the worker network range of the target provider.
+	NetworkingNodes string `config:"networking_nodes" validate:"cidr"`
+	// NetworkingPods is the network range of the pods.
+	NetworkingPods string `config:"networking_pods" validate:"cidr"`
+	// NetworkingServices is the network range of the services.
+	NetworkingServices string `config:"networking_services" validate:"cidr"`
+	// PrivilegedContainers allows running privileged containers in the cluster.
+	PrivilegedContainers bool `config:"privileged_containers"`
+}
+
+// GardenerGCPConfig configures a Gardener cluster running on GCP.
+type GardenerGCPConfig struct {
+	GardenerWorkerConfig
+	// Zones are the zones of the worker nodes.
+	Zones []string `config:"zones" validate:"required"`
+	// WorkerCIDR is the network range of the worker nodes.
+	WorkerCIDR string `config:"workercidr" default:"10.250.0.0/19" validate:"required,cidr"`
+	// ControlPlaneZone is the zone of the control plane.
+	ControlPlaneZone string `config:"gcp_control_plane_zone" validate:"required"`
+	// MachineImageName is the name of the operating system image of the worker nodes, such as "gardenlinux".
+	MachineImageName string `config:"machine_image_name"`
+	// MachineImageVersion is the version of the operating system image of the worker nodes.
+	MachineImageVersion string `config:"machine_image_version"`
+}
+
+// GardenerAzureConfig configures a Gardener cluster running on Azure.
+type GardenerAzureConfig struct {
+	GardenerWorkerConfig
+	// VnetCIDR is the network range of the virtual network of the cluster.
+	VnetCIDR string `config:"vnetcidr" default:"10.250.0.0/16" validate:"required,cidr"`
+	// WorkerCIDR is the network range of the worker nodes.
+	WorkerCIDR string `config:"workercidr" default:"10.250.0.0/19" validate:"required,cidr"`
+	// Zones are the zones of the worker nodes. Leave it empty for a cluster without zones.
+	Zones []string `config:"zones"`
+	// ServiceEndpoints are the service endpoints of the worker subnet, such as "Microsoft.Storage".
+	ServiceEndpoints []string `config:"service_endpoints"`
+	// MachineImageName is the name of the operating system image of the worker nodes.
+	MachineImageName string `config:"machine_image_name" validate:"required"`
+	// MachineImageVersion is the version of the operating system image of the worker nodes.
+	MachineImageVersion string `config:"machine_image_version" validate:"required"`
+}
+
+// GardenerAWSConfig configures a Gardener cluster running on AWS.
+type GardenerAWSConfig struct {
+	GardenerWorkerConfig
+	// VnetCIDR is the network range of the VPC of the cluster, which is split among the zones.
+	VnetCIDR string `config:"vnetcidr" default:"10.250.0.0/16" validate:"required,cidr"`
+	// Zones are the zones of the worker nodes.
+	Zones []string `config:"zones" validate:"required"`
+	// MachineImageName is the name of the operating system image of the worker nodes, such as "gardenlinux".
+	MachineImageName string `config:"machine_image_name"`
+	// MachineImageVersion is the version of the operating system image of the worker nodes.
+	MachineImageVersion string `config:"machine_image_version"`
+}
+
+// KindConfig configures a kind cluster.
+type KindConfig struct {
+	// NodeImage is the image of the kind nodes, which sets the Kubernetes version of the cluster.
+	NodeImage string `config:"node_image" validate:"required"`
+	// ControlPlaneNodes is the number of control plane nodes. The number of worker nodes is Cluster.NodeCount.
+	ControlPlaneNodes int `config:"control_plane_nodes" default:"1" validate:"min=1"`
+	// ExtraPortMappings map ports of the host to the first control plane node, such as "80:80" or "443:443/TCP" for an ingress.
+	ExtraPortMappings []string `config:"extra_port_mappings"`
+	// RegistryMirror is the URL of a registry the nodes pull Docker Hub images from, such as "http://kind-registry:5000".
+	RegistryMirror string `config:"registry_mirror"`
+	// FeatureGates are the Kubernetes feature gates of the cluster, such as "EphemeralContainers=true".
+	FeatureGates []string `config:"feature_gates"`
+}
+
+// K3dConfig configures a k3d cluster.
+type K3dConfig struct {
+	// Image is the k3s image of the nodes, such as "rancher/k3s:v1.20.4-k3s1". It defaults to the image of Cluster.KubernetesVersion, or to the default of k3d.
+	Image string `config:"image"`
+	// Servers is the number of server nodes. The number of agent nodes is Cluster.NodeCount.
+	Servers int `config:"servers" default:"1" validate:"min=1"`
+	// PortMappings map ports of the host to the nodes in the format of k3d v4, such as "80:80@loadbalancer" or "8080:80@server[0]".
+	PortMappings []string `config:"port_mappings"`
+}
+
+// AWSConfig configures an EKS cluster.
+type AWSConfig struct {
+	// Profile is the profile of the shared credentials file in Provider.CredentialsFilePath.
+	Profile string `config:"profile" default:"default"`
+	// VPCCIDR is the network range of the VPC of the cluster.
+	VPCCIDR string `config:"vpc_cidr" default:"10.0.0.0/16" validate:"cidr"`
+}
+
+// AzureConfig configures an AKS cluster.
+type AzureConfig struct {
+	// ResourceGroup is the resource group of the cluster. It defaults to Provider.ProjectName.
+	ResourceGroup string `config:"resource_group"`
+}
+
+// ProviderType returns Gardener.
+func (GardenerGCPConfig) ProviderType() ProviderType { return Gardener }
+
+// TargetProvider returns GCP, the provider Gardener runs the cluster on.
+func (GardenerGCPConfig) TargetProvider() ProviderType { return GCP }
+
+// ProviderType returns Gardener.
+func (GardenerAzureConfig) ProviderType() ProviderType { return Gardener }
+
+// TargetProvider returns Azure, the provider Gardener runs the cluster on.
+func (GardenerAzureConfig) TargetProvider() ProviderType { return Azure }
+
+// ProviderType returns Gardener.
+func (GardenerAWSConfig) ProviderType() ProviderType { return Gardener }
+
+// TargetProvider returns AWS, the provider Gardener runs the cluster on.
+func (GardenerAWSConfig) TargetProvider() ProviderType { return AWS }
+
+// ProviderType returns Kind.
+func (KindConfig) ProviderType() ProviderType { return Kind }
+
+// ProviderType returns K3d.
+func (K3dConfig) ProviderType() ProviderType { return K3d }
+
+// ProviderType returns AWS.
+func (AWSConfig) ProviderType() ProviderType { return AWS }
+
+// ProviderType returns Azure.
+func (AzureConfig) ProviderType() ProviderType { return Azure }
@@ -0,0 +1,22 @@
+package types
+
+// ServiceAccount describes the ServiceAccount Credentials creates in the cluster to return a kubeconfig with a static token.
+type ServiceAccount struct {
+	// Name of the ServiceAccount.
+	Name string `json:"name"`
+	// Namespace of the ServiceAccount. Defaults to "kube-system".
+	Namespace string `json:"namespace,omitempty"`
+	// ClusterRole bound to the ServiceAccount, such as "view" or "cluster-admin".
+	ClusterRole string `json:"clusterRole"`
+}
+
+// ExecAuth describes a client-go credential plugin the kubeconfig returned by Credentials runs to authenticate, instead of the default of the provider.
+type ExecAuth struct {
+	// Command to run, such as "gke-gcloud-auth-plugin".
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// Env are additional environment variables of the command.
+	Env map[string]string `json:"env,omitempty"`
+	// APIVersion of the ExecCredential the command returns. Defaults to "client.authentication.k8s.io/v1beta1".
+	APIVersion string `json:"apiVersion,omitempty"`
+}
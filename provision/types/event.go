@@ -0,0 +1,26 @@
+package types
+
+// Operation names a Hydroform operation.
+type Operation string
+
+const (
+	ProvisionOperation   Operation = "provision"
+	StatusOperation      Operation = "status"
+	CredentialsOperation Operation = "credentials"
+	UpdateOperation      Operation = "update"
+	PlanOperation        Operation = "plan"
+	ImportOperation      Operation = "import"
+	DeprovisionOperation Operation = "deprovision"
+	PreflightOperation   Operation = "preflight"
+)
+
+// Event describes a Hydroform operation. It is the only argument of the actions passed with WithBeforeAction, WithAfterAction, and OnError.
+type Event struct {
+	Operation Operation
+	Cluster   *Cluster
+	Provider  *Provider
+	// Result of the operation, such as the provisioned *Cluster or the *ClusterStatus. It is nil before the operation runs.
+	Result interface{}
+	// Err is the error of the operation or of its actions. It is only set for the actions passed with OnError.
+	Err error
+}
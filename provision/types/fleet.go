@@ -0,0 +1,108 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FleetMember is a cluster of a fleet along with its provider.
+type FleetMember struct {
+	Cluster  *Cluster
+	Provider *Provider
+	// Ops are options for this member only. They are applied after the options of the fleet, so they take precedence.
+	Ops []Option
+}
+
+// Key returns the key identifying the cluster of the member in the fleet.
+func (m FleetMember) Key() FleetKey {
+	return FleetKey{Provider: m.Provider.Type, Project: m.Provider.ProjectName, Cluster: m.Cluster.Name}
+}
+
+// FleetKey identifies a cluster of a fleet. Clusters of different providers or projects can have the same name.
+type FleetKey struct {
+	Provider ProviderType
+	Project  string
+	Cluster  string
+}
+
+func (k FleetKey) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.Provider, k.Project, k.Cluster)
+}
+
+// FleetResult is the outcome of an operation on a single member of a fleet.
+type FleetResult struct {
+	// Cluster is the cluster returned by the operation, such as the provisioned cluster, or the given cluster if the operation returned none.
+	Cluster  *Cluster
+	Provider *Provider
+	// Err is the error of the operation on this member, or nil if it succeeded.
+	Err error
+	// Duration is the time the operation on this member took.
+	Duration time.Duration
+}
+
+// FleetReport aggregates the results of an operation on all members of a fleet, in the order of the members.
+type FleetReport struct {
+	Operation Operation
+	Results   []FleetResult
+}
+
+// Succeeded returns the results of the members the operation succeeded on.
+func (r *FleetReport) Succeeded() []FleetResult {
+	var res []FleetResult
+	for _, fr := range r.Results {
+		if fr.Err == nil {
+			res = append(res, fr)
+		}
+	}
+	return res
+}
+
+// Failed returns the results of the members the operation failed on.
+func (r *FleetReport) Failed() []FleetResult {
+	var res []FleetResult
+	for _, fr := range r.Results {
+		if fr.Err != nil {
+			res = append(res, fr)
+		}
+	}
+	return res
+}
+
+// Err returns a *FleetError with the errors of all failed members, or nil if the operation succeeded on all of them.
+func (r *FleetReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	e := &FleetError{Operation: r.Operation, Total: len(r.Results), Errors: map[FleetKey]error{}}
+	for _, fr := range failed {
+		e.Errors[FleetMember{Cluster: fr.Cluster, Provider: fr.Provider}.Key()] = fr.Err
+	}
+	return e
+}
+
+// FleetError contains the errors of the members of a fleet an operation failed on, by the key of their cluster.
+// Retrieve it from the error returned by a fleet operation with errors.As.
+type FleetError struct {
+	Operation Operation
+	// Total is the number of members of the fleet.
+	Total  int
+	Errors map[FleetKey]error
+}
+
+func (e *FleetError) Error() string {
+	keys := make([]FleetKey, 0, len(e.Errors))
+	for k := range e.Errors {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	msg := fmt.Sprintf("%s failed for %d of %d clusters:", e.Operation, len(e.Errors), e.Total)
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, e.Errors[k]))
+	}
+	return msg + "\n - " + strings.Join(lines, "\n - ")
+}
@@ -0,0 +1,9 @@
+package types
+
+// ProviderMirror makes terraform use the provider plugins of a local directory instead of downloading them, so that clusters can be provisioned without internet access.
+type ProviderMirror struct {
+	// Dir contains the provider plugins, named like terraform-provider-google_v3.49.0, directly or in a subdirectory for the platform, such as linux_amd64.
+	Dir string `json:"dir"`
+	// LockFile is a JSON file pinning the version of each provider, such as {"google": "3.49.0"}. If empty, the newest plugins in Dir matching the cluster configuration are used.
+	LockFile string `json:"lockFile,omitempty"`
+}
@@ -0,0 +1,11 @@
+package types
+
+// ClusterModule replaces the terraform configuration Hydroform provisions the clusters of a provider with, for example to add network policies or labels to every cluster.
+// Set either Source or Template. The configuration must declare the outputs and the cluster resource of the built-in one,
+// such as the endpoint and cluster_ca_certificate outputs and the google_container_cluster.gke_cluster resource on GCP.
+type ClusterModule struct {
+	// Source is a terraform module source, such as a git URL or a local path, copied into the directory of the cluster like with "terraform init -from-module".
+	Source string `json:"source,omitempty"`
+	// Template is the HCL of a terraform configuration, written into the directory of the cluster instead of the built-in template.
+	Template string `json:"template,omitempty"`
+}
@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"io"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/provision/action"
+)
 
 // Options contains all possible configuration options for Hydroform.
 // Options need to be set each time a Hydroform function is called
@@ -8,7 +13,38 @@ type Options struct {
 	DataDir    string
 	Persistent bool
 	Timeouts   *Timeouts
-	Verbose    bool // Print terraform log for debugging
+	Verbose    bool // Print terraform output for debugging
+	// Output receives the output of terraform for each operation. If nil, it is discarded unless Verbose is set.
+	Output io.Writer
+	// PlanDestroy makes Plan describe the deprovisioning of a cluster instead of its provisioning or update.
+	PlanDestroy bool
+	// StateBackend configures where the state of clusters is stored. If nil, the state is stored in the data directory.
+	StateBackend *StateBackend
+	// BeforeActions, AfterActions, and ErrorActions run along with the operation they are passed to, receiving an *Event.
+	BeforeActions []action.Action
+	AfterActions  []action.Action
+	ErrorActions  []action.Action
+	// Progress receives the progress events of the operations changing the infrastructure of a cluster.
+	Progress func(ProgressEvent)
+	// ServiceAccount makes Credentials return a kubeconfig with the token of a ServiceAccount it creates in the cluster.
+	ServiceAccount *ServiceAccount
+	// ExecAuth makes Credentials return a kubeconfig authenticating with the given credential plugin.
+	ExecAuth *ExecAuth
+	// DryRun makes Reap only list the expired clusters instead of deprovisioning them.
+	DryRun bool
+	// ClusterModules replace the terraform configuration of the clusters of a provider.
+	ClusterModules map[ProviderType]ClusterModule
+	// ProviderMirror makes terraform use local provider plugins instead of downloading them.
+	ProviderMirror *ProviderMirror
+	// AuditSinks record every operation, and AuditActor is who runs them.
+	AuditSinks []AuditSink
+	AuditActor string
+	// PreflightCheckers check whether the provider can run a cluster before it is provisioned or updated, by provider type.
+	PreflightCheckers map[ProviderType]PreflightChecker
+	// MaxMonthlyCost makes the preflight checks fail if the estimated monthly cost of a cluster exceeds it. Zero means no limit.
+	MaxMonthlyCost float64
+	// NativeOperator manages clusters through the API of their provider instead of Terraform. Only the Gardener provider supports it, k3d always uses it.
+	NativeOperator bool
 }
 
 // Timeouts specifies timeouts on various operation
@@ -47,3 +83,157 @@ func Verbose(verbose bool) Option {
 		ops.Verbose = verbose
 	}
 }
+
+// WithOutput writes the output of terraform to the given writer, line by line, such as the changes of the resources and the errors.
+// Each operation writes its own output, so use a different writer for each concurrent operation to keep their output apart.
+// Hydroform does not redirect the standard logger or os.Stderr: terraform logs through the standard logger and its plugins log to os.Stderr, as configured by the application and TF_LOG.
+func WithOutput(w io.Writer) Option {
+	return func(ops *Options) {
+		ops.Output = w
+	}
+}
+
+// PlanDestroy makes Plan describe the changes of deprovisioning the cluster instead of provisioning or updating it.
+func PlanDestroy() Option {
+	return func(ops *Options) {
+		ops.PlanDestroy = true
+	}
+}
+
+// WithServiceAccount makes Credentials create the given ServiceAccount in the cluster, bind it to its ClusterRole, and return a self-contained kubeconfig with its token.
+// Such a kubeconfig works without the command line tools of the provider, such as in CI containers.
+// The ServiceAccount is created with the admin identity of the cluster. Calling Credentials again returns the token of the existing ServiceAccount.
+func WithServiceAccount(sa ServiceAccount) Option {
+	return func(ops *Options) {
+		ops.ServiceAccount = &sa
+	}
+}
+
+// WithExecAuth makes Credentials return a kubeconfig that runs the given credential plugin to authenticate, instead of the authentication of the provider.
+func WithExecAuth(exec ExecAuth) Option {
+	return func(ops *Options) {
+		ops.ExecAuth = &exec
+	}
+}
+
+// WithClusterModule makes Hydroform provision the clusters of the given provider with a custom terraform module source or template instead of the built-in one.
+// The override has to declare the outputs and the cluster resource of the built-in configuration, otherwise the operations fail.
+func WithClusterModule(p ProviderType, m ClusterModule) Option {
+	return func(ops *Options) {
+		if ops.ClusterModules == nil {
+			ops.ClusterModules = make(map[ProviderType]ClusterModule)
+		}
+		ops.ClusterModules[p] = m
+	}
+}
+
+// WithProviderMirror makes terraform use the provider plugins in the directory of the mirror instead of downloading them, pinned to the versions of its lock file.
+// The operations fail before changing any resource if the mirror misses a provider, listing all providers and versions the cluster needs.
+// To provision clusters without internet access, custom modules also have to be local, see WithClusterModule.
+func WithProviderMirror(m ProviderMirror) Option {
+	return func(ops *Options) {
+		ops.ProviderMirror = &m
+	}
+}
+
+// WithAuditSink records the operation in the given sink once it is over, with its parameters and its outcome.
+// The values of the custom configurations holding secrets, such as client_secret or access_key, are redacted from the record.
+// If recording fails, the operation returns the error of the sink as well, as an AuditError along with its result if the operation itself succeeded. Pass the option several times to record in several sinks.
+func WithAuditSink(s AuditSink) Option {
+	return func(ops *Options) {
+		ops.AuditSinks = append(ops.AuditSinks, s)
+	}
+}
+
+// WithAuditActor records the given actor as the one running the operation in the audit sinks, such as the user that requested a cluster from a service.
+// By default, the actor is the user running the process.
+func WithAuditActor(actor string) Option {
+	return func(ops *Options) {
+		ops.AuditActor = actor
+	}
+}
+
+// WithPreflightChecker makes Hydroform check the clusters of the given provider with the checker before provisioning or updating them.
+// All problems found, such as an unsupported Kubernetes version, an unavailable machine type, or a missing quota, are returned at once before any resource is changed.
+func WithPreflightChecker(p ProviderType, c PreflightChecker) Option {
+	return func(ops *Options) {
+		if ops.PreflightCheckers == nil {
+			ops.PreflightCheckers = make(map[ProviderType]PreflightChecker)
+		}
+		ops.PreflightCheckers[p] = c
+	}
+}
+
+// WithMaxMonthlyCost makes the preflight checks fail if the estimated monthly cost of a cluster exceeds the given amount, in the currency of the estimate.
+func WithMaxMonthlyCost(cost float64) Option {
+	return func(ops *Options) {
+		ops.MaxMonthlyCost = cost
+	}
+}
+
+// DryRun makes Reap only list the expired clusters instead of deprovisioning them.
+func DryRun() Option {
+	return func(ops *Options) {
+		ops.DryRun = true
+	}
+}
+
+// WithStateBackend stores the state of clusters in the given backend instead of the data directory.
+// Use a remote backend to safely share a cluster among several users or CI jobs.
+func WithStateBackend(backend *StateBackend) Option {
+	return func(ops *Options) {
+		ops.StateBackend = backend
+	}
+}
+
+// WithBeforeAction runs the given action before the operation, with the *Event of the operation as its only argument.
+// If the action fails, the operation does not run and returns the error of the action.
+// Unlike action.SetBefore, the action only applies to the call it is passed to, so concurrent operations do not interfere.
+func WithBeforeAction(a action.Action) Option {
+	return func(ops *Options) {
+		ops.BeforeActions = append(ops.BeforeActions, a)
+	}
+}
+
+// WithAfterAction runs the given action after the operation succeeded, with the *Event of the operation and its result as its only argument.
+// If the action fails, the operation returns the error of the action.
+func WithAfterAction(a action.Action) Option {
+	return func(ops *Options) {
+		ops.AfterActions = append(ops.AfterActions, a)
+	}
+}
+
+// OnError runs the given action if the operation or any of its actions failed, with the *Event of the operation and its error as its only argument.
+func OnError(a action.Action) Option {
+	return func(ops *Options) {
+		ops.ErrorActions = append(ops.ErrorActions, a)
+	}
+}
+
+// WithProgress calls the given function with the progress events of Provision, Update, and Deprovision, such as a resource that started or completed changing.
+// The function is called from the goroutines running the operation and must not block.
+func WithProgress(f func(ProgressEvent)) Option {
+	return func(ops *Options) {
+		ops.Progress = f
+	}
+}
+
+// WithProgressChannel sends the progress events of Provision, Update, and Deprovision to the given channel.
+// The events are dropped while the channel is full, so that the operation never waits for the receiver. Use a buffered channel to keep up with bursts of events. The channel is never closed.
+func WithProgressChannel(ch chan<- ProgressEvent) Option {
+	return WithProgress(func(e ProgressEvent) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+}
+
+// WithNativeOperator manages clusters directly through the API of their provider instead of Terraform.
+// Only the Gardener provider supports it, managing the Shoot of the cluster in the garden cluster of the credentials file.
+// Since Gardener keeps the state of the cluster, the state backend and data directory options do not apply.
+func WithNativeOperator() Option {
+	return func(ops *Options) {
+		ops.NativeOperator = true
+	}
+}
@@ -0,0 +1,47 @@
+package types
+
+// Plan describes the changes a Hydroform operation would make to the infrastructure of a cluster, without applying any of them.
+type Plan struct {
+	// Changes lists the resources affected by the operation.
+	Changes []ResourceChange `json:"changes"`
+}
+
+// ResourceChange describes the change planned for a single resource.
+type ResourceChange struct {
+	// Address identifies the resource in the provider configuration, such as google_container_cluster.gke_cluster.
+	Address string `json:"address"`
+	// Type is the provider-specific type of the resource, such as google_container_cluster.
+	Type string `json:"type"`
+	// Action indicates what will happen to the resource.
+	Action ChangeAction `json:"action"`
+}
+
+// ChangeAction indicates what will happen to a resource.
+type ChangeAction string
+
+const (
+	// CreateAction indicates that the resource will be created.
+	CreateAction ChangeAction = "create"
+	// UpdateAction indicates that the resource will be changed in place.
+	UpdateAction ChangeAction = "update"
+	// ReplaceAction indicates that the resource will be destroyed and created again.
+	ReplaceAction ChangeAction = "replace"
+	// DeleteAction indicates that the resource will be destroyed.
+	DeleteAction ChangeAction = "delete"
+)
+
+// Count returns the number of resources affected by the given action.
+func (p *Plan) Count(a ChangeAction) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges returns true if applying the plan would change any resource.
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0
+}
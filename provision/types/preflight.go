@@ -0,0 +1,59 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// hoursPerMonth is the average number of hours in a month, which cloud providers use to price resources by the month.
+const hoursPerMonth = 730
+
+// PreflightChecker tells whether the provider can run a cluster, before any of its resources are created.
+// Implementations usually query the API of the provider. Each method is only called for the clusters of the provider the checker was set for.
+type PreflightChecker interface {
+	// KubernetesVersions returns the Kubernetes versions the provider supports in the location of the cluster.
+	KubernetesVersions(ctx context.Context, cluster *Cluster, provider *Provider) ([]string, error)
+	// MachineTypes returns the machine types available in the location of the cluster.
+	MachineTypes(ctx context.Context, cluster *Cluster, provider *Provider) ([]string, error)
+	// QuotaShortages returns the quotas of the provider that are too low to provision the cluster, or none if the cluster fits.
+	QuotaShortages(ctx context.Context, cluster *Cluster, provider *Provider) ([]QuotaShortage, error)
+	// EstimateCost returns the estimated cost of running the cluster.
+	EstimateCost(ctx context.Context, cluster *Cluster, provider *Provider) (*CostEstimate, error)
+}
+
+// QuotaShortage describes a quota of the provider that is too low to provision the cluster.
+type QuotaShortage struct {
+	// Metric is the name of the quota, such as "CPUS" or "IN_USE_ADDRESSES".
+	Metric    string  `json:"metric"`
+	Required  float64 `json:"required"`
+	Available float64 `json:"available"`
+}
+
+// CostEstimate is the estimated cost of running a cluster.
+type CostEstimate struct {
+	// Currency of the cost, such as "USD".
+	Currency string  `json:"currency"`
+	Hourly   float64 `json:"hourly"`
+}
+
+// Monthly returns the estimated cost of running the cluster for a month.
+func (c *CostEstimate) Monthly() float64 {
+	return c.Hourly * hoursPerMonth
+}
+
+// PreflightReport contains the results of the preflight checks of a cluster.
+type PreflightReport struct {
+	// Problems that prevent provisioning the cluster, such as an unsupported Kubernetes version. The cluster can be provisioned if there are none.
+	Problems []string `json:"problems,omitempty"`
+	// Cost is the estimated cost of the cluster, or nil if it was not estimated.
+	Cost *CostEstimate `json:"cost,omitempty"`
+}
+
+// Err returns an error listing all problems found, or nil if there are none.
+func (r *PreflightReport) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("preflight checks failed with the following information:\n - %s", strings.Join(r.Problems, "\n - "))
+}
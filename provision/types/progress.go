@@ -0,0 +1,45 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// ProgressEvent reports the progress of an operation on the infrastructure of a cluster.
+type ProgressEvent struct {
+	Type ProgressEventType `json:"type"`
+	// Time at which the event happened.
+	Time time.Time `json:"time"`
+	// Resource is the address of the resource, such as google_container_cluster.gke_cluster. It is empty for errors not related to a single resource.
+	Resource string `json:"resource,omitempty"`
+	// Action applied to the resource.
+	Action ChangeAction `json:"action,omitempty"`
+	// Elapsed is the time spent on the resource since its change started.
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+	// Message is the original message of the operator.
+	Message string `json:"message"`
+}
+
+// ProgressEventType indicates what a ProgressEvent reports.
+type ProgressEventType string
+
+const (
+	// ResourceStarted indicates that the change of a resource started.
+	ResourceStarted ProgressEventType = "ResourceStarted"
+	// ResourceInProgress indicates that the change of a resource is still running.
+	ResourceInProgress ProgressEventType = "ResourceInProgress"
+	// ResourceCompleted indicates that the change of a resource completed successfully.
+	ResourceCompleted ProgressEventType = "ResourceCompleted"
+	// ProgressError indicates an error reported by the operator.
+	ProgressError ProgressEventType = "Error"
+)
+
+// TerraformError contains the errors Terraform reported while running an operation.
+// Retrieve it from the error returned by an operation with errors.As.
+type TerraformError struct {
+	Errors []string
+}
+
+func (e *TerraformError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
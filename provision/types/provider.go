@@ -11,6 +11,9 @@ type Provider struct {
 	CredentialsFilePath string `json:"credentialsFilePath"`
 	// CustomConfigurations is a list of custom properties relevant for the chosen provider.
 	CustomConfigurations map[string]interface{} `json:"customConfigurations"`
+	// Config is a typed alternative to CustomConfigurations, such as a GardenerGCPConfig.
+	// Its values are added to CustomConfigurations, taking precedence over the ones already there.
+	Config ProviderConfig `json:"-"`
 }
 
 // ProviderType lists available cloud providers.
@@ -27,4 +30,6 @@ const (
 	Gardener ProviderType = "gardener"
 	// Kind stands for the kind (kubernetes in docker) platform.
 	Kind ProviderType = "kind"
+	// K3d stands for k3d, which runs k3s (lightweight Kubernetes) in docker.
+	K3d ProviderType = "k3d"
 )
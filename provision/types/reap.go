@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReapReport lists the clusters found by Reap, in the order of their provider, project, and name.
+type ReapReport struct {
+	// DryRun is true if the expired clusters were only listed.
+	DryRun   bool
+	Clusters []ReapedCluster
+}
+
+// ReapedCluster is a cluster found by Reap.
+type ReapedCluster struct {
+	Provider ProviderType
+	Project  string
+	Name     string
+	// ExpiresAt is when the cluster expires, or zero if it does not expire.
+	ExpiresAt time.Time
+	// Expired is true if the cluster expired when Reap ran.
+	Expired bool
+	// Deprovisioned is true if Reap deprovisioned the cluster.
+	Deprovisioned bool
+	// Err is the error that prevented reaping the cluster, such as the error of deprovisioning it, or nil if there was none.
+	Err error
+}
+
+// Expired returns the clusters that expired, whether they were deprovisioned or not.
+func (r *ReapReport) Expired() []ReapedCluster {
+	var res []ReapedCluster
+	for _, c := range r.Clusters {
+		if c.Expired {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Err returns an error listing the clusters that could not be reaped, or nil if there are none.
+func (r *ReapReport) Err() error {
+	var lines []string
+	for _, c := range r.Clusters {
+		if c.Err != nil {
+			lines = append(lines, fmt.Sprintf("%s/%s/%s: %s", c.Provider, c.Project, c.Name, c.Err))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return fmt.Errorf("could not reap %d of %d clusters:\n - %s", len(lines), len(r.Clusters), strings.Join(lines, "\n - "))
+}